@@ -12,9 +12,11 @@ import (
 
 	kxlcfg "github.com/linkyfish/kxl_backend_go/internal/config"
 	"github.com/linkyfish/kxl_backend_go/internal/router"
+	"github.com/linkyfish/kxl_backend_go/internal/service"
 	"github.com/linkyfish/kxl_backend_go/pkg/db"
 	kxlredis "github.com/linkyfish/kxl_backend_go/pkg/redis"
 	"github.com/linkyfish/kxl_backend_go/pkg/session"
+	"github.com/linkyfish/kxl_backend_go/pkg/storage"
 )
 
 func main() {
@@ -23,6 +25,18 @@ func main() {
 		log.Fatalf("load config: %v", err)
 	}
 
+	// Subcommands run against the same config and exit without starting the server.
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			os.Exit(runMigrate(cfg, os.Args[2:]))
+		case "seed":
+			os.Exit(runSeed(cfg, os.Args[2:]))
+		default:
+			log.Fatalf("unknown command %q (available: migrate, seed)", os.Args[1])
+		}
+	}
+
 	gormDB, err := db.ConnectPostgres(cfg)
 	if err != nil {
 		log.Fatalf("connect db: %v", err)
@@ -36,13 +50,29 @@ func main() {
 
 	sess := session.NewManager(redisClient, cfg)
 
+	store, err := storage.New(cfg)
+	if err != nil {
+		log.Fatalf("init storage: %v", err)
+	}
+
+	searchLog := service.NewSearchLogService(cfg, gormDB)
+	scheduler := service.NewPublishScheduler(gormDB, redisClient,
+		time.Duration(cfg.Scheduler.IntervalSeconds)*time.Second,
+		service.NewSearchService(cfg, gormDB, redisClient).InvalidateSuggestions)
+
 	e := router.New(router.Deps{
-		Cfg:   cfg,
-		DB:    gormDB,
-		Redis: redisClient,
-		Sess:  sess,
+		Cfg:       cfg,
+		DB:        gormDB,
+		Redis:     redisClient,
+		Sess:      sess,
+		Storage:   store,
+		SearchLog: searchLog,
+		Scheduler: scheduler,
 	})
 
+	schedCtx, stopScheduler := context.WithCancel(context.Background())
+	go scheduler.Run(schedCtx)
+
 	// Graceful shutdown.
 	go func() {
 		addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
@@ -54,11 +84,14 @@ func main() {
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 	<-stop
+	stopScheduler()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	if err := e.Shutdown(ctx); err != nil {
 		log.Printf("shutdown: %v", err)
 	}
+	if err := searchLog.Close(ctx); err != nil {
+		log.Printf("flush search log: %v", err)
+	}
 }
-
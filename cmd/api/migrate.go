@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	kxlcfg "github.com/linkyfish/kxl_backend_go/internal/config"
+	"github.com/linkyfish/kxl_backend_go/internal/migrate"
+	"github.com/linkyfish/kxl_backend_go/migrations"
+	"github.com/linkyfish/kxl_backend_go/pkg/db"
+)
+
+const migrateUsage = `usage: kxl-api migrate <command>
+
+commands:
+  up              apply all pending migrations
+  down [-steps N] roll back the last N applied migrations (default 1)
+  status          list migrations and whether they are applied`
+
+// runMigrate implements `kxl-api migrate ...` and returns the process exit code.
+func runMigrate(cfg *kxlcfg.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	gormDB, err := db.ConnectPostgres(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "connect db: %v\n", err)
+		return 1
+	}
+	m, err := migrate.New(gormDB, migrations.FS)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "load migrations: %v\n", err)
+		return 1
+	}
+
+	ctx := context.Background()
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx)
+		for _, mig := range applied {
+			fmt.Printf("applied %06d_%s\n", mig.Version, mig.Name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migrate up: %v\n", err)
+			return 1
+		}
+		if len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	case "down":
+		fs := flag.NewFlagSet("down", flag.ContinueOnError)
+		steps := fs.Int("steps", 1, "number of migrations to roll back")
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+		reverted, err := m.Down(ctx, *steps)
+		for _, mig := range reverted {
+			fmt.Printf("reverted %06d_%s\n", mig.Version, mig.Name)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migrate down: %v\n", err)
+			return 1
+		}
+	case "status":
+		rows, err := m.Status(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "migrate status: %v\n", err)
+			return 1
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, st := range rows {
+			appliedAt := "pending"
+			if st.AppliedAt != nil {
+				appliedAt = st.AppliedAt.UTC().Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%06d\t%s\t%s\n", st.Version, st.Name, appliedAt)
+		}
+		_ = w.Flush()
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+	return 0
+}
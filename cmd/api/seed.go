@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	kxlcfg "github.com/linkyfish/kxl_backend_go/internal/config"
+	"github.com/linkyfish/kxl_backend_go/internal/seed"
+	"github.com/linkyfish/kxl_backend_go/internal/service"
+	"github.com/linkyfish/kxl_backend_go/pkg/db"
+	kxlredis "github.com/linkyfish/kxl_backend_go/pkg/redis"
+)
+
+// runSeed implements `kxl-api seed [flags]` and returns the process exit code.
+// The admin password may also be supplied via KXL_SEED_ADMIN_PASSWORD to keep it out of shell history.
+func runSeed(cfg *kxlcfg.Config, args []string) int {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	username := fs.String("admin-username", "admin", "username of the initial super_admin")
+	password := fs.String("admin-password", "", "password of the initial super_admin (generated when empty)")
+	demo := fs.Bool("demo", false, "load demo content for local development")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *password == "" {
+		*password = strings.TrimSpace(os.Getenv("KXL_SEED_ADMIN_PASSWORD"))
+	}
+	if *demo && cfg.App.Env == "production" {
+		fmt.Fprintln(os.Stderr, "seed: refusing to load demo content when APP_ENV=production")
+		return 2
+	}
+
+	gormDB, err := db.ConnectPostgres(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "connect db: %v\n", err)
+		return 1
+	}
+
+	// Redis only holds the RBAC cache here; without it new grants take effect
+	// when the cached entries expire.
+	redisClient, err := kxlredis.NewClient(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "seed: redis unavailable, role permission cache not cleared: %v\n", err)
+	}
+
+	res, err := seed.Run(context.Background(), gormDB, seed.Options{
+		AdminUsername: *username,
+		AdminPassword: *password,
+		Demo:          *demo,
+		Rbac:          service.NewRbacService(gormDB, redisClient, cfg.Security.RbacCacheTTLSeconds),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "seed: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("permissions upserted: %d\n", res.Permissions)
+	if len(res.RolesCreated) > 0 {
+		fmt.Printf("roles created: %s\n", strings.Join(res.RolesCreated, ", "))
+	}
+	if res.GrantsAdded > 0 {
+		fmt.Printf("admin role permissions granted: %d\n", res.GrantsAdded)
+	}
+	if res.AdminCreated {
+		fmt.Printf("super_admin %q created\n", res.AdminUsername)
+		if res.GeneratedPassword != "" {
+			fmt.Printf("generated password: %s\n", res.GeneratedPassword)
+			fmt.Println("store it now; it will not be shown again")
+		}
+	} else {
+		fmt.Printf("admin %q already exists, password left unchanged\n", res.AdminUsername)
+	}
+	if *demo {
+		if res.DemoLoaded {
+			fmt.Println("demo content loaded")
+		} else {
+			fmt.Println("demo content skipped: content tables are not empty")
+		}
+	}
+	return 0
+}
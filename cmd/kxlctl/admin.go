@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/linkyfish/kxl_backend_go/internal/service"
+	"github.com/linkyfish/kxl_backend_go/internal/util"
+	"github.com/linkyfish/kxl_backend_go/pkg/session"
+)
+
+func runAdmin(e *env, cmd string, args []string) error {
+	ctx := context.Background()
+	switch cmd {
+	case "list":
+		rows, err := service.NewUserService(e.DB()).ListAdmins(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tUSERNAME\tROLE\tSTATUS\tCREATED AT")
+		for _, a := range rows {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", a.ID, a.Username, a.Role, statusLabel(a.Status), a.CreatedAt.Format("2006-01-02 15:04"))
+		}
+		return w.Flush()
+
+	case "reset-password":
+		fs := flag.NewFlagSet("reset-password", flag.ContinueOnError)
+		password := fs.String("password", "", "new password (generated when empty)")
+		if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+			return errUsage
+		}
+		users := service.NewUserService(e.DB())
+		admin, err := users.FindAdmin(ctx, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		generated := false
+		if *password == "" {
+			if *password, err = util.RandomString(20); err != nil {
+				return err
+			}
+			generated = true
+		}
+		if err := users.SetAdminPassword(ctx, admin.ID, *password); err != nil {
+			return err
+		}
+		revoked, err := e.Sessions().DeleteSessionsFor(ctx, session.KindAdmin, admin.ID)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("password reset for %s, %d session(s) revoked\n", admin.Username, revoked)
+		if generated {
+			fmt.Printf("generated password: %s\n", *password)
+		}
+		return nil
+
+	case "enable", "disable":
+		if len(args) != 1 {
+			return errUsage
+		}
+		users := service.NewUserService(e.DB())
+		admin, err := users.FindAdmin(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		status := int16(1)
+		if cmd == "disable" {
+			status = 0
+		}
+		if _, err := users.UpdateAdmin(ctx, admin.ID, admin.Username, admin.Role, status); err != nil {
+			return err
+		}
+		revoked := 0
+		if status == 0 {
+			if revoked, err = e.Sessions().DeleteSessionsFor(ctx, session.KindAdmin, admin.ID); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("admin %s %sd, %d session(s) revoked\n", admin.Username, cmd, revoked)
+		return nil
+	}
+	return errUsage
+}
+
+func runUser(e *env, cmd string, args []string) error {
+	if (cmd != "enable" && cmd != "disable") || len(args) != 1 {
+		return errUsage
+	}
+	ctx := context.Background()
+	users := service.NewUserService(e.DB())
+	user, err := users.FindUser(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	status := int16(1)
+	if cmd == "disable" {
+		status = 0
+	}
+	// UpdateUserStatus bumps session_version on disable, which invalidates existing sessions.
+	if _, err := users.UpdateUserStatus(ctx, user.ID, status); err != nil {
+		return err
+	}
+	revoked := 0
+	if status == 0 {
+		if revoked, err = e.Sessions().DeleteSessionsFor(ctx, session.KindUser, user.ID); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("user %s %sd, %d session(s) revoked\n", user.Username, cmd, revoked)
+	return nil
+}
+
+func statusLabel(status int16) string {
+	if status == 1 {
+		return "enabled"
+	}
+	return "disabled"
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// runConfig prints the effective configuration (file + env overrides) using the
+// same dotted keys as config/config.yaml, with secrets redacted.
+func runConfig(e *env, cmd string, args []string) error {
+	if cmd != "show" || len(args) != 0 {
+		return errUsage
+	}
+	redacted := e.cfg.Redacted()
+	printConfig("", reflect.ValueOf(redacted))
+	return nil
+}
+
+func printConfig(prefix string, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key := t.Field(i).Tag.Get("mapstructure")
+		if key == "" {
+			key = t.Field(i).Name
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			printConfig(key, field)
+			continue
+		}
+		fmt.Printf("%s = %v\n", key, field.Interface())
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/linkyfish/kxl_backend_go/internal/service"
+)
+
+func runContent(e *env, cmd string, args []string) error {
+	fs := flag.NewFlagSet("content "+cmd, flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "list what would change without saving")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return errUsage
+	}
+
+	switch cmd {
+	case "sanitize":
+		svc := service.NewSanitizeService(e.DB(), service.NewHTMLSanitizer(e.cfg.Sanitize))
+		changes, err := svc.Existing(context.Background(), *dryRun)
+		verb := "sanitized"
+		if *dryRun {
+			verb = "would sanitize"
+		}
+		for _, c := range changes {
+			fmt.Printf("%s %s %s.%s (%d -> %d bytes)\n", verb, c.Table, c.ID, c.Field, c.BytesBefore, c.BytesAfter)
+		}
+		fmt.Printf("%s %d field(s)\n", verb, len(changes))
+		return err
+	default:
+		return errUsage
+	}
+}
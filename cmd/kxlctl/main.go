@@ -0,0 +1,146 @@
+// Command kxlctl is the operator CLI for kxl_backend_go: admin accounts,
+// user status, Redis sessions, the RBAC cache, the media library, content
+// slugs, stored rich text and effective configuration.
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	kxlcfg "github.com/linkyfish/kxl_backend_go/internal/config"
+	"github.com/linkyfish/kxl_backend_go/internal/service"
+	"github.com/linkyfish/kxl_backend_go/pkg/db"
+	kxlredis "github.com/linkyfish/kxl_backend_go/pkg/redis"
+	"github.com/linkyfish/kxl_backend_go/pkg/session"
+	"github.com/linkyfish/kxl_backend_go/pkg/storage"
+	"gorm.io/gorm"
+)
+
+const usage = `usage: kxlctl <group> <command> [flags] [args]
+
+admin   list
+        reset-password [-password P] <username|id>
+        enable  <username|id>
+        disable <username|id>
+user    enable  <username|email|id>
+        disable <username|email|id>
+session list   [-kind user|admin] [-subject id]
+        revoke [-kind user|admin] <sid>
+        revoke-all -kind user|admin <subject id>
+rbac    flush  [role]
+media   scan
+        gc [-grace 168h] [-dry-run]   (also drops expired resumable uploads)
+slugs   backfill                      (slugs for content created before they existed)
+content sanitize [-dry-run]           (apply the HTML allowlist to stored rich text)
+config  show`
+
+// env lazily opens the backends a command needs, so e.g. `config show`
+// works without a reachable database.
+type env struct {
+	cfg   *kxlcfg.Config
+	db    *gorm.DB
+	redis *redis.Client
+}
+
+func (e *env) DB() *gorm.DB {
+	if e.db == nil {
+		gormDB, err := db.ConnectPostgres(e.cfg)
+		if err != nil {
+			fatalf("connect db: %v", err)
+		}
+		e.db = gormDB
+	}
+	return e.db
+}
+
+func (e *env) Redis() *redis.Client {
+	if e.redis == nil {
+		client, err := kxlredis.NewClient(e.cfg)
+		if err != nil {
+			fatalf("connect redis: %v", err)
+		}
+		e.redis = client
+	}
+	return e.redis
+}
+
+func (e *env) Sessions() *session.Manager {
+	return session.NewManager(e.Redis(), e.cfg)
+}
+
+func (e *env) Uploads() *service.UploadService {
+	store, err := storage.New(e.cfg)
+	if err != nil {
+		fatalf("init storage: %v", err)
+	}
+	return service.NewUploadService(e.cfg, e.DB(), store)
+}
+
+func (e *env) Media() *service.MediaService {
+	return service.NewMediaService(e.DB(), e.Uploads())
+}
+
+func (e *env) ResumableUploads() *service.ResumableUploadService {
+	return service.NewResumableUploadService(e.DB(), e.Uploads(), time.Duration(e.cfg.Uploads.ResumableExpireHours)*time.Hour)
+}
+
+func (e *env) Close() {
+	if e.redis != nil {
+		_ = e.redis.Close()
+	}
+}
+
+func main() {
+	if len(os.Args) < 3 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cfg, err := kxlcfg.Load()
+	if err != nil {
+		fatalf("load config: %v", err)
+	}
+	e := &env{cfg: cfg}
+	defer e.Close()
+
+	group, cmd, args := os.Args[1], os.Args[2], os.Args[3:]
+	var runErr error
+	switch group {
+	case "admin":
+		runErr = runAdmin(e, cmd, args)
+	case "user":
+		runErr = runUser(e, cmd, args)
+	case "session":
+		runErr = runSession(e, cmd, args)
+	case "rbac":
+		runErr = runRbac(e, cmd, args)
+	case "media":
+		runErr = runMedia(e, cmd, args)
+	case "slugs":
+		runErr = runSlugs(e, cmd, args)
+	case "content":
+		runErr = runContent(e, cmd, args)
+	case "config":
+		runErr = runConfig(e, cmd, args)
+	default:
+		runErr = errUsage
+	}
+	if runErr == errUsage {
+		fmt.Fprintln(os.Stderr, usage)
+		e.Close()
+		os.Exit(2)
+	}
+	if runErr != nil {
+		e.Close()
+		fatalf("%s %s: %v", group, cmd, runErr)
+	}
+}
+
+var errUsage = fmt.Errorf("usage")
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "kxlctl: "+format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+)
+
+func runMedia(e *env, cmd string, args []string) error {
+	ctx := context.Background()
+	fs := flag.NewFlagSet("media "+cmd, flag.ContinueOnError)
+	grace := fs.Duration("grace", time.Duration(e.cfg.Uploads.OrphanGraceHours)*time.Hour, "only delete media orphaned for at least this long")
+	dryRun := fs.Bool("dry-run", false, "list what would be deleted without deleting")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return errUsage
+	}
+
+	switch cmd {
+	case "scan":
+		res, err := e.Media().Scan(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("scanned %d media: %d referenced, %d orphaned\n", res.Scanned, res.Referenced, res.Orphaned)
+		return nil
+
+	case "gc":
+		res, err := e.Media().GC(ctx, *grace, *dryRun)
+		if res != nil {
+			verb := "deleted"
+			if res.DryRun {
+				verb = "would delete"
+			}
+			for _, p := range res.Deleted {
+				fmt.Printf("%s %s\n", verb, p)
+			}
+			fmt.Printf("%s %d file(s), %d bytes (scanned %d, orphaned %d)\n",
+				verb, len(res.Deleted), res.FreedBytes, res.Scan.Scanned, res.Scan.Orphaned)
+		}
+		if err != nil || *dryRun {
+			return err
+		}
+		// Abandoned resumable uploads leave chunks behind that no media row
+		// points at; drop the expired ones as part of the same sweep.
+		n, err := e.ResumableUploads().CleanupExpired(ctx, 0)
+		if n > 0 {
+			fmt.Printf("removed %d expired resumable upload(s)\n", n)
+		}
+		return err
+
+	default:
+		return errUsage
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/linkyfish/kxl_backend_go/internal/service"
+)
+
+func runRbac(e *env, cmd string, args []string) error {
+	if cmd != "flush" || len(args) > 1 {
+		return errUsage
+	}
+	ctx := context.Background()
+	rbac := service.NewRbacService(nil, e.Redis(), e.cfg.Security.RbacCacheTTLSeconds)
+	if len(args) == 1 {
+		rbac.InvalidateRolePermissions(ctx, args[0])
+		fmt.Printf("rbac cache flushed for role %s\n", args[0])
+		return nil
+	}
+	n, err := rbac.FlushCache(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("rbac cache flushed, %d key(s) removed\n", n)
+	return nil
+}
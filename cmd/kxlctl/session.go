@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/linkyfish/kxl_backend_go/pkg/session"
+)
+
+func runSession(e *env, cmd string, args []string) error {
+	ctx := context.Background()
+	fs := flag.NewFlagSet("session "+cmd, flag.ContinueOnError)
+	kind := fs.String("kind", "", "session kind: user or admin")
+	subject := fs.String("subject", "", "only sessions of this user/admin id")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+
+	switch cmd {
+	case "list":
+		kinds := []string{session.KindUser, session.KindAdmin}
+		if *kind != "" {
+			kinds = []string{*kind}
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "KIND\tSUBJECT\tSID\tEXPIRES IN")
+		for _, k := range kinds {
+			rows, err := e.Sessions().ListSessions(ctx, k, *subject)
+			if err != nil {
+				return err
+			}
+			for _, r := range rows {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Kind, r.SubjectID, r.SID, r.TTL.Truncate(time.Second))
+			}
+		}
+		return w.Flush()
+
+	case "revoke":
+		if *kind == "" || fs.NArg() != 1 {
+			return errUsage
+		}
+		sess := e.Sessions()
+		var err error
+		switch *kind {
+		case session.KindUser:
+			err = sess.DeleteUserSession(ctx, fs.Arg(0))
+		case session.KindAdmin:
+			err = sess.DeleteAdminSession(ctx, fs.Arg(0))
+		default:
+			return errUsage
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println("session revoked")
+		return nil
+
+	case "revoke-all":
+		if *kind == "" || fs.NArg() != 1 {
+			return errUsage
+		}
+		n, err := e.Sessions().DeleteSessionsFor(ctx, *kind, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d session(s) revoked\n", n)
+		return nil
+	}
+	return errUsage
+}
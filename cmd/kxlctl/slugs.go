@@ -0,0 +1,22 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/linkyfish/kxl_backend_go/internal/service"
+)
+
+func runSlugs(e *env, cmd string, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+	switch cmd {
+	case "backfill":
+		n, err := service.NewSlugService(e.DB()).Backfill(context.Background())
+		fmt.Printf("generated %d slug(s)\n", n)
+		return err
+	default:
+		return errUsage
+	}
+}
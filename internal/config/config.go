@@ -12,14 +12,17 @@ import (
 )
 
 type Config struct {
-	App      AppConfig      `mapstructure:"app"`
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Redis    RedisConfig    `mapstructure:"redis"`
-	Session  SessionConfig  `mapstructure:"session"`
-	Security SecurityConfig `mapstructure:"security"`
-	Uploads  UploadsConfig  `mapstructure:"uploads"`
-	Cors     CorsConfig     `mapstructure:"cors"`
+	App       AppConfig       `mapstructure:"app"`
+	Server    ServerConfig    `mapstructure:"server"`
+	Database  DatabaseConfig  `mapstructure:"database"`
+	Redis     RedisConfig     `mapstructure:"redis"`
+	Session   SessionConfig   `mapstructure:"session"`
+	Security  SecurityConfig  `mapstructure:"security"`
+	Uploads   UploadsConfig   `mapstructure:"uploads"`
+	Search    SearchConfig    `mapstructure:"search"`
+	Scheduler SchedulerConfig `mapstructure:"scheduler"`
+	Sanitize  SanitizeConfig  `mapstructure:"sanitize"`
+	Cors      CorsConfig      `mapstructure:"cors"`
 }
 
 type AppConfig struct {
@@ -59,20 +62,122 @@ type SessionConfig struct {
 	AdminTTLSeconds int    `mapstructure:"admin_ttl_seconds"`
 }
 
+// SecurityConfig holds rate limits and secrets. SigningSecret signs
+// short-lived tokens such as upload tickets and draft preview links; features
+// relying on it are disabled while it is empty. PreviewTTLSeconds is the
+// default lifetime of a preview link.
 type SecurityConfig struct {
-	RateLimitLoginWindowSeconds  int `mapstructure:"rate_limit_login_window_seconds"`
-	RateLimitLoginMaxAttempts    int `mapstructure:"rate_limit_login_max_attempts"`
-	RateLimitUploadWindowSeconds int `mapstructure:"rate_limit_upload_window_seconds"`
-	RateLimitUploadMaxRequests   int `mapstructure:"rate_limit_upload_max_requests"`
-	RbacCacheTTLSeconds          int `mapstructure:"rbac_cache_ttl_seconds"`
+	RateLimitLoginWindowSeconds  int    `mapstructure:"rate_limit_login_window_seconds"`
+	RateLimitLoginMaxAttempts    int    `mapstructure:"rate_limit_login_max_attempts"`
+	RateLimitUploadWindowSeconds int    `mapstructure:"rate_limit_upload_window_seconds"`
+	RateLimitUploadMaxRequests   int    `mapstructure:"rate_limit_upload_max_requests"`
+	RbacCacheTTLSeconds          int    `mapstructure:"rbac_cache_ttl_seconds"`
+	SigningSecret                string `mapstructure:"signing_secret"`
+	PreviewTTLSeconds            int    `mapstructure:"preview_ttl_seconds"`
 }
 
+// UploadsConfig selects the storage driver ("local" or "s3") and the public
+// base URL used to build returned file URLs (e.g. "/uploads" or a CDN origin).
+// ImageVariantWidths lists the resized copies generated for every uploaded
+// image; an empty list disables derivatives. ImageWebP adds a WebP copy of
+// each PNG derivative; the encoder is lossless only, so JPEG sources (where
+// it would come out larger) get none. ImageStripMetadata removes
+// EXIF/GPS and other metadata from images and applies their EXIF orientation;
+// VideoStripMetadata blanks the metadata boxes (GPS, device) of MP4 videos,
+// while WebM is stored as uploaded. Unreferenced media is garbage
+// collected once it has been orphaned for OrphanGraceHours; the server does
+// not do this by itself, `kxlctl media gc` has to run from cron. Unfinished
+// resumable (tus) uploads expire after ResumableExpireHours of inactivity.
+// UserQuotaBytes / UserQuotaFiles are the default per-user limits for
+// uploads by site users (0 = unlimited); upload tickets are valid for
+// TicketTTLSeconds.
 type UploadsConfig struct {
-	Dir           string `mapstructure:"dir"`
-	ImageMaxBytes int64  `mapstructure:"image_max_bytes"`
-	VideoMaxBytes int64  `mapstructure:"video_max_bytes"`
+	Driver               string   `mapstructure:"driver"`
+	Dir                  string   `mapstructure:"dir"`
+	PublicBaseURL        string   `mapstructure:"public_base_url"`
+	ImageMaxBytes        int64    `mapstructure:"image_max_bytes"`
+	VideoMaxBytes        int64    `mapstructure:"video_max_bytes"`
+	ImageVariantWidths   []int    `mapstructure:"image_variant_widths"`
+	ImageWebP            bool     `mapstructure:"image_webp"`
+	ImageStripMetadata   bool     `mapstructure:"image_strip_metadata"`
+	VideoStripMetadata   bool     `mapstructure:"video_strip_metadata"`
+	OrphanGraceHours     int      `mapstructure:"orphan_grace_hours"`
+	ResumableExpireHours int      `mapstructure:"resumable_expire_hours"`
+	UserQuotaBytes       int64    `mapstructure:"user_quota_bytes"`
+	UserQuotaFiles       int      `mapstructure:"user_quota_files"`
+	TicketTTLSeconds     int      `mapstructure:"ticket_ttl_seconds"`
+	S3                   S3Config `mapstructure:"s3"`
 }
 
+// S3Config configures the S3-compatible driver (AWS S3, MinIO, OSS, COS...).
+// Prefix is prepended to every object key inside the bucket.
+type S3Config struct {
+	Endpoint  string `mapstructure:"endpoint"`
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+	UseSSL    bool   `mapstructure:"use_ssl"`
+	PathStyle bool   `mapstructure:"path_style"`
+	Prefix    string `mapstructure:"prefix"`
+}
+
+// SearchConfig selects how site search matches text. "fulltext" uses the
+// tsvector columns and suits space-separated languages; "trigram" matches
+// substrings (backed by pg_trgm indexes) so Chinese text without word
+// boundaries is found too. A past search is offered as a suggestion only
+// once SuggestMinClients different visitors ran it.
+type SearchConfig struct {
+	Mode              string `mapstructure:"mode"`
+	SuggestMinClients int    `mapstructure:"suggest_min_clients"`
+}
+
+const (
+	SearchModeFulltext = "fulltext"
+	SearchModeTrigram  = "trigram"
+)
+
+// SchedulerConfig controls the background job that applies scheduled
+// publish/unpublish times, every IntervalSeconds (0 disables it).
+type SchedulerConfig struct {
+	IntervalSeconds int `mapstructure:"interval_seconds"`
+}
+
+// SanitizeConfig is the allowlist applied to admin-authored rich text
+// (article content, case background/solution, banner highlight) when it is
+// saved and again when a page renders it. Tags lists the allowed elements;
+// Attributes lists allowed attributes, either on every allowed element
+// ("class") or on one ("a.href"). Links and images may use relative URLs or
+// one of URLSchemes. Everything else is removed, along with the contents of
+// script and style elements. An allowed input is kept only as a checkbox (GFM
+// task lists); any other input type is removed.
+type SanitizeConfig struct {
+	Tags       []string `mapstructure:"tags"`
+	Attributes []string `mapstructure:"attributes"`
+	URLSchemes []string `mapstructure:"url_schemes"`
+}
+
+// Default sanitizer allowlist: the markup the admin editor and the Markdown
+// renderer produce.
+var (
+	DefaultSanitizeTags = []string{
+		"p", "br", "hr", "h1", "h2", "h3", "h4", "h5", "h6",
+		"strong", "b", "em", "i", "u", "s", "del", "sup", "sub", "mark", "small",
+		"blockquote", "ul", "ol", "li", "a", "img", "figure", "figcaption",
+		"pre", "code", "span", "div", "table", "thead", "tbody", "tr", "th", "td",
+		"input",
+	}
+	DefaultSanitizeAttributes = []string{
+		"class", "title",
+		"h1.id", "h2.id", "h3.id", "h4.id", "h5.id", "h6.id",
+		"a.href", "a.target", "a.rel",
+		"img.src", "img.alt", "img.width", "img.height", "img.loading",
+		"th.align", "td.align", "th.colspan", "td.colspan", "th.rowspan", "td.rowspan",
+		"input.type", "input.checked", "input.disabled",
+	}
+	DefaultSanitizeURLSchemes = []string{"http", "https", "mailto", "tel"}
+)
+
 type CorsConfig struct {
 	AllowOrigin string `mapstructure:"allow_origin"`
 }
@@ -105,9 +210,29 @@ func Load() (*Config, error) {
 	v.SetDefault("security.rate_limit_upload_window_seconds", 60)
 	v.SetDefault("security.rate_limit_upload_max_requests", 30)
 	v.SetDefault("security.rbac_cache_ttl_seconds", 300)
+	v.SetDefault("security.preview_ttl_seconds", 259200)
+	v.SetDefault("uploads.driver", "local")
 	v.SetDefault("uploads.dir", "uploads")
+	v.SetDefault("uploads.public_base_url", "/uploads")
 	v.SetDefault("uploads.image_max_bytes", int64(10*1024*1024))
 	v.SetDefault("uploads.video_max_bytes", int64(500*1024*1024))
+	v.SetDefault("uploads.image_variant_widths", []int{320, 640, 1280})
+	v.SetDefault("uploads.image_webp", true)
+	v.SetDefault("uploads.image_strip_metadata", true)
+	v.SetDefault("uploads.video_strip_metadata", true)
+	v.SetDefault("uploads.orphan_grace_hours", 168)
+	v.SetDefault("uploads.resumable_expire_hours", 24)
+	v.SetDefault("uploads.user_quota_bytes", int64(1073741824))
+	v.SetDefault("uploads.user_quota_files", 500)
+	v.SetDefault("uploads.ticket_ttl_seconds", 600)
+	v.SetDefault("uploads.s3.use_ssl", true)
+	v.SetDefault("uploads.s3.path_style", true)
+	v.SetDefault("search.mode", SearchModeFulltext)
+	v.SetDefault("search.suggest_min_clients", 3)
+	v.SetDefault("scheduler.interval_seconds", 30)
+	v.SetDefault("sanitize.tags", DefaultSanitizeTags)
+	v.SetDefault("sanitize.attributes", DefaultSanitizeAttributes)
+	v.SetDefault("sanitize.url_schemes", DefaultSanitizeURLSchemes)
 	v.SetDefault("cors.allow_origin", "*")
 
 	// Read config file if present (config/config.yaml is recommended).
@@ -130,10 +255,52 @@ func Load() (*Config, error) {
 	if cfg.Session.Prefix == "" {
 		cfg.Session.Prefix = "kxl_session:"
 	}
+	switch cfg.Search.Mode {
+	case SearchModeFulltext, SearchModeTrigram:
+	case "":
+		cfg.Search.Mode = SearchModeFulltext
+	default:
+		return nil, fmt.Errorf("invalid SEARCH_MODE: %q", cfg.Search.Mode)
+	}
 
 	return &cfg, nil
 }
 
+// redactedValue matches what url.URL.Redacted uses for passwords.
+const redactedValue = "xxxxx"
+
+// Redacted returns a copy that is safe to print or log: passwords and
+// credentials embedded in connection URLs are masked.
+func (c *Config) Redacted() Config {
+	out := *c
+	if out.Database.Password != "" {
+		out.Database.Password = redactedValue
+	}
+	out.Database.URL = redactURL(out.Database.URL)
+	if out.Redis.Password != "" {
+		out.Redis.Password = redactedValue
+	}
+	out.Redis.URL = redactURL(out.Redis.URL)
+	if out.Uploads.S3.SecretKey != "" {
+		out.Uploads.S3.SecretKey = redactedValue
+	}
+	if out.Security.SigningSecret != "" {
+		out.Security.SigningSecret = redactedValue
+	}
+	return out
+}
+
+func redactURL(raw string) string {
+	if raw == "" {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redactedValue
+	}
+	return u.Redacted()
+}
+
 func applyEnvOverrides(cfg *Config) {
 	// Server
 	if v := os.Getenv("SERVER_HOST"); v != "" {
@@ -221,17 +388,104 @@ func applyEnvOverrides(cfg *Config) {
 	if v := getenvInt("RBAC_CACHE_TTL_SECONDS"); v != nil {
 		cfg.Security.RbacCacheTTLSeconds = *v
 	}
+	if v := os.Getenv("SIGNING_SECRET"); v != "" {
+		cfg.Security.SigningSecret = v
+	}
+	if v := getenvInt("PREVIEW_TTL_SECONDS"); v != nil {
+		cfg.Security.PreviewTTLSeconds = *v
+	}
 
 	// Uploads
+	if v := os.Getenv("UPLOADS_DRIVER"); v != "" {
+		cfg.Uploads.Driver = v
+	}
 	if v := os.Getenv("UPLOADS_DIR"); v != "" {
 		cfg.Uploads.Dir = v
 	}
+	if v := os.Getenv("UPLOADS_PUBLIC_BASE_URL"); v != "" {
+		cfg.Uploads.PublicBaseURL = v
+	}
 	if v := getenvInt64("UPLOAD_IMAGE_MAX_BYTES"); v != nil {
 		cfg.Uploads.ImageMaxBytes = *v
 	}
 	if v := getenvInt64("UPLOAD_VIDEO_MAX_BYTES"); v != nil {
 		cfg.Uploads.VideoMaxBytes = *v
 	}
+	if v, ok := getenvIntList("UPLOAD_IMAGE_VARIANT_WIDTHS"); ok {
+		cfg.Uploads.ImageVariantWidths = v
+	}
+	if v := getenvBool("UPLOAD_IMAGE_WEBP"); v != nil {
+		cfg.Uploads.ImageWebP = *v
+	}
+	if v := getenvBool("UPLOAD_IMAGE_STRIP_METADATA"); v != nil {
+		cfg.Uploads.ImageStripMetadata = *v
+	}
+	if v := getenvBool("UPLOAD_VIDEO_STRIP_METADATA"); v != nil {
+		cfg.Uploads.VideoStripMetadata = *v
+	}
+	if v := getenvInt("UPLOAD_ORPHAN_GRACE_HOURS"); v != nil {
+		cfg.Uploads.OrphanGraceHours = *v
+	}
+	if v := getenvInt("UPLOAD_RESUMABLE_EXPIRE_HOURS"); v != nil {
+		cfg.Uploads.ResumableExpireHours = *v
+	}
+	if v := getenvInt64("UPLOAD_USER_QUOTA_BYTES"); v != nil {
+		cfg.Uploads.UserQuotaBytes = *v
+	}
+	if v := getenvInt("UPLOAD_USER_QUOTA_FILES"); v != nil {
+		cfg.Uploads.UserQuotaFiles = *v
+	}
+	if v := getenvInt("UPLOAD_TICKET_TTL_SECONDS"); v != nil {
+		cfg.Uploads.TicketTTLSeconds = *v
+	}
+	if v := os.Getenv("S3_ENDPOINT"); v != "" {
+		cfg.Uploads.S3.Endpoint = v
+	}
+	if v := os.Getenv("S3_REGION"); v != "" {
+		cfg.Uploads.S3.Region = v
+	}
+	if v := os.Getenv("S3_BUCKET"); v != "" {
+		cfg.Uploads.S3.Bucket = v
+	}
+	if v := os.Getenv("S3_ACCESS_KEY"); v != "" {
+		cfg.Uploads.S3.AccessKey = v
+	}
+	if v := os.Getenv("S3_SECRET_KEY"); v != "" {
+		cfg.Uploads.S3.SecretKey = v
+	}
+	if v := getenvBool("S3_USE_SSL"); v != nil {
+		cfg.Uploads.S3.UseSSL = *v
+	}
+	if v := getenvBool("S3_PATH_STYLE"); v != nil {
+		cfg.Uploads.S3.PathStyle = *v
+	}
+	if v := os.Getenv("S3_PREFIX"); v != "" {
+		cfg.Uploads.S3.Prefix = v
+	}
+
+	// Search
+	if v := os.Getenv("SEARCH_MODE"); v != "" {
+		cfg.Search.Mode = strings.ToLower(strings.TrimSpace(v))
+	}
+	if v := getenvInt("SEARCH_SUGGEST_MIN_CLIENTS"); v != nil {
+		cfg.Search.SuggestMinClients = *v
+	}
+
+	// Scheduler
+	if v := getenvInt("SCHEDULER_INTERVAL_SECONDS"); v != nil {
+		cfg.Scheduler.IntervalSeconds = *v
+	}
+
+	// Sanitize
+	if v, ok := getenvStringList("SANITIZE_TAGS"); ok {
+		cfg.Sanitize.Tags = v
+	}
+	if v, ok := getenvStringList("SANITIZE_ATTRIBUTES"); ok {
+		cfg.Sanitize.Attributes = v
+	}
+	if v, ok := getenvStringList("SANITIZE_URL_SCHEMES"); ok {
+		cfg.Sanitize.URLSchemes = v
+	}
 
 	// CORS
 	if v := os.Getenv("CORS_ALLOW_ORIGIN"); v != "" {
@@ -315,6 +569,44 @@ func getenvInt64(key string) *int64 {
 	return &n
 }
 
+// getenvIntList parses a comma-separated list. A variable that is set but
+// empty (or "none") yields an empty list so features can be switched off.
+func getenvIntList(key string) ([]int, bool) {
+	raw, ok := os.LookupEnv(key)
+	if !ok {
+		return nil, false
+	}
+	raw = strings.TrimSpace(raw)
+	out := []int{}
+	if raw == "" || strings.EqualFold(raw, "none") {
+		return out, true
+	}
+	for _, part := range strings.Split(raw, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n <= 0 {
+			continue
+		}
+		out = append(out, n)
+	}
+	return out, true
+}
+
+// getenvStringList parses a comma-separated list of lowercase names; set but
+// empty yields an empty list.
+func getenvStringList(key string) ([]string, bool) {
+	raw, ok := os.LookupEnv(key)
+	if !ok {
+		return nil, false
+	}
+	out := []string{}
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.ToLower(strings.TrimSpace(part)); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out, true
+}
+
 func getenvBool(key string) *bool {
 	raw := strings.TrimSpace(os.Getenv(key))
 	if raw == "" {
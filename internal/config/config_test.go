@@ -31,5 +31,49 @@ func TestLoad_WithConfigFileInRepoRoot(t *testing.T) {
 	if cfg.Session.Prefix == "" {
 		t.Fatalf("expected non-empty session prefix")
 	}
+	if len(cfg.Uploads.ImageVariantWidths) == 0 {
+		t.Fatalf("expected image variant widths from config.yaml")
+	}
+	if cfg.Search.Mode != SearchModeFulltext {
+		t.Fatalf("expected default search mode, got %q", cfg.Search.Mode)
+	}
+	if cfg.Scheduler.IntervalSeconds != 30 {
+		t.Fatalf("expected default scheduler interval, got %d", cfg.Scheduler.IntervalSeconds)
+	}
+	if cfg.Security.PreviewTTLSeconds != 259200 {
+		t.Fatalf("expected default preview ttl, got %d", cfg.Security.PreviewTTLSeconds)
+	}
+	if len(cfg.Sanitize.Tags) == 0 || len(cfg.Sanitize.Attributes) == 0 || len(cfg.Sanitize.URLSchemes) == 0 {
+		t.Fatalf("expected sanitize allowlist from config.yaml, got %+v", cfg.Sanitize)
+	}
 }
 
+
+func TestRedacted_MasksSecrets(t *testing.T) {
+	cfg := &Config{
+		Database: DatabaseConfig{URL: "postgres://kxl:s3cret@db:5432/kxl", Password: "s3cret"},
+		Redis:    RedisConfig{URL: "redis://:r3dis@cache:6379/0", Password: "r3dis"},
+		Uploads:  UploadsConfig{S3: S3Config{AccessKey: "AKID", SecretKey: "sk"}},
+		Security: SecurityConfig{SigningSecret: "sign"},
+	}
+
+	out := cfg.Redacted()
+	if out.Database.Password != redactedValue || out.Redis.Password != redactedValue {
+		t.Fatalf("passwords not redacted: %+v", out)
+	}
+	if out.Database.URL != "postgres://kxl:xxxxx@db:5432/kxl" {
+		t.Fatalf("unexpected database url: %s", out.Database.URL)
+	}
+	if out.Redis.URL != "redis://:xxxxx@cache:6379/0" {
+		t.Fatalf("unexpected redis url: %s", out.Redis.URL)
+	}
+	if out.Uploads.S3.SecretKey != redactedValue {
+		t.Fatalf("s3 secret key not redacted: %q", out.Uploads.S3.SecretKey)
+	}
+	if out.Security.SigningSecret != redactedValue {
+		t.Fatalf("signing secret not redacted: %q", out.Security.SigningSecret)
+	}
+	if cfg.Database.Password != "s3cret" {
+		t.Fatalf("Redacted must not modify the original config")
+	}
+}
@@ -7,6 +7,7 @@ const (
 	CodeConflict        = 40002
 	CodeUnauthorized    = 40101
 	CodeForbidden       = 40301
+	CodeQuotaExceeded   = 40302
 	CodeNotFound        = 40401
 	CodeTooManyRequests = 42901
 
@@ -71,7 +72,7 @@ func HTTPStatusFromCode(code int) int {
 		return http.StatusConflict
 	case code == CodeUnauthorized:
 		return http.StatusUnauthorized
-	case code == CodeForbidden:
+	case code == CodeForbidden, code == CodeQuotaExceeded:
 		return http.StatusForbidden
 	case code == CodeNotFound:
 		return http.StatusNotFound
@@ -15,8 +15,11 @@ import (
 )
 
 type ArticleHandler struct {
-	DB       *gorm.DB
-	Articles *service.ArticleService
+	DB        *gorm.DB
+	Articles  *service.ArticleService
+	Revisions *service.RevisionService
+	Slugs     *service.SlugService
+	Sanitizer *service.HTMLSanitizer
 }
 
 func (h *ArticleHandler) List(c echo.Context) error {
@@ -109,6 +112,7 @@ func (h *ArticleHandler) List(c echo.Context) error {
 		items = append(items, map[string]interface{}{
 			"id":           a.ID,
 			"title":        a.Title,
+			"slug":         a.Slug,
 			"summary":      a.Summary,
 			"cover_image":  a.CoverImage,
 			"category":     category,
@@ -163,26 +167,37 @@ func (h *ArticleHandler) Detail(c echo.Context) error {
 	}
 
 	return c.JSON(http.StatusOK, response.Success(map[string]interface{}{
-		"id":           a.ID,
-		"title":        a.Title,
-		"summary":      a.Summary,
-		"content":      a.Content,
-		"cover_image":  a.CoverImage,
-		"category":     category,
-		"published_at": a.PublishedAt,
-		"view_count":   a.ViewCount,
-		"tags":         tags,
-		"created_at":   a.CreatedAt,
-		"updated_at":   a.UpdatedAt,
+		"id":                a.ID,
+		"title":             a.Title,
+		"slug":              a.Slug,
+		"summary":           a.Summary,
+		"summary_generated": a.SummaryGenerated,
+		"content":           a.Content,
+		"content_format":    a.ContentFormat,
+		"content_html":      a.RenderedContent(),
+		"cover_image":       a.CoverImage,
+		"category":          category,
+		"published_at":      a.PublishedAt,
+		"publish_at":        a.PublishAt,
+		"unpublish_at":      a.UnpublishAt,
+		"view_count":        a.ViewCount,
+		"tags":              tags,
+		"created_at":        a.CreatedAt,
+		"updated_at":        a.UpdatedAt,
 	}))
 }
 
 type articleUpsertRequest struct {
 	Title      string  `json:"title" form:"title"`
+	Slug       string  `json:"slug" form:"slug"`
 	Summary    string  `json:"summary" form:"summary"`
 	Content    string  `json:"content" form:"content"`
 	CoverImage *string `json:"cover_image" form:"cover_image"`
 	CategoryID *int    `json:"category_id" form:"category_id"`
+
+	// ContentFormat is "html" (default on create) or "markdown"; empty on
+	// update keeps the current format.
+	ContentFormat string `json:"content_format" form:"content_format"`
 }
 
 func (h *ArticleHandler) Create(c echo.Context) error {
@@ -191,7 +206,8 @@ func (h *ArticleHandler) Create(c echo.Context) error {
 	}
 	var req articleUpsertRequest
 	_ = c.Bind(&req)
-	if req.Title == "" || req.Summary == "" || req.Content == "" {
+	// An empty summary is generated from the content.
+	if req.Title == "" || req.Content == "" {
 		return kxlerrors.Validation("validation error: missing required fields")
 	}
 
@@ -205,22 +221,46 @@ func (h *ArticleHandler) Create(c echo.Context) error {
 		Status:     0,
 		PublishedAt: nil,
 	}
-	if err := h.DB.WithContext(c.Request().Context()).Create(a).Error; err != nil {
-		return kxlerrors.Internal("db error")
+	format, err := service.NormalizeContentFormat(req.ContentFormat)
+	if err != nil {
+		return err
+	}
+	a.ContentFormat = format
+	h.Sanitizer.Article(a)
+	if err := service.RenderArticle(a); err != nil {
+		return err
+	}
+	if err := h.DB.WithContext(c.Request().Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(a).Error; err != nil {
+			return kxlerrors.Internal("db error")
+		}
+		slug, err := h.Slugs.Assign(tx, service.ContentArticle, a.ID, "", req.Slug, a.Title)
+		if err != nil {
+			return err
+		}
+		a.Slug = slug
+		_, err = h.Revisions.Record(tx, service.ContentArticle, a.ID, currentAdminID(c))
+		return err
+	}); err != nil {
+		return err
 	}
 
 	return c.JSON(http.StatusOK, response.Success(map[string]interface{}{
-		"id":           a.ID,
-		"title":        a.Title,
-		"summary":      a.Summary,
-		"content":      a.Content,
-		"cover_image":  a.CoverImage,
-		"category":     nil,
-		"published_at": a.PublishedAt,
-		"view_count":   a.ViewCount,
-		"tags":         []interface{}{},
-		"created_at":   a.CreatedAt,
-		"updated_at":   a.UpdatedAt,
+		"id":                a.ID,
+		"title":             a.Title,
+		"slug":              a.Slug,
+		"summary_generated": a.SummaryGenerated,
+		"summary":           a.Summary,
+		"content":           a.Content,
+		"content_format":    a.ContentFormat,
+		"content_html":      a.RenderedContent(),
+		"cover_image":       a.CoverImage,
+		"category":          nil,
+		"published_at":      a.PublishedAt,
+		"view_count":        a.ViewCount,
+		"tags":              []interface{}{},
+		"created_at":        a.CreatedAt,
+		"updated_at":        a.UpdatedAt,
 	}))
 }
 
@@ -231,7 +271,8 @@ func (h *ArticleHandler) Update(c echo.Context) error {
 	id := c.Param("id")
 	var req articleUpsertRequest
 	_ = c.Bind(&req)
-	if req.Title == "" || req.Summary == "" || req.Content == "" {
+	// An empty summary is generated from the content.
+	if req.Title == "" || req.Content == "" {
 		return kxlerrors.Validation("validation error: missing required fields")
 	}
 
@@ -244,13 +285,40 @@ func (h *ArticleHandler) Update(c echo.Context) error {
 	}
 
 	a.Title = req.Title
-	a.Summary = req.Summary
+	// A generated summary sent back unchanged stays generated and follows
+	// the new content; anything else is the editor's own summary.
+	if !a.SummaryGenerated || req.Summary != a.Summary {
+		a.Summary = req.Summary
+		a.SummaryGenerated = false
+	}
 	a.Content = req.Content
 	a.CoverImage = normalizeOptString(req.CoverImage)
 	a.CategoryID = req.CategoryID
+	if req.ContentFormat != "" {
+		format, err := service.NormalizeContentFormat(req.ContentFormat)
+		if err != nil {
+			return err
+		}
+		a.ContentFormat = format
+	}
+	h.Sanitizer.Article(&a)
+	if err := service.RenderArticle(&a); err != nil {
+		return err
+	}
 
-	if err := h.DB.WithContext(c.Request().Context()).Save(&a).Error; err != nil {
-		return kxlerrors.Internal("db error")
+	if err := h.DB.WithContext(c.Request().Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&a).Error; err != nil {
+			return kxlerrors.Internal("db error")
+		}
+		slug, err := h.Slugs.Assign(tx, service.ContentArticle, a.ID, a.Slug, req.Slug, a.Title)
+		if err != nil {
+			return err
+		}
+		a.Slug = slug
+		_, err = h.Revisions.Record(tx, service.ContentArticle, a.ID, currentAdminID(c))
+		return err
+	}); err != nil {
+		return err
 	}
 
 	return h.Detail(c)
@@ -295,11 +363,14 @@ func (h *ArticleHandler) UpdateStatus(c echo.Context) error {
 	}
 
 	a.Status = *req.Status
+	// A manual change settles the pending schedule in the same direction.
 	if a.Status == 1 {
 		now := time.Now().UTC()
 		a.PublishedAt = &now
+		a.PublishAt = nil
 	} else {
 		a.PublishedAt = nil
+		a.UnpublishAt = nil
 	}
 	if err := h.DB.WithContext(c.Request().Context()).Save(&a).Error; err != nil {
 		return kxlerrors.Internal("db error")
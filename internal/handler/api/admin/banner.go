@@ -13,7 +13,8 @@ import (
 )
 
 type BannerHandler struct {
-	Banners *service.BannerService
+	Banners   *service.BannerService
+	Sanitizer *service.HTMLSanitizer
 }
 
 func (h *BannerHandler) List(c echo.Context) error {
@@ -72,7 +73,7 @@ func (h *BannerHandler) Create(c echo.Context) error {
 	row, err := h.Banners.Create(c.Request().Context(), &model.Banner{
 		Title:     req.Title,
 		Subtitle:  normalizeOptString(req.Subtitle),
-		Highlight: normalizeOptString(req.Highlight),
+		Highlight: h.Sanitizer.SanitizeOpt(normalizeOptString(req.Highlight)),
 		Tag:       normalizeOptString(req.Tag),
 		Image:     normalizeOptString(req.Image),
 		Link:      normalizeOptString(req.Link),
@@ -104,7 +105,7 @@ func (h *BannerHandler) Update(c echo.Context) error {
 	row, err := h.Banners.Update(c.Request().Context(), id, &model.Banner{
 		Title:     req.Title,
 		Subtitle:  normalizeOptString(req.Subtitle),
-		Highlight: normalizeOptString(req.Highlight),
+		Highlight: h.Sanitizer.SanitizeOpt(normalizeOptString(req.Highlight)),
 		Tag:       normalizeOptString(req.Tag),
 		Image:     normalizeOptString(req.Image),
 		Link:      normalizeOptString(req.Link),
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/linkyfish/kxl_backend_go/internal/dto/response"
@@ -16,9 +17,12 @@ import (
 )
 
 type CaseHandler struct {
-	DB       *gorm.DB
-	Cases    *service.CaseService
-	Projects *service.ProjectService
+	DB        *gorm.DB
+	Cases     *service.CaseService
+	Projects  *service.ProjectService
+	Revisions *service.RevisionService
+	Slugs     *service.SlugService
+	Sanitizer *service.HTMLSanitizer
 }
 
 func (h *CaseHandler) List(c echo.Context) error {
@@ -100,6 +104,7 @@ func (h *CaseHandler) List(c echo.Context) error {
 		items = append(items, map[string]interface{}{
 			"id":          row.ID,
 			"client_name": row.ClientName,
+			"slug":        row.Slug,
 			"cover_image": row.CoverImage,
 			"summary":     row.Summary,
 			"status":      row.Status,
@@ -192,6 +197,7 @@ func (h *CaseHandler) Detail(c echo.Context) error {
 			relatedProjects = append(relatedProjects, map[string]interface{}{
 				"id":          p.ID,
 				"name":        p.Name,
+				"slug":        p.Slug,
 				"description": p.Description,
 				"cover_image": p.CoverImage,
 				"status":      p.Status,
@@ -205,15 +211,22 @@ func (h *CaseHandler) Detail(c echo.Context) error {
 	return c.JSON(http.StatusOK, response.Success(map[string]interface{}{
 		"id":                 cs.ID,
 		"client_name":        cs.ClientName,
+		"slug":               cs.Slug,
 		"cover_image":        cs.CoverImage,
 		"summary":            cs.Summary,
 		"background":         cs.Background,
 		"solution":           cs.Solution,
+		"content_format":     cs.ContentFormat,
+		"background_html":    cs.RenderedBackground(),
+		"solution_html":      cs.RenderedSolution(),
 		"results":            cs.Results,
 		"testimonial":        cs.Testimonial,
 		"testimonial_author": cs.TestimonialAuthor,
 		"testimonial_title":  cs.TestimonialTitle,
 		"status":             cs.Status,
+		"published_at":       cs.PublishedAt,
+		"publish_at":         cs.PublishAt,
+		"unpublish_at":       cs.UnpublishAt,
 		"category":           category,
 		"related_projects":   relatedProjects,
 		"created_at":         cs.CreatedAt,
@@ -223,6 +236,7 @@ func (h *CaseHandler) Detail(c echo.Context) error {
 
 type caseUpsertRequest struct {
 	ClientName        string          `json:"client_name" form:"client_name"`
+	Slug              string          `json:"slug" form:"slug"`
 	CoverImage        *string         `json:"cover_image" form:"cover_image"`
 	Summary           string          `json:"summary" form:"summary"`
 	Background        string          `json:"background" form:"background"`
@@ -232,6 +246,9 @@ type caseUpsertRequest struct {
 	TestimonialAuthor *string         `json:"testimonial_author" form:"testimonial_author"`
 	TestimonialTitle  *string         `json:"testimonial_title" form:"testimonial_title"`
 	CategoryID        *int            `json:"category_id" form:"category_id"`
+	// ContentFormat applies to background and solution: "html" (default on
+	// create) or "markdown"; empty on update keeps the current format.
+	ContentFormat     string          `json:"content_format" form:"content_format"`
 }
 
 func (h *CaseHandler) Create(c echo.Context) error {
@@ -264,17 +281,41 @@ func (h *CaseHandler) Create(c echo.Context) error {
 		CategoryID:        req.CategoryID,
 		Status:            0,
 	}
-	if err := h.DB.WithContext(c.Request().Context()).Create(row).Error; err != nil {
-		return kxlerrors.Internal("db error")
+	format, err := service.NormalizeContentFormat(req.ContentFormat)
+	if err != nil {
+		return err
+	}
+	row.ContentFormat = format
+	h.Sanitizer.Case(row)
+	if err := service.RenderCase(row); err != nil {
+		return err
+	}
+	if err := h.DB.WithContext(c.Request().Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(row).Error; err != nil {
+			return kxlerrors.Internal("db error")
+		}
+		slug, err := h.Slugs.Assign(tx, service.ContentCase, row.ID, "", req.Slug, row.ClientName)
+		if err != nil {
+			return err
+		}
+		row.Slug = slug
+		_, err = h.Revisions.Record(tx, service.ContentCase, row.ID, currentAdminID(c))
+		return err
+	}); err != nil {
+		return err
 	}
 
 	return c.JSON(http.StatusOK, response.Success(map[string]interface{}{
 		"id":                 row.ID,
 		"client_name":        row.ClientName,
+		"slug":               row.Slug,
 		"cover_image":        row.CoverImage,
 		"summary":            row.Summary,
 		"background":         row.Background,
 		"solution":           row.Solution,
+		"content_format":     row.ContentFormat,
+		"background_html":    row.RenderedBackground(),
+		"solution_html":      row.RenderedSolution(),
 		"results":            row.Results,
 		"testimonial":        row.Testimonial,
 		"testimonial_author": row.TestimonialAuthor,
@@ -323,9 +364,31 @@ func (h *CaseHandler) Update(c echo.Context) error {
 	row.TestimonialAuthor = normalizeOptString(req.TestimonialAuthor)
 	row.TestimonialTitle = normalizeOptString(req.TestimonialTitle)
 	row.CategoryID = req.CategoryID
+	if req.ContentFormat != "" {
+		format, err := service.NormalizeContentFormat(req.ContentFormat)
+		if err != nil {
+			return err
+		}
+		row.ContentFormat = format
+	}
+	h.Sanitizer.Case(&row)
+	if err := service.RenderCase(&row); err != nil {
+		return err
+	}
 
-	if err := h.DB.WithContext(c.Request().Context()).Save(&row).Error; err != nil {
-		return kxlerrors.Internal("db error")
+	if err := h.DB.WithContext(c.Request().Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&row).Error; err != nil {
+			return kxlerrors.Internal("db error")
+		}
+		slug, err := h.Slugs.Assign(tx, service.ContentCase, row.ID, row.Slug, req.Slug, row.ClientName)
+		if err != nil {
+			return err
+		}
+		row.Slug = slug
+		_, err = h.Revisions.Record(tx, service.ContentCase, row.ID, currentAdminID(c))
+		return err
+	}); err != nil {
+		return err
 	}
 
 	return h.Detail(c)
@@ -369,6 +432,15 @@ func (h *CaseHandler) UpdateStatus(c echo.Context) error {
 		return kxlerrors.Internal("db error")
 	}
 	row.Status = *req.Status
+	// A manual change settles the pending schedule in the same direction.
+	if row.Status == 1 {
+		now := time.Now().UTC()
+		row.PublishedAt = &now
+		row.PublishAt = nil
+	} else {
+		row.PublishedAt = nil
+		row.UnpublishAt = nil
+	}
 	if err := h.DB.WithContext(c.Request().Context()).Save(&row).Error; err != nil {
 		return kxlerrors.Internal("db error")
 	}
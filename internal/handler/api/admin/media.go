@@ -0,0 +1,172 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/linkyfish/kxl_backend_go/internal/dto/response"
+	kxlerrors "github.com/linkyfish/kxl_backend_go/internal/errors"
+	"github.com/linkyfish/kxl_backend_go/internal/middleware"
+	"github.com/linkyfish/kxl_backend_go/internal/model"
+	"github.com/linkyfish/kxl_backend_go/internal/service"
+	"github.com/linkyfish/kxl_backend_go/internal/util"
+)
+
+type MediaHandler struct {
+	Media *service.MediaService
+	// OrphanGrace is the default grace period for GC when the request does
+	// not pass grace_hours.
+	OrphanGrace time.Duration
+}
+
+func (h *MediaHandler) List(c echo.Context) error {
+	if err := middleware.AdminRequirePermission(c, "upload:read"); err != nil {
+		return err
+	}
+
+	page := int64(1)
+	pageSize := int64(20)
+	if raw := c.QueryParam("page"); raw != "" {
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			page = n
+		}
+	}
+	if raw := c.QueryParam("page_size"); raw != "" {
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			pageSize = n
+		}
+	}
+	if page < 1 {
+		return kxlerrors.Validation("validation error: page must be >= 1")
+	}
+	if pageSize < 1 || pageSize > 200 {
+		return kxlerrors.Validation("validation error: page_size must be between 1 and 200")
+	}
+
+	f := service.MediaFilter{
+		Keyword:      c.QueryParam("keyword"),
+		Kind:         c.QueryParam("kind"),
+		UploaderType: c.QueryParam("uploader_type"),
+		UploaderID:   c.QueryParam("uploader_id"),
+	}
+	if f.UploaderID != "" && !util.IsUUID(f.UploaderID) {
+		return kxlerrors.Validation("validation error: invalid uploader_id")
+	}
+	if raw := c.QueryParam("orphaned"); raw != "" {
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return kxlerrors.Validation("validation error: invalid orphaned")
+		}
+		f.Orphaned = &b
+	}
+
+	rows, total, err := h.Media.List(c.Request().Context(), f, page, pageSize)
+	if err != nil {
+		return err
+	}
+	items := make([]map[string]interface{}, 0, len(rows))
+	for i := range rows {
+		items = append(items, h.mediaDTO(&rows[i]))
+	}
+	return c.JSON(http.StatusOK, response.Success(response.Paginated(items, total, page, pageSize)))
+}
+
+func (h *MediaHandler) Detail(c echo.Context) error {
+	if err := middleware.AdminRequirePermission(c, "upload:read"); err != nil {
+		return err
+	}
+	id := c.Param("id")
+	if !util.IsUUID(id) {
+		return kxlerrors.NotFound("not found: media not found")
+	}
+	ctx := c.Request().Context()
+	m, err := h.Media.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	refs, err := h.Media.References(ctx, m)
+	if err != nil {
+		return err
+	}
+	data := h.mediaDTO(m)
+	data["references"] = refs
+	return c.JSON(http.StatusOK, response.Success(data))
+}
+
+// Delete removes a media file. Referenced media is refused with a conflict
+// unless ?force=true.
+func (h *MediaHandler) Delete(c echo.Context) error {
+	if err := middleware.AdminRequirePermission(c, "upload:delete"); err != nil {
+		return err
+	}
+	id := c.Param("id")
+	if !util.IsUUID(id) {
+		return kxlerrors.NotFound("not found: media not found")
+	}
+	force, _ := strconv.ParseBool(c.QueryParam("force"))
+	if err := h.Media.Delete(c.Request().Context(), id, force); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, response.SuccessWithoutData())
+}
+
+// Scan recomputes reference counts without deleting anything.
+func (h *MediaHandler) Scan(c echo.Context) error {
+	if err := middleware.AdminRequirePermission(c, "upload:delete"); err != nil {
+		return err
+	}
+	res, err := h.Media.Scan(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, response.Success(res))
+}
+
+// GC deletes orphaned media older than the grace period. Pass dry_run=true to
+// preview.
+func (h *MediaHandler) GC(c echo.Context) error {
+	if err := middleware.AdminRequirePermission(c, "upload:delete"); err != nil {
+		return err
+	}
+	grace := h.OrphanGrace
+	if raw := c.QueryParam("grace_hours"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return kxlerrors.Validation("validation error: invalid grace_hours")
+		}
+		grace = time.Duration(n) * time.Hour
+	}
+	dryRun, _ := strconv.ParseBool(c.QueryParam("dry_run"))
+	res, err := h.Media.GC(c.Request().Context(), grace, dryRun)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, response.Success(res))
+}
+
+func (h *MediaHandler) mediaDTO(m *model.Media) map[string]interface{} {
+	variants := []string{}
+	_ = json.Unmarshal(m.Variants, &variants)
+	return map[string]interface{}{
+		"id":              m.ID,
+		"path":            m.Path,
+		"url":             h.Media.URL(m),
+		"kind":            m.Kind,
+		"mime":            m.Mime,
+		"size":            m.Size,
+		"width":           m.Width,
+		"height":          m.Height,
+		"sha256":          m.SHA256,
+		"original_name":   m.OriginalName,
+		"uploader_type":   m.UploaderType,
+		"uploader_id":     m.UploaderID,
+		"variants":        variants,
+		"ref_count":       m.RefCount,
+		"last_scanned_at": m.LastScannedAt,
+		"orphaned_at":     m.OrphanedAt,
+		"created_at":      m.CreatedAt,
+	}
+}
@@ -0,0 +1,41 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/linkyfish/kxl_backend_go/internal/dto/response"
+	kxlerrors "github.com/linkyfish/kxl_backend_go/internal/errors"
+	"github.com/linkyfish/kxl_backend_go/internal/middleware"
+	"github.com/linkyfish/kxl_backend_go/internal/service"
+)
+
+// PreviewLinkHandler issues draft preview links for one content type at
+// POST /api/admin/<Resource>/:id/preview-link, guarded by <Resource>:write
+// since the link shows the draft to anyone holding it.
+type PreviewLinkHandler struct {
+	Previews    *service.PreviewService
+	ContentType string
+	Resource    string
+}
+
+type previewLinkRequest struct {
+	TTLSeconds int `json:"ttl_seconds" form:"ttl_seconds"`
+}
+
+func (h *PreviewLinkHandler) Create(c echo.Context) error {
+	if err := middleware.AdminRequirePermission(c, h.Resource+":write"); err != nil {
+		return err
+	}
+	var req previewLinkRequest
+	_ = c.Bind(&req)
+	if req.TTLSeconds < 0 {
+		return kxlerrors.Validation("validation error: ttl_seconds must be positive")
+	}
+	link, err := h.Previews.Issue(c.Request().Context(), h.ContentType, c.Param("id"), time.Duration(req.TTLSeconds)*time.Second)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, response.Success(link))
+}
@@ -16,8 +16,10 @@ import (
 )
 
 type ProjectHandler struct {
-	DB       *gorm.DB
-	Projects *service.ProjectService
+	DB        *gorm.DB
+	Projects  *service.ProjectService
+	Revisions *service.RevisionService
+	Slugs     *service.SlugService
 }
 
 func (h *ProjectHandler) List(c echo.Context) error {
@@ -110,6 +112,7 @@ func (h *ProjectHandler) List(c echo.Context) error {
 		items = append(items, map[string]interface{}{
 			"id":          p.ID,
 			"name":        p.Name,
+			"slug":        p.Slug,
 			"description": p.Description,
 			"cover_image": p.CoverImage,
 			"status":      p.Status,
@@ -134,6 +137,7 @@ func (h *ProjectHandler) List(c echo.Context) error {
 
 type projectUpsertRequest struct {
 	Name        string  `json:"name" form:"name"`
+	Slug        string  `json:"slug" form:"slug"`
 	Description string  `json:"description" form:"description"`
 	CoverImage  *string `json:"cover_image" form:"cover_image"`
 	CategoryID  *int    `json:"category_id" form:"category_id"`
@@ -158,8 +162,19 @@ func (h *ProjectHandler) Create(c echo.Context) error {
 		SortOrder:   req.SortOrder,
 		Status:      0,
 	}
-	if err := h.DB.WithContext(c.Request().Context()).Create(p).Error; err != nil {
-		return kxlerrors.Internal("db error")
+	if err := h.DB.WithContext(c.Request().Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(p).Error; err != nil {
+			return kxlerrors.Internal("db error")
+		}
+		slug, err := h.Slugs.Assign(tx, service.ContentProject, p.ID, "", req.Slug, p.Name)
+		if err != nil {
+			return err
+		}
+		p.Slug = slug
+		_, err = h.Revisions.Record(tx, service.ContentProject, p.ID, currentAdminID(c))
+		return err
+	}); err != nil {
+		return err
 	}
 	return c.JSON(http.StatusOK, response.Success(h.projectDetailDTO(c.Request().Context(), *p)))
 }
@@ -188,8 +203,19 @@ func (h *ProjectHandler) Update(c echo.Context) error {
 	p.CoverImage = normalizeOptString(req.CoverImage)
 	p.CategoryID = req.CategoryID
 	p.SortOrder = req.SortOrder
-	if err := h.DB.WithContext(c.Request().Context()).Save(&p).Error; err != nil {
-		return kxlerrors.Internal("db error")
+	if err := h.DB.WithContext(c.Request().Context()).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&p).Error; err != nil {
+			return kxlerrors.Internal("db error")
+		}
+		slug, err := h.Slugs.Assign(tx, service.ContentProject, p.ID, p.Slug, req.Slug, p.Name)
+		if err != nil {
+			return err
+		}
+		p.Slug = slug
+		_, err = h.Revisions.Record(tx, service.ContentProject, p.ID, currentAdminID(c))
+		return err
+	}); err != nil {
+		return err
 	}
 	return c.JSON(http.StatusOK, response.Success(h.projectDetailDTO(c.Request().Context(), p)))
 }
@@ -232,6 +258,15 @@ func (h *ProjectHandler) UpdateStatus(c echo.Context) error {
 		return kxlerrors.Internal("db error")
 	}
 	p.Status = *req.Status
+	// A manual change settles the pending schedule in the same direction.
+	if p.Status == 1 {
+		now := time.Now().UTC()
+		p.PublishedAt = &now
+		p.PublishAt = nil
+	} else {
+		p.PublishedAt = nil
+		p.UnpublishAt = nil
+	}
 	if err := h.DB.WithContext(c.Request().Context()).Save(&p).Error; err != nil {
 		return kxlerrors.Internal("db error")
 	}
@@ -657,18 +692,22 @@ func (h *ProjectHandler) projectDetailDTO(ctx context.Context, p model.Project)
 	}
 
 	return map[string]interface{}{
-		"id":          p.ID,
-		"name":        p.Name,
-		"description": p.Description,
-		"cover_image": p.CoverImage,
-		"status":      p.Status,
-		"sort_order":  p.SortOrder,
-		"category":    category,
-		"tags":        tags,
-		"features":    featureDTOs,
-		"media":       mediaDTOs,
-		"versions":    versionDTOs,
-		"created_at":  p.CreatedAt,
-		"updated_at":  p.UpdatedAt,
+		"id":           p.ID,
+		"name":         p.Name,
+		"slug":         p.Slug,
+		"description":  p.Description,
+		"cover_image":  p.CoverImage,
+		"status":       p.Status,
+		"sort_order":   p.SortOrder,
+		"published_at": p.PublishedAt,
+		"publish_at":   p.PublishAt,
+		"unpublish_at": p.UnpublishAt,
+		"category":     category,
+		"tags":         tags,
+		"features":     featureDTOs,
+		"media":        mediaDTOs,
+		"versions":     versionDTOs,
+		"created_at":   p.CreatedAt,
+		"updated_at":   p.UpdatedAt,
 	}
 }
@@ -0,0 +1,44 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/linkyfish/kxl_backend_go/internal/dto/response"
+	kxlerrors "github.com/linkyfish/kxl_backend_go/internal/errors"
+	"github.com/linkyfish/kxl_backend_go/internal/middleware"
+	"github.com/linkyfish/kxl_backend_go/internal/service"
+)
+
+// PublishScheduleHandler sets the publish/unpublish times of one content type
+// at PUT /api/admin/<Resource>/:id/schedule, guarded by <Resource>:write.
+type PublishScheduleHandler struct {
+	Scheduler   *service.PublishScheduler
+	ContentType string
+	Resource    string
+}
+
+// publishScheduleRequest takes RFC 3339 times; null clears a time.
+type publishScheduleRequest struct {
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+}
+
+func (h *PublishScheduleHandler) Update(c echo.Context) error {
+	if err := middleware.AdminRequirePermission(c, h.Resource+":write"); err != nil {
+		return err
+	}
+	var req publishScheduleRequest
+	if err := c.Bind(&req); err != nil {
+		return kxlerrors.Validation("validation error: publish_at and unpublish_at must be RFC 3339 times or null")
+	}
+	if err := h.Scheduler.Schedule(c.Request().Context(), h.ContentType, c.Param("id"), req.PublishAt, req.UnpublishAt); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, response.Success(map[string]interface{}{
+		"id":           c.Param("id"),
+		"publish_at":   req.PublishAt,
+		"unpublish_at": req.UnpublishAt,
+	}))
+}
@@ -0,0 +1,169 @@
+package admin
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	kxlerrors "github.com/linkyfish/kxl_backend_go/internal/errors"
+	"github.com/linkyfish/kxl_backend_go/internal/middleware"
+	"github.com/linkyfish/kxl_backend_go/internal/model"
+	"github.com/linkyfish/kxl_backend_go/internal/service"
+	"github.com/linkyfish/kxl_backend_go/internal/util"
+)
+
+const tusVersion = "1.0.0"
+
+// ResumableUploadHandler speaks the tus 1.0 protocol (core + creation,
+// termination, expiration) for large video uploads. Responses carry tus
+// headers and empty bodies as the protocol requires; errors still use the
+// regular JSON envelope.
+type ResumableUploadHandler struct {
+	Uploads *service.ResumableUploadService
+}
+
+// Options advertises server capabilities. It is public so CORS preflights and
+// tus clients can probe without credentials.
+func (h *ResumableUploadHandler) Options(c echo.Context) error {
+	hdr := c.Response().Header()
+	hdr.Set("Tus-Resumable", tusVersion)
+	hdr.Set("Tus-Version", tusVersion)
+	hdr.Set("Tus-Extension", "creation,termination,expiration")
+	hdr.Set("Tus-Max-Size", strconv.FormatInt(h.Uploads.MaxSize(), 10))
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *ResumableUploadHandler) Create(c echo.Context) error {
+	if err := middleware.AdminRequirePermission(c, "upload:write"); err != nil {
+		return err
+	}
+	if err := requireTusResumable(c); err != nil {
+		return err
+	}
+	length, err := strconv.ParseInt(c.Request().Header.Get("Upload-Length"), 10, 64)
+	if err != nil || length <= 0 {
+		return kxlerrors.Validation("validation error: invalid Upload-Length")
+	}
+	meta, err := parseTusMetadata(c.Request().Header.Get("Upload-Metadata"))
+	if err != nil {
+		return err
+	}
+
+	row, err := h.Uploads.Create(c.Request().Context(), length, meta, currentUploader(c))
+	if err != nil {
+		return err
+	}
+	hdr := c.Response().Header()
+	hdr.Set("Tus-Resumable", tusVersion)
+	hdr.Set(echo.HeaderLocation, strings.TrimRight(c.Request().URL.Path, "/")+"/"+row.ID)
+	hdr.Set("Upload-Expires", row.ExpiresAt.UTC().Format(http.TimeFormat))
+	return c.NoContent(http.StatusCreated)
+}
+
+func (h *ResumableUploadHandler) Head(c echo.Context) error {
+	if err := middleware.AdminRequirePermission(c, "upload:write"); err != nil {
+		return err
+	}
+	id := c.Param("id")
+	if !util.IsUUID(id) {
+		return kxlerrors.NotFound("not found: upload not found")
+	}
+	row, err := h.Uploads.Get(c.Request().Context(), id, currentUploader(c))
+	if err != nil {
+		return err
+	}
+	writeTusState(c, row)
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.NoContent(http.StatusOK)
+}
+
+func (h *ResumableUploadHandler) Patch(c echo.Context) error {
+	if err := middleware.AdminRequirePermission(c, "upload:write"); err != nil {
+		return err
+	}
+	if err := requireTusResumable(c); err != nil {
+		return err
+	}
+	req := c.Request()
+	if ct := strings.TrimSpace(strings.Split(req.Header.Get(echo.HeaderContentType), ";")[0]); ct != "application/offset+octet-stream" {
+		return kxlerrors.New(kxlerrors.CodeValidationError, "validation error: Content-Type must be application/offset+octet-stream", http.StatusUnsupportedMediaType, nil)
+	}
+	offset, err := strconv.ParseInt(req.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return kxlerrors.Validation("validation error: invalid Upload-Offset")
+	}
+	id := c.Param("id")
+	if !util.IsUUID(id) {
+		return kxlerrors.NotFound("not found: upload not found")
+	}
+
+	row, err := h.Uploads.Append(req.Context(), id, offset, req.Body, currentUploader(c))
+	if err != nil {
+		return err
+	}
+	if row.Status == model.ResumableStatusCompleted {
+		middleware.CountFinishedUpload(c, service.UploadKindVideo)
+	}
+	writeTusState(c, row)
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *ResumableUploadHandler) Delete(c echo.Context) error {
+	if err := middleware.AdminRequirePermission(c, "upload:write"); err != nil {
+		return err
+	}
+	id := c.Param("id")
+	if !util.IsUUID(id) {
+		return kxlerrors.NotFound("not found: upload not found")
+	}
+	if err := h.Uploads.Terminate(c.Request().Context(), id, currentUploader(c)); err != nil {
+		return err
+	}
+	c.Response().Header().Set("Tus-Resumable", tusVersion)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// writeTusState sets the offset headers; finished uploads also expose the
+// resulting media so the client does not need a second request.
+func writeTusState(c echo.Context, row *model.ResumableUpload) {
+	hdr := c.Response().Header()
+	hdr.Set("Tus-Resumable", tusVersion)
+	hdr.Set("Upload-Offset", strconv.FormatInt(row.UploadOffset, 10))
+	hdr.Set("Upload-Length", strconv.FormatInt(row.UploadLength, 10))
+	if row.Status == model.ResumableStatusCompleted {
+		if row.MediaID != nil {
+			hdr.Set("X-Media-Id", *row.MediaID)
+		}
+		hdr.Set("X-Media-Url", row.URL)
+	} else {
+		hdr.Set("Upload-Expires", row.ExpiresAt.UTC().Format(http.TimeFormat))
+	}
+}
+
+func requireTusResumable(c echo.Context) error {
+	if c.Request().Header.Get("Tus-Resumable") != tusVersion {
+		c.Response().Header().Set("Tus-Version", tusVersion)
+		return kxlerrors.New(kxlerrors.CodeValidationError, "validation error: unsupported Tus-Resumable version", http.StatusPreconditionFailed, nil)
+	}
+	return nil
+}
+
+// parseTusMetadata decodes "key base64value,key2 base64value2".
+func parseTusMetadata(raw string) (map[string]string, error) {
+	meta := map[string]string{}
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, enc, _ := strings.Cut(pair, " ")
+		val, err := base64.StdEncoding.DecodeString(strings.TrimSpace(enc))
+		if key == "" || err != nil {
+			return nil, kxlerrors.Validation("validation error: invalid Upload-Metadata")
+		}
+		meta[key] = string(val)
+	}
+	return meta, nil
+}
@@ -0,0 +1,150 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/linkyfish/kxl_backend_go/internal/dto/response"
+	kxlerrors "github.com/linkyfish/kxl_backend_go/internal/errors"
+	"github.com/linkyfish/kxl_backend_go/internal/middleware"
+	"github.com/linkyfish/kxl_backend_go/internal/model"
+	"github.com/linkyfish/kxl_backend_go/internal/service"
+)
+
+// RevisionHandler serves the revision history of one content type below
+// /api/admin/<Resource>/:id/revisions, guarded by the <Resource>:read and
+// <Resource>:write permissions of that content.
+type RevisionHandler struct {
+	Revisions   *service.RevisionService
+	ContentType string
+	Resource    string
+}
+
+func (h *RevisionHandler) List(c echo.Context) error {
+	if err := middleware.AdminRequirePermission(c, h.Resource+":read"); err != nil {
+		return err
+	}
+
+	page := int64(1)
+	pageSize := int64(20)
+	if raw := c.QueryParam("page"); raw != "" {
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			page = n
+		}
+	}
+	if raw := c.QueryParam("page_size"); raw != "" {
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			pageSize = n
+		}
+	}
+	if page < 1 {
+		return kxlerrors.Validation("validation error: page must be >= 1")
+	}
+	if pageSize < 1 || pageSize > 200 {
+		return kxlerrors.Validation("validation error: page_size must be between 1 and 200")
+	}
+
+	rows, total, err := h.Revisions.List(c.Request().Context(), h.ContentType, c.Param("id"), page, pageSize)
+	if err != nil {
+		return err
+	}
+	items := make([]map[string]interface{}, 0, len(rows))
+	for _, r := range rows {
+		items = append(items, revisionDTO(r, false))
+	}
+
+	totalPages := int64(0)
+	if total > 0 {
+		totalPages = (total + pageSize - 1) / pageSize
+	}
+	return c.JSON(http.StatusOK, response.Success(map[string]interface{}{
+		"items":       items,
+		"total":       total,
+		"page":        page,
+		"page_size":   pageSize,
+		"total_pages": totalPages,
+	}))
+}
+
+func (h *RevisionHandler) Detail(c echo.Context) error {
+	if err := middleware.AdminRequirePermission(c, h.Resource+":read"); err != nil {
+		return err
+	}
+	version, err := revisionVersion(c.Param("version"), "version")
+	if err != nil {
+		return err
+	}
+	rev, err := h.Revisions.Get(c.Request().Context(), h.ContentType, c.Param("id"), version)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, response.Success(revisionDTO(*rev, true)))
+}
+
+// Diff compares revision `from` with revision `to` (query parameters).
+func (h *RevisionHandler) Diff(c echo.Context) error {
+	if err := middleware.AdminRequirePermission(c, h.Resource+":read"); err != nil {
+		return err
+	}
+	from, err := revisionVersion(c.QueryParam("from"), "from")
+	if err != nil {
+		return err
+	}
+	to, err := revisionVersion(c.QueryParam("to"), "to")
+	if err != nil {
+		return err
+	}
+	changes, err := h.Revisions.Diff(c.Request().Context(), h.ContentType, c.Param("id"), from, to)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, response.Success(map[string]interface{}{
+		"from":    from,
+		"to":      to,
+		"changes": changes,
+	}))
+}
+
+// Restore copies a revision back into the content and returns the new
+// revision that records it.
+func (h *RevisionHandler) Restore(c echo.Context) error {
+	if err := middleware.AdminRequirePermission(c, h.Resource+":write"); err != nil {
+		return err
+	}
+	version, err := revisionVersion(c.Param("version"), "version")
+	if err != nil {
+		return err
+	}
+	rev, err := h.Revisions.Restore(c.Request().Context(), h.ContentType, c.Param("id"), version, currentAdminID(c))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, response.Success(revisionDTO(*rev, true)))
+}
+
+func revisionVersion(raw, name string) (int, error) {
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		return 0, kxlerrors.Validation("validation error: " + name + " must be a positive integer")
+	}
+	return n, nil
+}
+
+func revisionDTO(r model.ContentRevision, withData bool) map[string]interface{} {
+	out := map[string]interface{}{
+		"version":    r.Version,
+		"admin_id":   r.AdminID,
+		"created_at": r.CreatedAt,
+	}
+	if withData {
+		out["data"] = r.Data
+	}
+	return out
+}
+
+// currentAdminID is the admin making the request, for audit fields.
+func currentAdminID(c echo.Context) string {
+	id, _ := c.Get("current_admin_id").(string)
+	return id
+}
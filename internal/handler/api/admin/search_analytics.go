@@ -0,0 +1,114 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/linkyfish/kxl_backend_go/internal/dto/response"
+	kxlerrors "github.com/linkyfish/kxl_backend_go/internal/errors"
+	"github.com/linkyfish/kxl_backend_go/internal/middleware"
+	"github.com/linkyfish/kxl_backend_go/internal/service"
+)
+
+type SearchAnalyticsHandler struct {
+	Logs *service.SearchLogService
+}
+
+func (h *SearchAnalyticsHandler) TopQueries(c echo.Context) error {
+	if err := middleware.AdminRequirePermission(c, "search:read"); err != nil {
+		return err
+	}
+	from, to, limit, err := analyticsRange(c)
+	if err != nil {
+		return err
+	}
+	rows, err := h.Logs.TopQueries(c.Request().Context(), from, to, limit)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, response.Success(map[string]interface{}{
+		"start_date": from.Format("2006-01-02"),
+		"end_date":   to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"items":      rows,
+	}))
+}
+
+func (h *SearchAnalyticsHandler) ZeroResults(c echo.Context) error {
+	if err := middleware.AdminRequirePermission(c, "search:read"); err != nil {
+		return err
+	}
+	from, to, limit, err := analyticsRange(c)
+	if err != nil {
+		return err
+	}
+	rows, err := h.Logs.ZeroResultQueries(c.Request().Context(), from, to, limit)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, response.Success(map[string]interface{}{
+		"start_date": from.Format("2006-01-02"),
+		"end_date":   to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"items":      rows,
+	}))
+}
+
+func (h *SearchAnalyticsHandler) ClickThrough(c echo.Context) error {
+	if err := middleware.AdminRequirePermission(c, "search:read"); err != nil {
+		return err
+	}
+	from, to, limit, err := analyticsRange(c)
+	if err != nil {
+		return err
+	}
+	queries, results, err := h.Logs.ClickThrough(c.Request().Context(), from, to, limit)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, response.Success(map[string]interface{}{
+		"start_date": from.Format("2006-01-02"),
+		"end_date":   to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"queries":    queries,
+		"results":    results,
+	}))
+}
+
+// analyticsRange reads start_date/end_date (YYYY-MM-DD, both inclusive,
+// UTC; default the last 30 days) and limit (1-200, default 20). The returned
+// range is half-open: [from, to).
+func analyticsRange(c echo.Context) (time.Time, time.Time, int, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	to := today.AddDate(0, 0, 1)
+	if raw := c.QueryParam("end_date"); raw != "" {
+		t, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return time.Time{}, time.Time{}, 0, kxlerrors.Validation("validation error: invalid end_date")
+		}
+		to = t.AddDate(0, 0, 1)
+	}
+	from := to.AddDate(0, 0, -30)
+	if raw := c.QueryParam("start_date"); raw != "" {
+		t, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return time.Time{}, time.Time{}, 0, kxlerrors.Validation("validation error: invalid start_date")
+		}
+		from = t
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, 0, kxlerrors.Validation("validation error: start_date must not be after end_date")
+	}
+
+	limit := 20
+	if raw := c.QueryParam("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return time.Time{}, time.Time{}, 0, kxlerrors.Validation("validation error: invalid limit")
+		}
+		limit = n
+	}
+	if limit < 1 || limit > 200 {
+		return time.Time{}, time.Time{}, 0, kxlerrors.Validation("validation error: limit must be between 1 and 200")
+	}
+	return from, to, limit, nil
+}
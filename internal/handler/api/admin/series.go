@@ -0,0 +1,144 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/linkyfish/kxl_backend_go/internal/dto/response"
+	"github.com/linkyfish/kxl_backend_go/internal/middleware"
+	"github.com/linkyfish/kxl_backend_go/internal/model"
+	"github.com/linkyfish/kxl_backend_go/internal/service"
+)
+
+// SeriesHandler manages series and collections. They group articles, so
+// they share the articles permissions.
+type SeriesHandler struct {
+	Series *service.SeriesService
+}
+
+func (h *SeriesHandler) List(c echo.Context) error {
+	if err := middleware.AdminRequirePermission(c, "articles:read"); err != nil {
+		return err
+	}
+	rows, err := h.Series.ListAll(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	data := make([]map[string]interface{}, 0, len(rows))
+	for _, s := range rows {
+		data = append(data, seriesDTO(s))
+	}
+	return c.JSON(http.StatusOK, response.Success(data))
+}
+
+func (h *SeriesHandler) Detail(c echo.Context) error {
+	if err := middleware.AdminRequirePermission(c, "articles:read"); err != nil {
+		return err
+	}
+	row, err := h.Series.Get(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return err
+	}
+	items, err := h.Series.Entries(c.Request().Context(), row.ID, false)
+	if err != nil {
+		return err
+	}
+	data := seriesDTO(*row)
+	data["items"] = items
+	return c.JSON(http.StatusOK, response.Success(data))
+}
+
+type seriesRequest struct {
+	Kind        string  `json:"kind" form:"kind"`
+	Title       string  `json:"title" form:"title"`
+	Slug        string  `json:"slug" form:"slug"`
+	Description string  `json:"description" form:"description"`
+	CoverImage  *string `json:"cover_image" form:"cover_image"`
+	IsVisible   *bool   `json:"is_visible" form:"is_visible"`
+	SortOrder   int     `json:"sort_order" form:"sort_order"`
+}
+
+func (r seriesRequest) toModel() *model.Series {
+	isVisible := true
+	if r.IsVisible != nil {
+		isVisible = *r.IsVisible
+	}
+	return &model.Series{
+		Kind:        r.Kind,
+		Title:       r.Title,
+		Slug:        r.Slug,
+		Description: r.Description,
+		CoverImage:  normalizeOptString(r.CoverImage),
+		IsVisible:   isVisible,
+		SortOrder:   r.SortOrder,
+	}
+}
+
+func (h *SeriesHandler) Create(c echo.Context) error {
+	if err := middleware.AdminRequirePermission(c, "articles:write"); err != nil {
+		return err
+	}
+	var req seriesRequest
+	_ = c.Bind(&req)
+	row, err := h.Series.Create(c.Request().Context(), req.toModel())
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, response.Success(seriesDTO(*row)))
+}
+
+func (h *SeriesHandler) Update(c echo.Context) error {
+	if err := middleware.AdminRequirePermission(c, "articles:write"); err != nil {
+		return err
+	}
+	var req seriesRequest
+	_ = c.Bind(&req)
+	row, err := h.Series.Update(c.Request().Context(), c.Param("id"), req.toModel())
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, response.Success(seriesDTO(*row)))
+}
+
+func (h *SeriesHandler) Delete(c echo.Context) error {
+	if err := middleware.AdminRequirePermission(c, "articles:write"); err != nil {
+		return err
+	}
+	if err := h.Series.Delete(c.Request().Context(), c.Param("id")); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, response.SuccessWithoutData())
+}
+
+type seriesItemsRequest struct {
+	Items []service.SeriesItemRef `json:"items"`
+}
+
+// SetItems replaces the ordered items of a series:
+// {"items": [{"type": "article", "id": "..."}, ...]}.
+func (h *SeriesHandler) SetItems(c echo.Context) error {
+	if err := middleware.AdminRequirePermission(c, "articles:write"); err != nil {
+		return err
+	}
+	var req seriesItemsRequest
+	_ = c.Bind(&req)
+	if err := h.Series.SetItems(c.Request().Context(), c.Param("id"), req.Items); err != nil {
+		return err
+	}
+	return h.Detail(c)
+}
+
+func seriesDTO(s model.Series) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          s.ID,
+		"kind":        s.Kind,
+		"title":       s.Title,
+		"slug":        s.Slug,
+		"description": s.Description,
+		"cover_image": s.CoverImage,
+		"is_visible":  s.IsVisible,
+		"sort_order":  s.SortOrder,
+		"created_at":  s.CreatedAt,
+		"updated_at":  s.UpdatedAt,
+	}
+}
@@ -6,6 +6,7 @@ import (
 	kxlerrors "github.com/linkyfish/kxl_backend_go/internal/errors"
 	"github.com/linkyfish/kxl_backend_go/internal/dto/response"
 	"github.com/linkyfish/kxl_backend_go/internal/middleware"
+	"github.com/linkyfish/kxl_backend_go/internal/model"
 	"github.com/linkyfish/kxl_backend_go/internal/service"
 	"github.com/labstack/echo/v4"
 )
@@ -22,11 +23,11 @@ func (h *UploadHandler) UploadImage(c echo.Context) error {
 	if err != nil || f == nil {
 		return kxlerrors.Validation("validation error: missing file")
 	}
-	url, err := h.Uploads.Upload(c.Request().Context(), f, service.UploadKindImage)
+	res, err := h.Uploads.Upload(c.Request().Context(), f, service.UploadKindImage, currentUploader(c))
 	if err != nil {
 		return err
 	}
-	return c.JSON(http.StatusOK, response.Success(map[string]interface{}{"url": url}))
+	return c.JSON(http.StatusOK, response.Success(res))
 }
 
 func (h *UploadHandler) UploadVideo(c echo.Context) error {
@@ -37,11 +38,11 @@ func (h *UploadHandler) UploadVideo(c echo.Context) error {
 	if err != nil || f == nil {
 		return kxlerrors.Validation("validation error: missing file")
 	}
-	url, err := h.Uploads.Upload(c.Request().Context(), f, service.UploadKindVideo)
+	res, err := h.Uploads.Upload(c.Request().Context(), f, service.UploadKindVideo, currentUploader(c))
 	if err != nil {
 		return err
 	}
-	return c.JSON(http.StatusOK, response.Success(map[string]interface{}{"url": url}))
+	return c.JSON(http.StatusOK, response.Success(res))
 }
 
 func (h *UploadHandler) DeleteFile(c echo.Context) error {
@@ -55,3 +56,10 @@ func (h *UploadHandler) DeleteFile(c echo.Context) error {
 	return c.JSON(http.StatusOK, response.SuccessWithoutData())
 }
 
+// currentUploader attributes admin uploads in the media library.
+func currentUploader(c echo.Context) service.Uploader {
+	if a, ok := c.Get("current_admin").(*model.Admin); ok && a != nil {
+		return service.Uploader{Type: model.UploaderTypeAdmin, ID: a.ID}
+	}
+	return service.Uploader{}
+}
@@ -8,11 +8,13 @@ import (
 	"github.com/linkyfish/kxl_backend_go/internal/dto/response"
 	"github.com/linkyfish/kxl_backend_go/internal/middleware"
 	"github.com/linkyfish/kxl_backend_go/internal/service"
+	"github.com/linkyfish/kxl_backend_go/internal/util"
 	"github.com/labstack/echo/v4"
 )
 
 type UserHandler struct {
-	Users *service.UserService
+	Users   *service.UserService
+	Uploads *service.UploadService
 }
 
 func (h *UserHandler) ListUsers(c echo.Context) error {
@@ -122,6 +124,49 @@ func (h *UserHandler) UpdateUserStatus(c echo.Context) error {
 	}))
 }
 
+func (h *UserHandler) UploadQuota(c echo.Context) error {
+	if err := middleware.AdminRequirePermission(c, "users:read"); err != nil {
+		return err
+	}
+	id := c.Param("id")
+	if !util.IsUUID(id) {
+		return kxlerrors.NotFound("not found: user not found")
+	}
+	if _, err := h.Users.GetUser(c.Request().Context(), id); err != nil {
+		return err
+	}
+	q, err := h.Uploads.Quota(c.Request().Context(), id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, response.Success(q))
+}
+
+// updateUploadQuotaRequest sets per-user limits; null restores the default.
+type updateUploadQuotaRequest struct {
+	MaxBytes *int64 `json:"max_bytes"`
+	MaxFiles *int64 `json:"max_files"`
+}
+
+func (h *UserHandler) UpdateUploadQuota(c echo.Context) error {
+	if err := middleware.AdminRequirePermission(c, "users:write"); err != nil {
+		return err
+	}
+	id := c.Param("id")
+	if !util.IsUUID(id) {
+		return kxlerrors.NotFound("not found: user not found")
+	}
+	var req updateUploadQuotaRequest
+	if err := c.Bind(&req); err != nil {
+		return kxlerrors.Validation("validation error: invalid body")
+	}
+	q, err := h.Uploads.SetQuotaLimits(c.Request().Context(), id, req.MaxBytes, req.MaxFiles)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, response.Success(q))
+}
+
 func (h *UserHandler) ListAdmins(c echo.Context) error {
 	if err := middleware.AdminRequirePermission(c, "admins:manage"); err != nil {
 		return err
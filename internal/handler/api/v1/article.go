@@ -15,6 +15,8 @@ import (
 type ArticleHandler struct {
 	DB       *gorm.DB
 	Articles *service.ArticleService
+	Slugs    *service.SlugService
+	Series   *service.SeriesService
 }
 
 func (h *ArticleHandler) List(c echo.Context) error {
@@ -89,6 +91,7 @@ func (h *ArticleHandler) List(c echo.Context) error {
 		items = append(items, map[string]interface{}{
 			"id":           a.ID,
 			"title":        a.Title,
+			"slug":         a.Slug,
 			"summary":      a.Summary,
 			"cover_image":  a.CoverImage,
 			"category":     category,
@@ -112,13 +115,20 @@ func (h *ArticleHandler) List(c echo.Context) error {
 }
 
 func (h *ArticleHandler) Detail(c echo.Context) error {
-	id := c.Param("id")
-	a, err := h.Articles.GetPublic(c.Request().Context(), id)
+	key := c.Param("id")
+	target, err := h.Slugs.Resolve(c.Request().Context(), service.ContentArticle, key)
 	if err != nil {
 		return err
 	}
+	a, err := h.Articles.GetPublic(c.Request().Context(), target.ID)
+	if err != nil {
+		return err
+	}
+	if target.Redirect(key) {
+		return redirectCanonical(c, "/api/v1/articles/", target.Canonical)
+	}
 	oldViewCount := a.ViewCount
-	_ = h.Articles.IncrementViewCount(c.Request().Context(), id)
+	_ = h.Articles.IncrementViewCount(c.Request().Context(), a.ID)
 
 	var category interface{} = nil
 	if a.CategoryID != nil {
@@ -141,18 +151,31 @@ func (h *ArticleHandler) Detail(c echo.Context) error {
 		tags = append(tags, tagDTO(t))
 	}
 
+	series, err := h.Series.Navigation(c.Request().Context(), service.ContentArticle, a.ID)
+	if err != nil {
+		return err
+	}
+
+	outline := service.OutlineArticle(a)
 	return c.JSON(http.StatusOK, response.Success(map[string]interface{}{
-		"id":           a.ID,
-		"title":        a.Title,
-		"summary":      a.Summary,
-		"content":      a.Content,
-		"cover_image":  a.CoverImage,
-		"category":     category,
-		"published_at": a.PublishedAt,
-		"view_count":   oldViewCount + 1,
-		"tags":         tags,
-		"created_at":   a.CreatedAt,
-		"updated_at":   a.UpdatedAt,
+		"id":              a.ID,
+		"title":           a.Title,
+		"slug":            a.Slug,
+		"summary":         a.Summary,
+		"content":         outline.HTML,
+		"content_format":  a.ContentFormat,
+		"toc":             outline.TOC,
+		"word_count":      outline.WordCount,
+		"char_count":      outline.CharCount,
+		"reading_minutes": outline.ReadingMinutes,
+		"cover_image":     a.CoverImage,
+		"category":        category,
+		"published_at":    a.PublishedAt,
+		"view_count":      oldViewCount + 1,
+		"tags":            tags,
+		"series":          seriesNavDTO(series),
+		"created_at":      a.CreatedAt,
+		"updated_at":      a.UpdatedAt,
 	}))
 }
 
@@ -198,6 +221,7 @@ func (h *ArticleHandler) Related(c echo.Context) error {
 		items = append(items, map[string]interface{}{
 			"id":           a.ID,
 			"title":        a.Title,
+			"slug":         a.Slug,
 			"summary":      a.Summary,
 			"cover_image":  a.CoverImage,
 			"category":     category,
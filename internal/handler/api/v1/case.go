@@ -16,6 +16,7 @@ type CaseHandler struct {
 	DB       *gorm.DB
 	Cases    *service.CaseService
 	Projects *service.ProjectService
+	Slugs    *service.SlugService
 }
 
 func (h *CaseHandler) List(c echo.Context) error {
@@ -77,6 +78,7 @@ func (h *CaseHandler) List(c echo.Context) error {
 		items = append(items, map[string]interface{}{
 			"id":          row.ID,
 			"client_name": row.ClientName,
+			"slug":        row.Slug,
 			"cover_image": row.CoverImage,
 			"summary":     row.Summary,
 			"status":      row.Status,
@@ -98,11 +100,18 @@ func (h *CaseHandler) List(c echo.Context) error {
 }
 
 func (h *CaseHandler) Detail(c echo.Context) error {
-	id := c.Param("id")
-	cs, err := h.Cases.GetPublic(c.Request().Context(), id)
+	key := c.Param("id")
+	target, err := h.Slugs.Resolve(c.Request().Context(), service.ContentCase, key)
 	if err != nil {
 		return err
 	}
+	cs, err := h.Cases.GetPublic(c.Request().Context(), target.ID)
+	if err != nil {
+		return err
+	}
+	if target.Redirect(key) {
+		return redirectCanonical(c, "/api/v1/cases/", target.Canonical)
+	}
 
 	var category interface{} = nil
 	if cs.CategoryID != nil {
@@ -122,7 +131,7 @@ func (h *CaseHandler) Detail(c echo.Context) error {
 		var projects []model.Project
 		if err := h.DB.WithContext(c.Request().Context()).
 			Where("id in ?", projectIDs).
-			Where("status = ?", 1).
+			Scopes(service.Published).
 			Order("sort_order asc").Order("id asc").
 			Find(&projects).Error; err != nil {
 			return kxlerrors.Internal("db error")
@@ -164,6 +173,7 @@ func (h *CaseHandler) Detail(c echo.Context) error {
 			relatedProjects = append(relatedProjects, map[string]interface{}{
 				"id":          p.ID,
 				"name":        p.Name,
+				"slug":        p.Slug,
 				"description": p.Description,
 				"cover_image": p.CoverImage,
 				"status":      p.Status,
@@ -177,10 +187,12 @@ func (h *CaseHandler) Detail(c echo.Context) error {
 	return c.JSON(http.StatusOK, response.Success(map[string]interface{}{
 		"id":                 cs.ID,
 		"client_name":        cs.ClientName,
+		"slug":               cs.Slug,
 		"cover_image":        cs.CoverImage,
 		"summary":            cs.Summary,
-		"background":         cs.Background,
-		"solution":           cs.Solution,
+		"background":         cs.RenderedBackground(),
+		"solution":           cs.RenderedSolution(),
+		"content_format":     cs.ContentFormat,
 		"results":            cs.Results,
 		"testimonial":        cs.Testimonial,
 		"testimonial_author": cs.TestimonialAuthor,
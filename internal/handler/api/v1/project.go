@@ -15,6 +15,7 @@ import (
 type ProjectHandler struct {
 	DB       *gorm.DB
 	Projects *service.ProjectService
+	Slugs    *service.SlugService
 }
 
 func (h *ProjectHandler) List(c echo.Context) error {
@@ -86,6 +87,7 @@ func (h *ProjectHandler) List(c echo.Context) error {
 		items = append(items, map[string]interface{}{
 			"id":          p.ID,
 			"name":        p.Name,
+			"slug":        p.Slug,
 			"description": p.Description,
 			"cover_image": p.CoverImage,
 			"status":      p.Status,
@@ -109,11 +111,18 @@ func (h *ProjectHandler) List(c echo.Context) error {
 }
 
 func (h *ProjectHandler) Detail(c echo.Context) error {
-	id := c.Param("id")
-	p, err := h.Projects.GetPublic(c.Request().Context(), id)
+	key := c.Param("id")
+	target, err := h.Slugs.Resolve(c.Request().Context(), service.ContentProject, key)
 	if err != nil {
 		return err
 	}
+	p, err := h.Projects.GetPublic(c.Request().Context(), target.ID)
+	if err != nil {
+		return err
+	}
+	if target.Redirect(key) {
+		return redirectCanonical(c, "/api/v1/projects/", target.Canonical)
+	}
 
 	features, err := h.Projects.ListFeatures(c.Request().Context(), p.ID)
 	if err != nil {
@@ -183,6 +192,7 @@ func (h *ProjectHandler) Detail(c echo.Context) error {
 	return c.JSON(http.StatusOK, response.Success(map[string]interface{}{
 		"id":          p.ID,
 		"name":        p.Name,
+		"slug":        p.Slug,
 		"description": p.Description,
 		"cover_image": p.CoverImage,
 		"status":      p.Status,
@@ -12,6 +12,7 @@ import (
 
 type SearchHandler struct {
 	SearchSvc *service.SearchService
+	Logs      *service.SearchLogService
 }
 
 func (h *SearchHandler) Search(c echo.Context) error {
@@ -20,9 +21,20 @@ func (h *SearchHandler) Search(c echo.Context) error {
 		return kxlerrors.Validation("validation error: q is required")
 	}
 	typ := c.QueryParam("type")
-	var typPtr *string
-	if typ != "" {
-		typPtr = &typ
+	opts := service.SearchOptions{Type: typ, Cursor: c.QueryParam("cursor"), Client: c.RealIP()}
+	if raw := c.QueryParam("category_id"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return kxlerrors.Validation("validation error: invalid category_id")
+		}
+		opts.CategoryID = &n
+	}
+	if raw := c.QueryParam("tag_id"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return kxlerrors.Validation("validation error: invalid tag_id")
+		}
+		opts.TagID = &n
 	}
 
 	page := int64(1)
@@ -44,25 +56,54 @@ func (h *SearchHandler) Search(c echo.Context) error {
 		return kxlerrors.Validation("validation error: page_size must be between 1 and 200")
 	}
 
-	items, total, err := h.SearchSvc.Search(c.Request().Context(), q, typPtr, page, pageSize)
+	res, err := h.SearchSvc.Search(c.Request().Context(), q, opts, page, pageSize)
 	if err != nil {
 		return err
 	}
+	// Follow-up pages of an infinite scroll (cursor set) skip the facets and
+	// the query log; the first page already covered both.
+	var facets *service.SearchFacets
+	if opts.Cursor == "" {
+		facets, err = h.SearchSvc.Facets(c.Request().Context(), q, opts)
+		if err != nil {
+			return err
+		}
+		if page == 1 {
+			h.Logs.RecordQuery(q, h.SearchSvc.FilterType(typ), res.Total, c.RealIP())
+		}
+	}
 
 	totalPages := int64(0)
-	if total > 0 {
-		totalPages = (total + pageSize - 1) / pageSize
+	if res.Total > 0 {
+		totalPages = (res.Total + pageSize - 1) / pageSize
+	}
+	var next interface{}
+	if res.Next != "" {
+		next = res.Next
 	}
 	return c.JSON(http.StatusOK, response.Success(map[string]interface{}{
-		"items":       items,
-		"total":       total,
+		"items":       res.Items,
+		"total":       res.Total,
 		"page":        page,
 		"page_size":   pageSize,
 		"total_pages": totalPages,
+		"next":        next,
+		"facets":      facets,
 	}))
 }
 
 func (h *SearchHandler) Suggestions(c echo.Context) error {
-	items, _ := h.SearchSvc.Suggestions(c.Request().Context())
+	limit := 10
+	if raw := c.QueryParam("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return kxlerrors.Validation("validation error: invalid limit")
+		}
+		limit = n
+	}
+	items, err := h.SearchSvc.Suggestions(c.Request().Context(), c.QueryParam("q"), limit)
+	if err != nil {
+		return err
+	}
 	return c.JSON(http.StatusOK, response.Success(items))
 }
@@ -0,0 +1,69 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/linkyfish/kxl_backend_go/internal/dto/response"
+	"github.com/linkyfish/kxl_backend_go/internal/model"
+	"github.com/linkyfish/kxl_backend_go/internal/service"
+)
+
+type SeriesHandler struct {
+	Series *service.SeriesService
+}
+
+func (h *SeriesHandler) List(c echo.Context) error {
+	rows, err := h.Series.ListVisible(c.Request().Context())
+	if err != nil {
+		return err
+	}
+	data := make([]map[string]interface{}, 0, len(rows))
+	for _, s := range rows {
+		data = append(data, seriesDTO(s))
+	}
+	return c.JSON(http.StatusOK, response.Success(data))
+}
+
+// Detail returns a series by slug or id with its published items in order.
+func (h *SeriesHandler) Detail(c echo.Context) error {
+	row, err := h.Series.GetPublic(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return err
+	}
+	items, err := h.Series.Entries(c.Request().Context(), row.ID, true)
+	if err != nil {
+		return err
+	}
+	data := seriesDTO(*row)
+	data["items"] = items
+	return c.JSON(http.StatusOK, response.Success(data))
+}
+
+func seriesDTO(s model.Series) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          s.ID,
+		"kind":        s.Kind,
+		"title":       s.Title,
+		"slug":        s.Slug,
+		"description": s.Description,
+		"cover_image": s.CoverImage,
+		"created_at":  s.CreatedAt,
+		"updated_at":  s.UpdatedAt,
+	}
+}
+
+// seriesNavDTO describes where content sits in each series it belongs to.
+func seriesNavDTO(navs []service.SeriesNav) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(navs))
+	for _, n := range navs {
+		out = append(out, map[string]interface{}{
+			"series": seriesDTO(n.Series),
+			"part":   n.Part,
+			"total":  n.Total,
+			"prev":   n.Prev,
+			"next":   n.Next,
+		})
+	}
+	return out
+}
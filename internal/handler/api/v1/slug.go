@@ -0,0 +1,18 @@
+package v1
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+)
+
+// redirectCanonical sends a detail request made by id or by a replaced slug
+// to base+slug with a permanent redirect, keeping the query string.
+func redirectCanonical(c echo.Context, base, slug string) error {
+	target := base + url.PathEscape(slug)
+	if q := c.Request().URL.RawQuery; q != "" {
+		target += "?" + q
+	}
+	return c.Redirect(http.StatusMovedPermanently, target)
+}
@@ -2,9 +2,11 @@ package upload
 
 import (
 	"net/http"
+	"strconv"
 
 	kxlerrors "github.com/linkyfish/kxl_backend_go/internal/errors"
 	"github.com/linkyfish/kxl_backend_go/internal/dto/response"
+	"github.com/linkyfish/kxl_backend_go/internal/model"
 	"github.com/linkyfish/kxl_backend_go/internal/service"
 	"github.com/labstack/echo/v4"
 )
@@ -14,26 +16,95 @@ type UploadHandler struct {
 }
 
 func (h *UploadHandler) UploadImage(c echo.Context) error {
+	return h.upload(c, service.UploadKindImage)
+}
+
+func (h *UploadHandler) UploadVideo(c echo.Context) error {
+	return h.upload(c, service.UploadKindVideo)
+}
+
+// upload runs behind AuthUploader, so the current user is always set and the
+// upload counts against their quota.
+func (h *UploadHandler) upload(c echo.Context, kind string) error {
+	u, _ := c.Get("current_user").(*model.User)
+	if u == nil {
+		return kxlerrors.Unauthorized()
+	}
+	if k, _ := c.Get("upload_ticket_kind").(string); k != "" && k != kind {
+		return kxlerrors.Forbidden()
+	}
 	f, err := c.FormFile("file")
 	if err != nil || f == nil {
 		return kxlerrors.Validation("validation error: missing file")
 	}
-	url, err := h.Uploads.Upload(c.Request().Context(), f, service.UploadKindImage)
+	res, err := h.Uploads.Upload(c.Request().Context(), f, kind, service.Uploader{Type: model.UploaderTypeUser, ID: u.ID})
 	if err != nil {
 		return err
 	}
-	return c.JSON(http.StatusOK, response.Success(map[string]interface{}{"url": url}))
+	return c.JSON(http.StatusOK, response.Success(res))
 }
 
-func (h *UploadHandler) UploadVideo(c echo.Context) error {
-	f, err := c.FormFile("file")
-	if err != nil || f == nil {
-		return kxlerrors.Validation("validation error: missing file")
+type issueTicketRequest struct {
+	Kind string `json:"kind" form:"kind"`
+}
+
+// IssueTicket hands the signed-in user a short-lived ticket for uploading
+// without the session cookie.
+func (h *UploadHandler) IssueTicket(c echo.Context) error {
+	u, _ := c.Get("current_user").(*model.User)
+	if u == nil {
+		return kxlerrors.Unauthorized()
 	}
-	url, err := h.Uploads.Upload(c.Request().Context(), f, service.UploadKindVideo)
+	var req issueTicketRequest
+	_ = c.Bind(&req)
+	ticket, err := h.Uploads.IssueTicket(u.ID, req.Kind)
 	if err != nil {
 		return err
 	}
-	return c.JSON(http.StatusOK, response.Success(map[string]interface{}{"url": url}))
+	return c.JSON(http.StatusOK, response.Success(ticket))
 }
 
+func (h *UploadHandler) Quota(c echo.Context) error {
+	u, _ := c.Get("current_user").(*model.User)
+	if u == nil {
+		return kxlerrors.Unauthorized()
+	}
+	q, err := h.Uploads.Quota(c.Request().Context(), u.ID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, response.Success(q))
+}
+
+// RefusePrivate answers 404 for keys that must not be served (resumable
+// upload chunks); it guards the static handler for local uploads.
+func RefusePrivate(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if service.IsPrivateUploadKey(c.Param("*")) {
+			return echo.ErrNotFound
+		}
+		return next(c)
+	}
+}
+
+// Serve streams /uploads/* from the storage backend. It is only mounted when
+// uploads do not live in a local directory that Echo can serve statically.
+func (h *UploadHandler) Serve(c echo.Context) error {
+	rc, obj, err := h.Uploads.Open(c.Request().Context(), c.Param("*"))
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	contentType := obj.ContentType
+	if contentType == "" {
+		contentType = echo.MIMEOctetStream
+	}
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentLength, strconv.FormatInt(obj.Size, 10))
+	res.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	if !obj.ModTime.IsZero() {
+		res.Header().Set(echo.HeaderLastModified, obj.ModTime.UTC().Format(http.TimeFormat))
+	}
+	return c.Stream(http.StatusOK, contentType, rc)
+}
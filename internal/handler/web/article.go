@@ -18,6 +18,9 @@ type ArticleHandler struct {
 	Settings *service.SettingsService
 	Friendly *service.FriendlyLinkService
 	Articles *service.ArticleService
+	Previews *service.PreviewService
+	Slugs    *service.SlugService
+	Series   *service.SeriesService
 }
 
 func (h *ArticleHandler) List(c echo.Context) error {
@@ -101,15 +104,28 @@ func (h *ArticleHandler) Detail(c echo.Context) error {
 		return err
 	}
 
-	id := strings.TrimSpace(c.Param("id"))
-	if id == "" {
+	key := strings.TrimSpace(c.Param("id"))
+	if key == "" {
 		return kxlerrors.NotFound("not found: article not found")
 	}
+	target, err := h.Slugs.Resolve(c.Request().Context(), service.ContentArticle, key)
+	if err != nil {
+		return err
+	}
 
-	a, err := h.Articles.GetPublic(c.Request().Context(), id)
+	preview := previewRequested(c, h.Previews, service.ContentArticle, target.ID)
+	var a *model.Article
+	if preview {
+		a, err = h.Articles.GetPreview(c.Request().Context(), target.ID)
+	} else {
+		a, err = h.Articles.GetPublic(c.Request().Context(), target.ID)
+	}
 	if err != nil {
 		return err
 	}
+	if target.Redirect(key) {
+		return redirectCanonical(c, "/articles/", target.Canonical)
+	}
 
 	// Category
 	var category interface{} = nil
@@ -130,19 +146,30 @@ func (h *ArticleHandler) Detail(c echo.Context) error {
 		tags = append(tags, tagDTO(t))
 	}
 
+	outline := service.OutlineArticle(a)
+	toc := make([]map[string]interface{}, 0, len(outline.TOC))
+	for _, e := range outline.TOC {
+		toc = append(toc, map[string]interface{}{"level": e.Level, "id": e.ID, "text": e.Text})
+	}
+
 	article := map[string]interface{}{
-		"id":           a.ID,
-		"title":        a.Title,
-		"summary":      a.Summary,
-		"content":      a.Content,
-		"cover_image":  a.CoverImage,
-		"category":     category,
-		"published_at": a.PublishedAt,
-		"view_count":   a.ViewCount,
-		"tags":         tags,
-		"author":       nil,
-		"created_at":   a.CreatedAt,
-		"updated_at":   a.UpdatedAt,
+		"id":              a.ID,
+		"slug":            a.Slug,
+		"title":           a.Title,
+		"summary":         a.Summary,
+		"content":         outline.HTML,
+		"toc":             toc,
+		"word_count":      outline.WordCount,
+		"char_count":      outline.CharCount,
+		"reading_minutes": outline.ReadingMinutes,
+		"cover_image":     a.CoverImage,
+		"category":        category,
+		"published_at":    a.PublishedAt,
+		"view_count":      a.ViewCount,
+		"tags":            tags,
+		"author":          nil,
+		"created_at":      a.CreatedAt,
+		"updated_at":      a.UpdatedAt,
 	}
 
 	// Prev/Next navigation.
@@ -151,16 +178,32 @@ func (h *ArticleHandler) Detail(c echo.Context) error {
 	if prevID, nextID, err := h.Articles.NavigationPublic(c.Request().Context(), a.ID); err == nil {
 		if prevID != nil {
 			if pa, err := h.Articles.GetPublic(c.Request().Context(), *prevID); err == nil {
-				prevArticle = map[string]interface{}{"id": pa.ID, "title": pa.Title}
+				prevArticle = map[string]interface{}{"id": pa.ID, "slug": pa.Slug, "title": pa.Title}
 			}
 		}
 		if nextID != nil {
 			if na, err := h.Articles.GetPublic(c.Request().Context(), *nextID); err == nil {
-				nextArticle = map[string]interface{}{"id": na.ID, "title": na.Title}
+				nextArticle = map[string]interface{}{"id": na.ID, "slug": na.Slug, "title": na.Title}
 			}
 		}
 	}
 
+	// Place in the series and collections the article belongs to.
+	series := []map[string]interface{}{}
+	if navs, err := h.Series.Navigation(c.Request().Context(), service.ContentArticle, a.ID); err == nil {
+		for _, n := range navs {
+			series = append(series, map[string]interface{}{
+				"title": n.Series.Title,
+				"slug":  n.Series.Slug,
+				"kind":  n.Series.Kind,
+				"part":  n.Part,
+				"total": n.Total,
+				"prev":  seriesEntryDTO(n.Prev),
+				"next":  seriesEntryDTO(n.Next),
+			})
+		}
+	}
+
 	related := []map[string]interface{}{}
 	if rows, err := h.Articles.RelatedPublic(c.Request().Context(), a.ID); err == nil {
 		items, err := buildArticleListItems(c.Request().Context(), rows, h.Articles)
@@ -175,12 +218,22 @@ func (h *ArticleHandler) Detail(c echo.Context) error {
 		"article":         article,
 		"prev_article":    prevArticle,
 		"next_article":    nextArticle,
+		"series":          series,
 		"related_articles": related,
+		"preview":         preview,
 	}
 	InjectBaseContext(ctx, c, base)
+	setCanonicalURL(ctx, "/articles/", a.Slug, a.ID)
 	return c.Render(http.StatusOK, "pages/articles/detail.html", ctx)
 }
 
+func seriesEntryDTO(e *service.SeriesEntry) interface{} {
+	if e == nil {
+		return nil
+	}
+	return map[string]interface{}{"type": e.Type, "title": e.Title, "url": e.URL}
+}
+
 func categoryDTOs(rows []model.Category) []map[string]interface{} {
 	if len(rows) == 0 {
 		return []map[string]interface{}{}
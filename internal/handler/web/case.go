@@ -21,6 +21,8 @@ type CaseHandler struct {
 	Friendly *service.FriendlyLinkService
 	Cases    *service.CaseService
 	Projects *service.ProjectService
+	Previews *service.PreviewService
+	Slugs    *service.SlugService
 }
 
 func (h *CaseHandler) List(c echo.Context) error {
@@ -97,15 +99,28 @@ func (h *CaseHandler) Detail(c echo.Context) error {
 		return err
 	}
 
-	id := strings.TrimSpace(c.Param("id"))
-	if id == "" {
+	key := strings.TrimSpace(c.Param("id"))
+	if key == "" {
 		return echo.NewHTTPError(http.StatusNotFound)
 	}
+	target, err := h.Slugs.Resolve(c.Request().Context(), service.ContentCase, key)
+	if err != nil {
+		return err
+	}
 
-	cs, err := h.Cases.GetPublic(c.Request().Context(), id)
+	preview := previewRequested(c, h.Previews, service.ContentCase, target.ID)
+	var cs *model.CaseStudy
+	if preview {
+		cs, err = h.Cases.GetPreview(c.Request().Context(), target.ID)
+	} else {
+		cs, err = h.Cases.GetPublic(c.Request().Context(), target.ID)
+	}
 	if err != nil {
 		return err
 	}
+	if target.Redirect(key) {
+		return redirectCanonical(c, "/cases/", target.Canonical)
+	}
 
 	// Category
 	var category interface{} = nil
@@ -127,7 +142,7 @@ func (h *CaseHandler) Detail(c echo.Context) error {
 			var projects []model.Project
 			if err := h.DB.WithContext(c.Request().Context()).
 				Where("id in ?", projectIDs).
-				Where("status = ?", 1).
+				Scopes(service.Published).
 				Order("sort_order asc").Order("id asc").
 				Find(&projects).Error; err != nil {
 				return kxlerrors.Internal("db error")
@@ -168,6 +183,7 @@ func (h *CaseHandler) Detail(c echo.Context) error {
 				}
 				relatedProjects = append(relatedProjects, map[string]interface{}{
 					"id":          p.ID,
+					"slug":        p.Slug,
 					"name":        p.Name,
 					"description": p.Description,
 					"cover_image": p.CoverImage,
@@ -184,12 +200,13 @@ func (h *CaseHandler) Detail(c echo.Context) error {
 
 	caseObj := map[string]interface{}{
 		"id":                 cs.ID,
+		"slug":               cs.Slug,
 		"client_name":        cs.ClientName,
 		"client_logo":        nil,
 		"cover_image":        cs.CoverImage,
 		"summary":            cs.Summary,
-		"background":         cs.Background,
-		"solution":           cs.Solution,
+		"background":         cs.RenderedBackground(),
+		"solution":           cs.RenderedSolution(),
 		"results":            results,
 		"testimonial":        cs.Testimonial,
 		"testimonial_author": cs.TestimonialAuthor,
@@ -205,8 +222,10 @@ func (h *CaseHandler) Detail(c echo.Context) error {
 		"page_title":  cs.ClientName,
 		"breadcrumbs": []map[string]interface{}{{"title": "成功案例", "url": "/cases"}, {"title": cs.ClientName, "url": ""}},
 		"case":        caseObj,
+		"preview":     preview,
 	}
 	InjectBaseContext(ctx, c, base)
+	setCanonicalURL(ctx, "/cases/", cs.Slug, cs.ID)
 	return c.Render(http.StatusOK, "pages/cases/detail.html", ctx)
 }
 
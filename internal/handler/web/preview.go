@@ -0,0 +1,23 @@
+package web
+
+import (
+	"strings"
+
+	"github.com/linkyfish/kxl_backend_go/internal/service"
+	"github.com/labstack/echo/v4"
+)
+
+// previewRequested reports whether the request carries a valid `preview`
+// token for typ/id. Preview responses are kept out of search engines and
+// shared caches, and don't pass the token on in the Referer header.
+func previewRequested(c echo.Context, previews *service.PreviewService, typ, id string) bool {
+	token := strings.TrimSpace(c.QueryParam("preview"))
+	if token == "" || !previews.Verify(token, typ, id) {
+		return false
+	}
+	h := c.Response().Header()
+	h.Set("X-Robots-Tag", "noindex, nofollow")
+	h.Set("Cache-Control", "private, no-store")
+	h.Set("Referrer-Policy", "no-referrer")
+	return true
+}
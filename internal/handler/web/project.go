@@ -17,6 +17,8 @@ type ProjectHandler struct {
 	Settings *service.SettingsService
 	Friendly *service.FriendlyLinkService
 	Projects *service.ProjectService
+	Previews *service.PreviewService
+	Slugs    *service.SlugService
 }
 
 func (h *ProjectHandler) List(c echo.Context) error {
@@ -93,15 +95,28 @@ func (h *ProjectHandler) Detail(c echo.Context) error {
 		return err
 	}
 
-	id := strings.TrimSpace(c.Param("id"))
-	if id == "" {
+	key := strings.TrimSpace(c.Param("id"))
+	if key == "" {
 		return echo.NewHTTPError(http.StatusNotFound)
 	}
+	target, err := h.Slugs.Resolve(c.Request().Context(), service.ContentProject, key)
+	if err != nil {
+		return err
+	}
 
-	p, err := h.Projects.GetPublic(c.Request().Context(), id)
+	preview := previewRequested(c, h.Previews, service.ContentProject, target.ID)
+	var p *model.Project
+	if preview {
+		p, err = h.Projects.GetPreview(c.Request().Context(), target.ID)
+	} else {
+		p, err = h.Projects.GetPublic(c.Request().Context(), target.ID)
+	}
 	if err != nil {
 		return err
 	}
+	if target.Redirect(key) {
+		return redirectCanonical(c, "/projects/", target.Canonical)
+	}
 
 	features, err := h.Projects.ListFeatures(c.Request().Context(), p.ID)
 	if err != nil {
@@ -172,6 +187,7 @@ func (h *ProjectHandler) Detail(c echo.Context) error {
 
 	project := map[string]interface{}{
 		"id":          p.ID,
+		"slug":        p.Slug,
 		"name":        p.Name,
 		"description": p.Description,
 		"cover_image": p.CoverImage,
@@ -197,8 +213,10 @@ func (h *ProjectHandler) Detail(c echo.Context) error {
 		"page_title":  p.Name,
 		"breadcrumbs": []map[string]interface{}{{"title": "软件产品", "url": "/projects"}, {"title": p.Name, "url": ""}},
 		"project":     project,
+		"preview":     preview,
 	}
 	InjectBaseContext(ctx, c, base)
+	setCanonicalURL(ctx, "/projects/", p.Slug, p.ID)
 	return c.Render(http.StatusOK, "pages/projects/detail.html", ctx)
 }
 
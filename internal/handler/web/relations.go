@@ -48,6 +48,7 @@ func buildArticleListItems(ctx context.Context, rows []model.Article, svc *servi
 		}
 		items = append(items, map[string]interface{}{
 			"id":           a.ID,
+			"slug":         a.Slug,
 			"title":        a.Title,
 			"summary":      a.Summary,
 			"cover_image":  a.CoverImage,
@@ -103,6 +104,7 @@ func buildProjectListItems(ctx context.Context, rows []model.Project, svc *servi
 		}
 		items = append(items, map[string]interface{}{
 			"id":          p.ID,
+			"slug":        p.Slug,
 			"name":        p.Name,
 			"description": p.Description,
 			"cover_image": p.CoverImage,
@@ -151,6 +153,7 @@ func buildCaseListItems(ctx context.Context, rows []model.CaseStudy, svc *servic
 		}
 		items = append(items, map[string]interface{}{
 			"id":               row.ID,
+			"slug":             row.Slug,
 			"client_name":      row.ClientName,
 			"client_logo":      nil,
 			"cover_image":      row.CoverImage,
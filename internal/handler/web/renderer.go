@@ -1,7 +1,9 @@
 package web
 
 import (
+	"context"
 	"fmt"
+	"html"
 	"io"
 	"strings"
 	"sync"
@@ -9,16 +11,42 @@ import (
 
 	"github.com/flosch/pongo2/v6"
 	"github.com/labstack/echo/v4"
+	"github.com/linkyfish/kxl_backend_go/internal/service"
 )
 
 var registerOnce sync.Once
 
+// imageVariants resolves derivative URLs for the srcset filter. It is set once
+// at startup by SetImageVariantSource; without it srcset renders nothing.
+var imageVariants func(url string) []service.ImageVariant
+
+// htmlSanitizer backs the safe_html filter; nil uses the default allowlist.
+var htmlSanitizer *service.HTMLSanitizer
+
+// SetHTMLSanitizer sets the allowlist the safe_html filter applies.
+func SetHTMLSanitizer(s *service.HTMLSanitizer) {
+	htmlSanitizer = s
+}
+
+// SetImageVariantSource wires the srcset filter to the upload service.
+func SetImageVariantSource(uploads *service.UploadService) {
+	if uploads == nil {
+		imageVariants = nil
+		return
+	}
+	imageVariants = func(url string) []service.ImageVariant {
+		return uploads.ImageVariants(context.Background(), url)
+	}
+}
+
 func registerPongo2() {
 	registerOnce.Do(func() {
 		// Match the existing Rust Tera filter names used by templates.
 		_ = pongo2.RegisterFilter("truncate_text", truncateTextFilter)
 		_ = pongo2.RegisterFilter("format_date", formatDateFilter)
 		_ = pongo2.RegisterFilter("highlight", highlightFilter)
+		_ = pongo2.RegisterFilter("srcset", srcsetFilter)
+		_ = pongo2.RegisterFilter("safe_html", safeHTMLFilter)
 
 		// Global helper used in templates.
 		pongo2.Globals["current_year"] = func() int {
@@ -163,19 +191,54 @@ func formatDateFilter(in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *po
 	return pongo2.AsValue(raw), nil
 }
 
+// highlightFilter wraps occurrences of the keyword in <mark>. The text is
+// plain text (titles, generated summaries), so it is escaped first and the
+// result is marked safe; no |safe is needed after it.
 func highlightFilter(in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
-	text := in.String()
+	text := html.EscapeString(in.String())
 	keyword := ""
 	if param != nil && !param.IsNil() {
-		keyword = strings.TrimSpace(param.String())
+		keyword = html.EscapeString(strings.TrimSpace(param.String()))
 	}
 	if keyword == "" || text == "" {
-		return pongo2.AsValue(text), nil
+		return pongo2.AsSafeValue(text), nil
 	}
 	highlighted := strings.ReplaceAll(text, keyword,
 		fmt.Sprintf("<mark class=\"bg-primary-100 text-primary-800 px-1 rounded\">%s</mark>", keyword),
 	)
-	return pongo2.AsValue(highlighted), nil
+	return pongo2.AsSafeValue(highlighted), nil
+}
+
+// safeHTMLFilter sanitizes rich text against the allowlist and marks the
+// result safe: {{ article.content|safe_html }}. Use it instead of |safe for
+// anything an admin authored.
+func safeHTMLFilter(in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	if in.IsNil() {
+		return pongo2.AsSafeValue(""), nil
+	}
+	return pongo2.AsSafeValue(htmlSanitizer.Sanitize(in.String())), nil
+}
+
+// srcsetFilter turns a stored image URL into a srcset value:
+// {{ url|srcset }} lists the source-format variants, {{ url|srcset:"webp" }}
+// the WebP ones. Images without derivatives yield "".
+func srcsetFilter(in *pongo2.Value, param *pongo2.Value) (*pongo2.Value, *pongo2.Error) {
+	if imageVariants == nil {
+		return pongo2.AsValue(""), nil
+	}
+	webp := param != nil && !param.IsNil() && strings.EqualFold(strings.TrimSpace(param.String()), "webp")
+	parts := make([]string, 0, 4)
+	for _, v := range imageVariants(in.String()) {
+		u := v.URL
+		if webp {
+			u = v.WebP
+		}
+		if u == "" {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s %dw", u, v.Width))
+	}
+	return pongo2.AsValue(strings.Join(parts, ", ")), nil
 }
 
 func strftimeToGoLayout(format string) string {
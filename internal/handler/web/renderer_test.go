@@ -5,6 +5,9 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/flosch/pongo2/v6"
+	"github.com/linkyfish/kxl_backend_go/internal/service"
 )
 
 func TestTemplatesCompile(t *testing.T) {
@@ -39,3 +42,64 @@ func TestTemplatesCompile(t *testing.T) {
 		t.Fatalf("walk templates: %v", err)
 	}
 }
+
+func TestSrcsetFilter(t *testing.T) {
+	registerPongo2()
+	prev := imageVariants
+	t.Cleanup(func() { imageVariants = prev })
+	imageVariants = func(url string) []service.ImageVariant {
+		if url != "/uploads/images/a.jpg" {
+			return nil
+		}
+		return []service.ImageVariant{
+			{Width: 320, URL: "/uploads/images/a_w320.jpg", WebP: "/uploads/images/a_w320.webp"},
+			{Width: 640, URL: "/uploads/images/a_w640.jpg", WebP: "/uploads/images/a_w640.webp"},
+		}
+	}
+
+	tpl, err := pongo2.FromString(`{{ img|srcset }}|{{ img|srcset:"webp" }}|{{ other|srcset }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := tpl.Execute(pongo2.Context{"img": "/uploads/images/a.jpg", "other": "/static/logo.png"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "/uploads/images/a_w320.jpg 320w, /uploads/images/a_w640.jpg 640w|" +
+		"/uploads/images/a_w320.webp 320w, /uploads/images/a_w640.webp 640w|"
+	if out != want {
+		t.Fatalf("got %q\nwant %q", out, want)
+	}
+}
+
+func TestSafeHTMLFilter(t *testing.T) {
+	registerPongo2()
+	tpl, err := pongo2.FromString(`{{ body|safe_html }}|{{ missing|safe_html }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := tpl.Execute(pongo2.Context{"body": `<p>Hi <em>there</em><script>alert(1)</script></p>`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "<p>Hi <em>there</em></p>|"; out != want {
+		t.Fatalf("got %q\nwant %q", out, want)
+	}
+}
+
+func TestHighlightFilter_EscapesText(t *testing.T) {
+	registerPongo2()
+	tpl, err := pongo2.FromString(`{{ text|highlight:kw|safe }}|{{ text|highlight:"" }}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := tpl.Execute(pongo2.Context{"text": `<img src=x onerror=alert(1)> a&b`, "kw": "a&b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `&lt;img src=x onerror=alert(1)&gt; <mark class="bg-primary-100 text-primary-800 px-1 rounded">a&amp;b</mark>|` +
+		`&lt;img src=x onerror=alert(1)&gt; a&amp;b`
+	if out != want {
+		t.Fatalf("got %q\nwant %q", out, want)
+	}
+}
@@ -2,6 +2,7 @@ package web
 
 import (
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 
@@ -14,6 +15,7 @@ type SearchHandler struct {
 	Settings *service.SettingsService
 	Friendly *service.FriendlyLinkService
 	Search   *service.SearchService
+	Logs     *service.SearchLogService
 }
 
 func (h *SearchHandler) Index(c echo.Context) error {
@@ -24,6 +26,13 @@ func (h *SearchHandler) Index(c echo.Context) error {
 
 	keyword := strings.TrimSpace(c.QueryParam("q"))
 	searchType := strings.TrimSpace(c.QueryParam("type"))
+	opts := service.SearchOptions{Type: searchType, Client: c.RealIP()}
+	if n, err := strconv.Atoi(strings.TrimSpace(c.QueryParam("category_id"))); err == nil && n > 0 {
+		opts.CategoryID = &n
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(c.QueryParam("tag_id"))); err == nil && n > 0 {
+		opts.TagID = &n
+	}
 	page := int64(1)
 	if raw := strings.TrimSpace(c.QueryParam("page")); raw != "" {
 		if n, err := strconv.ParseInt(raw, 10, 64); err == nil && n > 0 {
@@ -34,29 +43,67 @@ func (h *SearchHandler) Index(c echo.Context) error {
 
 	results := []map[string]interface{}{}
 	var total int64 = 0
+	typeFacets := []map[string]interface{}{}
+	categoryFacets := []map[string]interface{}{}
+	tagFacets := []map[string]interface{}{}
 
 	if keyword != "" && h.Search != nil {
-		var typPtr *string
-		if searchType != "" {
-			typPtr = &searchType
-		}
-
-		items, t, err := h.Search.Search(c.Request().Context(), keyword, typPtr, page, pageSize)
+		res, err := h.Search.Search(c.Request().Context(), keyword, opts, page, pageSize)
 		if err != nil {
 			return err
 		}
-		total = t
+		total = res.Total
+		if page == 1 {
+			h.Logs.RecordQuery(keyword, h.Search.FilterType(searchType), total, c.RealIP())
+		}
 
-		for _, it := range items {
+		for _, it := range res.Items {
 			results = append(results, map[string]interface{}{
-				"id":      it.ID,
-				"type":    it.Type,
-				"title":   it.Title,
-				"url":     it.URL,
-				"excerpt": it.Summary,
-				"date":    "",
+				"id":        it.ID,
+				"type":      it.Type,
+				"title":     it.Title,
+				"url":       it.URL,
+				"click_url": it.ClickURL,
+				"excerpt":   it.Summary,
+				"date":      "",
 			})
 		}
+
+		facets, err := h.Search.Facets(c.Request().Context(), keyword, opts)
+		if err != nil {
+			return err
+		}
+		var all int64
+		for _, f := range facets.Types {
+			all += f.Count
+		}
+		// Picking a type keeps the category and tag filters.
+		typeFacets = append(typeFacets, map[string]interface{}{
+			"label":  "全部",
+			"count":  all,
+			"url":    searchPageURL(keyword, service.SearchOptions{CategoryID: opts.CategoryID, TagID: opts.TagID}),
+			"active": searchType == "",
+		})
+		for _, f := range facets.Types {
+			o := opts
+			o.Type = f.Type
+			typeFacets = append(typeFacets, map[string]interface{}{
+				"label":  f.Label,
+				"count":  f.Count,
+				"url":    searchPageURL(keyword, o),
+				"active": searchType == f.Type,
+			})
+		}
+		categoryFacets = termFacetLinks(keyword, facets.Categories, opts.CategoryID, func(id *int) service.SearchOptions {
+			o := opts
+			o.CategoryID = id
+			return o
+		})
+		tagFacets = termFacetLinks(keyword, facets.Tags, opts.TagID, func(id *int) service.SearchOptions {
+			o := opts
+			o.TagID = id
+			return o
+		})
 	}
 
 	totalPages := int64(0)
@@ -65,19 +112,19 @@ func (h *SearchHandler) Index(c echo.Context) error {
 	}
 	query := ""
 	if keyword != "" {
-		query = "q=" + keyword
-		if searchType != "" {
-			query += "&type=" + searchType
-		}
+		query = strings.TrimPrefix(searchPageURL(keyword, opts), "/search?")
 	}
 
 	ctx := pongo2.Context{
-		"page_title":  "搜索结果",
-		"breadcrumbs": []map[string]interface{}{{"title": "搜索结果", "url": "/search"}},
-		"keyword":     keyword,
-		"search_type": searchType,
-		"results":     results,
-		"total":       total,
+		"page_title":      "搜索结果",
+		"breadcrumbs":     []map[string]interface{}{{"title": "搜索结果", "url": "/search"}},
+		"keyword":         keyword,
+		"search_type":     searchType,
+		"results":         results,
+		"type_facets":     typeFacets,
+		"category_facets": categoryFacets,
+		"tag_facets":      tagFacets,
+		"total":           total,
 		"pagination": map[string]interface{}{
 			"current_page": page,
 			"total_pages":  totalPages,
@@ -90,3 +137,55 @@ func (h *SearchHandler) Index(c echo.Context) error {
 	return c.Render(http.StatusOK, "pages/search.html", ctx)
 }
 
+// searchPageURL links to the /search page for keyword with opts applied.
+func searchPageURL(keyword string, opts service.SearchOptions) string {
+	v := url.Values{}
+	v.Set("q", keyword)
+	if opts.Type != "" {
+		v.Set("type", opts.Type)
+	}
+	if opts.CategoryID != nil {
+		v.Set("category_id", strconv.Itoa(*opts.CategoryID))
+	}
+	if opts.TagID != nil {
+		v.Set("tag_id", strconv.Itoa(*opts.TagID))
+	}
+	return "/search?" + v.Encode()
+}
+
+// termFacetLinks turns category or tag facets into filter links; the link of
+// the selected entry clears the filter again.
+func termFacetLinks(keyword string, facets []service.SearchTermFacet, selected *int, with func(id *int) service.SearchOptions) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(facets))
+	for _, f := range facets {
+		active := selected != nil && *selected == f.ID
+		id := &f.ID
+		if active {
+			id = nil
+		}
+		out = append(out, map[string]interface{}{
+			"label":  f.Name,
+			"count":  f.Count,
+			"url":    searchPageURL(keyword, with(id)),
+			"active": active,
+		})
+	}
+	return out
+}
+
+// Click counts a visit to a search result and redirects to it. Links come
+// from SearchResultItem.ClickURL; the target is rebuilt from type and id (and
+// stored content), so this cannot be used as an open redirect.
+func (h *SearchHandler) Click(c echo.Context) error {
+	typ := strings.TrimSpace(c.QueryParam("type"))
+	id := strings.TrimSpace(c.QueryParam("id"))
+	if id == "" {
+		return c.Redirect(http.StatusFound, "/search")
+	}
+	target, ok := h.Search.ResultURL(c.Request().Context(), typ, id)
+	if !ok {
+		return c.Redirect(http.StatusFound, "/search")
+	}
+	h.Logs.RecordClick(c.QueryParam("q"), typ, id, target, c.RealIP())
+	return c.Redirect(http.StatusFound, target)
+}
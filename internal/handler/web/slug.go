@@ -0,0 +1,33 @@
+package web
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/flosch/pongo2/v6"
+	"github.com/labstack/echo/v4"
+)
+
+// redirectCanonical answers a detail request made by id or by a replaced
+// slug with a permanent redirect to base+slug, keeping the query string
+// (e.g. a preview token).
+func redirectCanonical(c echo.Context, base, slug string) error {
+	target := base + url.PathEscape(slug)
+	if q := c.Request().URL.RawQuery; q != "" {
+		target += "?" + q
+	}
+	return c.Redirect(http.StatusMovedPermanently, target)
+}
+
+// setCanonicalURL points the page's canonical link at its slug URL, or at
+// its id URL for content that has no slug yet. Call after InjectBaseContext.
+func setCanonicalURL(ctx pongo2.Context, base, slug, id string) {
+	origin, _ := ctx["base_url"].(string)
+	if origin == "" {
+		return
+	}
+	if slug == "" {
+		slug = id
+	}
+	ctx["canonical_url"] = origin + base + url.PathEscape(slug)
+}
@@ -3,6 +3,7 @@ package web
 import (
 	"bytes"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/flosch/pongo2/v6"
@@ -170,3 +171,107 @@ func mergeCtx(base pongo2.Context, extra pongo2.Context) pongo2.Context {
 	return out
 }
 
+
+func TestSearchPageRendersFacets(t *testing.T) {
+	r, err := NewRenderer(filepath.Join("..", "..", "..", "templates"))
+	if err != nil {
+		t.Fatalf("NewRenderer: %v", err)
+	}
+	tpl, err := r.get("pages/search.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := pongo2.Context{
+		"company":        companyDTO(nil),
+		"friendly_links": []map[string]interface{}{},
+		"current_path":   "/search",
+		"keyword":        "erp",
+		"results":        []map[string]interface{}{},
+		"total":          3,
+		"type_facets": []map[string]interface{}{
+			{"label": "全部", "count": 3, "url": "/search?q=erp", "active": true},
+			{"label": "文章", "count": 3, "url": "/search?q=erp&type=article", "active": false},
+		},
+		"category_facets": []map[string]interface{}{
+			{"label": "行业资讯", "count": 2, "url": "/search?category_id=4&q=erp", "active": false},
+		},
+		"tag_facets": []map[string]interface{}{
+			{"label": "制造业", "count": 1, "url": "/search?q=erp&tag_id=9", "active": true},
+		},
+		"pagination": map[string]interface{}{
+			"current_page": 1, "total_pages": 1, "total_items": 3, "base_url": "/search", "query": "q=erp",
+		},
+	}
+	var buf bytes.Buffer
+	if err := tpl.ExecuteWriter(ctx, &buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"文章 (3)", "行业资讯", "#制造业", "/search?q=erp&amp;tag_id=9"} {
+		if !bytes.Contains(buf.Bytes(), []byte(want)) {
+			t.Errorf("rendered page lacks %q", want)
+		}
+	}
+}
+
+func TestPreviewPageIsMarked(t *testing.T) {
+	r, err := NewRenderer(filepath.Join("..", "..", "..", "templates"))
+	if err != nil {
+		t.Fatalf("NewRenderer: %v", err)
+	}
+	tpl, err := r.get("pages/projects/detail.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	render := func(preview bool) string {
+		ctx := pongo2.Context{
+			"company":        companyDTO(nil),
+			"friendly_links": []map[string]interface{}{},
+			"current_path":   "/projects/test",
+			"project":        map[string]interface{}{"id": "test", "name": "Draft", "tags": []map[string]interface{}{}},
+			"preview":        preview,
+		}
+		var buf bytes.Buffer
+		if err := tpl.ExecuteWriter(ctx, &buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+	if out := render(true); !strings.Contains(out, `content="noindex, nofollow"`) || !strings.Contains(out, "预览模式") {
+		t.Error("preview page lacks noindex or banner")
+	}
+	if out := render(false); strings.Contains(out, "noindex") || strings.Contains(out, "预览模式") {
+		t.Error("published page marked as preview")
+	}
+}
+
+func TestArticleSeriesNavigation(t *testing.T) {
+	r, err := NewRenderer(filepath.Join("..", "..", "..", "templates"))
+	if err != nil {
+		t.Fatalf("NewRenderer: %v", err)
+	}
+	tpl, err := r.get("pages/articles/detail.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := pongo2.Context{
+		"company":        companyDTO(nil),
+		"friendly_links": []map[string]interface{}{},
+		"current_path":   "/articles/part-2",
+		"article":        map[string]interface{}{"id": "test", "title": "Part 2", "tags": []map[string]interface{}{}},
+		"series": []map[string]interface{}{{
+			"title": "Go 入门", "kind": "series", "part": 2, "total": 3,
+			"prev": map[string]interface{}{"title": "Part 1", "url": "/articles/part-1"},
+			"next": map[string]interface{}{"title": "Part 3", "url": "/articles/part-3"},
+		}},
+	}
+	var buf bytes.Buffer
+	if err := tpl.ExecuteWriter(ctx, &buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"第 2 篇，共 3 篇", `href="/articles/part-1"`, `href="/articles/part-3"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("page lacks %q", want)
+		}
+	}
+}
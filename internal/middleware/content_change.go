@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// OnContentChange calls fn after a successful write request (any method but
+// GET, HEAD and OPTIONS) to a route below one of the given path prefixes,
+// e.g. to drop caches derived from that content.
+func OnContentChange(fn func(ctx context.Context), prefixes ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := next(c)
+			switch c.Request().Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				return err
+			}
+			if err != nil || c.Response().Status >= http.StatusBadRequest {
+				return err
+			}
+			path := c.Path()
+			for _, p := range prefixes {
+				if path == p || strings.HasPrefix(path, p+"/") {
+					fn(c.Request().Context())
+					break
+				}
+			}
+			return nil
+		}
+	}
+}
@@ -14,8 +14,10 @@ func CORS(cfg *kxlcfg.Config) echo.MiddlewareFunc {
 
 	return echomw.CORSWithConfig(echomw.CORSConfig{
 		AllowOrigins:     []string{allowOrigin},
-		AllowMethods:     []string{echo.GET, echo.POST, echo.PUT, echo.PATCH, echo.DELETE, echo.OPTIONS},
-		AllowHeaders:     []string{"Content-Type", "Authorization", "X-Requested-With"},
+		AllowMethods:     []string{echo.GET, echo.HEAD, echo.POST, echo.PUT, echo.PATCH, echo.DELETE, echo.OPTIONS},
+		AllowHeaders:     []string{"Content-Type", "Authorization", "X-Requested-With", "Tus-Resumable", "Upload-Length", "Upload-Offset", "Upload-Metadata"},
+		// Resumable (tus) clients read these from cross-origin responses.
+		ExposeHeaders:    []string{"Location", "Tus-Resumable", "Tus-Version", "Tus-Extension", "Tus-Max-Size", "Upload-Offset", "Upload-Length", "Upload-Expires", "X-Media-Id", "X-Media-Url"},
 		AllowCredentials: true,
 	})
 }
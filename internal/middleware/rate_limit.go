@@ -58,14 +58,13 @@ func (rl *RedisRateLimiter) Middleware() echo.MiddlewareFunc {
 	}
 }
 
-// RateLimit implements the same endpoint-aware policy as the PHP backend:
-// - Login endpoints: key by ip + identifier fragment.
-// - Upload endpoints: key by user/admin id (if available), otherwise by ip.
+// RateLimit implements the same endpoint-aware policy as the PHP backend for
+// login endpoints: key by ip + identifier fragment. Uploads are limited per
+// route by UploadRateLimit and ResumableUploadRateLimit, which run after
+// authentication so they can key by account.
 func RateLimit(client *redis.Client, cfg *kxlcfg.Config) echo.MiddlewareFunc {
 	windowLogin := 60
 	maxLogin := int64(20)
-	windowUpload := 60
-	maxUpload := int64(30)
 	if cfg != nil {
 		if cfg.Security.RateLimitLoginWindowSeconds > 0 {
 			windowLogin = cfg.Security.RateLimitLoginWindowSeconds
@@ -73,12 +72,6 @@ func RateLimit(client *redis.Client, cfg *kxlcfg.Config) echo.MiddlewareFunc {
 		if cfg.Security.RateLimitLoginMaxAttempts > 0 {
 			maxLogin = int64(cfg.Security.RateLimitLoginMaxAttempts)
 		}
-		if cfg.Security.RateLimitUploadWindowSeconds > 0 {
-			windowUpload = cfg.Security.RateLimitUploadWindowSeconds
-		}
-		if cfg.Security.RateLimitUploadMaxRequests > 0 {
-			maxUpload = int64(cfg.Security.RateLimitUploadMaxRequests)
-		}
 	}
 
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
@@ -107,36 +100,106 @@ func RateLimit(client *redis.Client, cfg *kxlcfg.Config) echo.MiddlewareFunc {
 				}
 			}
 
-			if method == http.MethodPost && (path == "/api/upload/image" || path == "/api/upload/video" || path == "/api/admin/upload/image" || path == "/api/admin/upload/video") {
-				kind := "image"
-				if strings.HasSuffix(path, "/video") {
-					kind = "video"
-				}
-				actor := "user"
-				if strings.HasPrefix(path, "/api/admin/") {
-					actor = "admin"
-				}
+			return next(c)
+		}
+	}
+}
 
-				idKey := "current_" + actor + "_id"
-				id, _ := c.Get(idKey).(string)
-				var key string
-				if id != "" {
-					key = fmt.Sprintf("rl:upload:%s:%s:%s", actor, id, kind)
-				} else {
-					ip := c.RealIP()
-					key = fmt.Sprintf("rl:upload:%s:%s:%s", actor, ip, kind)
-				}
+func uploadLimits(cfg *kxlcfg.Config) (windowSeconds int64, maxRequests int64) {
+	windowSeconds, maxRequests = 60, 30
+	if cfg != nil {
+		if cfg.Security.RateLimitUploadWindowSeconds > 0 {
+			windowSeconds = int64(cfg.Security.RateLimitUploadWindowSeconds)
+		}
+		if cfg.Security.RateLimitUploadMaxRequests > 0 {
+			maxRequests = int64(cfg.Security.RateLimitUploadMaxRequests)
+		}
+	}
+	return windowSeconds, maxRequests
+}
 
-				if err := enforceRateLimit(req.Context(), client, key, int64(windowUpload), maxUpload); err != nil {
+// UploadRateLimit charges every request to the caller's upload bucket for
+// kind. It must be placed after AuthAdmin/AuthUploader on the route.
+func UploadRateLimit(client *redis.Client, cfg *kxlcfg.Config, kind string) echo.MiddlewareFunc {
+	window, maxRequests := uploadLimits(cfg)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if client == nil {
+				return next(c)
+			}
+			if err := enforceRateLimit(c.Request().Context(), client, uploadRateLimitKey(c, kind), window, maxRequests); err != nil {
+				return err
+			}
+			return next(c)
+		}
+	}
+}
+
+// ResumableUploadRateLimit limits tus uploads: they count once, when they
+// finish, against the same bucket as multipart video uploads. Creating one is
+// refused while the bucket is full; individual chunks are never counted. It
+// must be placed after AuthAdmin on the route.
+func ResumableUploadRateLimit(client *redis.Client, cfg *kxlcfg.Config) echo.MiddlewareFunc {
+	window, maxRequests := uploadLimits(cfg)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if client == nil {
+				return next(c)
+			}
+			req := c.Request()
+			switch req.Method {
+			case http.MethodPost:
+				if err := checkRateLimit(req.Context(), client, uploadRateLimitKey(c, "video"), maxRequests); err != nil {
 					return err
 				}
+			case http.MethodPatch:
+				c.Set(uploadCounterContextKey, func(kind string) {
+					_ = enforceRateLimit(req.Context(), client, uploadRateLimitKey(c, kind), window, maxRequests)
+				})
 			}
-
 			return next(c)
 		}
 	}
 }
 
+const uploadCounterContextKey = "rate_limit_upload_counter"
+
+// CountFinishedUpload charges a completed resumable upload to the caller's
+// upload rate limit bucket. It is a no-op when rate limiting is disabled.
+func CountFinishedUpload(c echo.Context, kind string) {
+	if fn, ok := c.Get(uploadCounterContextKey).(func(string)); ok && fn != nil {
+		fn(kind)
+	}
+}
+
+// uploadRateLimitKey keys upload limits by the authenticated admin or user
+// id, falling back to the ip when neither is set.
+func uploadRateLimitKey(c echo.Context, kind string) string {
+	for _, actor := range []string{"admin", "user"} {
+		if id, _ := c.Get("current_" + actor + "_id").(string); id != "" {
+			return fmt.Sprintf("rl:upload:%s:%s:%s", actor, id, kind)
+		}
+	}
+	return fmt.Sprintf("rl:upload:ip:%s:%s", c.RealIP(), kind)
+}
+
+// checkRateLimit refuses the request when the bucket is already full without
+// consuming from it.
+func checkRateLimit(ctx context.Context, client *redis.Client, key string, maxRequests int64) error {
+	if maxRequests <= 0 {
+		return nil
+	}
+	n, err := client.Get(ctx, key).Int64()
+	if err != nil {
+		// Missing key or Redis error: fail open.
+		return nil
+	}
+	if n >= maxRequests {
+		return kxlerrors.New(kxlerrors.CodeTooManyRequests, "too many requests: rate limit exceeded", http.StatusTooManyRequests, nil)
+	}
+	return nil
+}
+
 func enforceRateLimit(ctx context.Context, client *redis.Client, key string, windowSeconds int64, maxRequests int64) error {
 	if windowSeconds <= 0 || maxRequests <= 0 {
 		return nil
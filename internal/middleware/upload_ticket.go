@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"errors"
+
+	"github.com/labstack/echo/v4"
+	kxlerrors "github.com/linkyfish/kxl_backend_go/internal/errors"
+	"github.com/linkyfish/kxl_backend_go/internal/model"
+	"github.com/linkyfish/kxl_backend_go/internal/service"
+	"github.com/linkyfish/kxl_backend_go/pkg/session"
+	"gorm.io/gorm"
+)
+
+// UploadTicketHeader carries a signed upload ticket; the "ticket" query
+// parameter is accepted as well for clients that cannot set headers.
+const UploadTicketHeader = "X-Upload-Ticket"
+
+// AuthUploader authenticates public upload endpoints: a valid upload ticket
+// stands in for the user session, otherwise AuthUser applies. The ticket's
+// kind restriction is exposed as "upload_ticket_kind".
+func AuthUploader(db *gorm.DB, sess *session.Manager, uploads *service.UploadService) echo.MiddlewareFunc {
+	authUser := AuthUser(db, sess)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		withSession := authUser(next)
+		return func(c echo.Context) error {
+			token := c.Request().Header.Get(UploadTicketHeader)
+			if token == "" {
+				token = c.QueryParam("ticket")
+			}
+			if token == "" {
+				return withSession(c)
+			}
+			if db == nil || uploads == nil {
+				return kxlerrors.Internal("auth middleware not configured")
+			}
+
+			ticket, err := uploads.VerifyTicket(token)
+			if err != nil {
+				return err
+			}
+			var user model.User
+			if err := db.Where("id = ?", ticket.UserID).First(&user).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return kxlerrors.Unauthorized()
+				}
+				return kxlerrors.Internal("db error")
+			}
+			if user.Status != 1 {
+				return kxlerrors.Unauthorized()
+			}
+
+			c.Set("current_user_id", user.ID)
+			c.Set("current_user", &user)
+			c.Set("upload_ticket_kind", ticket.Kind)
+			return next(c)
+		}
+	}
+}
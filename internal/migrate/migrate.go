@@ -0,0 +1,301 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	versionTable = "schema_migrations"
+	lockTable    = "schema_migrations_lock"
+)
+
+// Migration is a single versioned schema change loaded from the embedded FS.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status describes whether a known migration has been applied.
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+type Migrator struct {
+	db          *gorm.DB
+	migrations  []Migration
+	owner       string
+	LockTimeout time.Duration
+	LockStale   time.Duration
+}
+
+func New(db *gorm.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	host, _ := os.Hostname()
+	return &Migrator{
+		db:          db,
+		migrations:  migrations,
+		owner:       fmt.Sprintf("%s:%d", host, os.Getpid()),
+		LockTimeout: 2 * time.Minute,
+		LockStale:   15 * time.Minute,
+	}, nil
+}
+
+// Load parses `<version>_<name>.(up|down).sql` files into an ordered list.
+// Every version must provide an up file; down files are optional but recommended.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".sql") {
+			continue
+		}
+		version, name, direction, err := parseFilename(e.Name())
+		if err != nil {
+			return nil, err
+		}
+		raw, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, name)
+		}
+		switch direction {
+		case "up":
+			m.Up = string(raw)
+		case "down":
+			m.Down = string(raw)
+		}
+	}
+
+	out := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+func parseFilename(filename string) (int64, string, string, error) {
+	base := strings.TrimSuffix(path.Base(filename), ".sql")
+	direction := ""
+	switch {
+	case strings.HasSuffix(base, ".up"):
+		direction = "up"
+	case strings.HasSuffix(base, ".down"):
+		direction = "down"
+	default:
+		return 0, "", "", fmt.Errorf("migration %s: expected .up.sql or .down.sql suffix", filename)
+	}
+	base = strings.TrimSuffix(base, "."+direction)
+
+	rawVersion, name, ok := strings.Cut(base, "_")
+	if !ok || name == "" {
+		return 0, "", "", fmt.Errorf("migration %s: expected <version>_<name>", filename)
+	}
+	version, err := strconv.ParseInt(rawVersion, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, "", "", fmt.Errorf("migration %s: invalid version %q", filename, rawVersion)
+	}
+	return version, name, direction, nil
+}
+
+// Up applies every pending migration in version order and returns the ones applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func() error {
+		done, err := m.appliedVersions(ctx)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if _, ok := done[mig.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, mig); err != nil {
+				return err
+			}
+			applied = append(applied, mig)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the latest `steps` applied migrations.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps <= 0 {
+		steps = 1
+	}
+	var reverted []Migration
+	err := m.withLock(ctx, func() error {
+		done, err := m.appliedVersions(ctx)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			mig := m.migrations[i]
+			if _, ok := done[mig.Version]; !ok {
+				continue
+			}
+			if strings.TrimSpace(mig.Down) == "" {
+				return fmt.Errorf("migration %d_%s has no down script", mig.Version, mig.Name)
+			}
+			if err := m.revert(ctx, mig); err != nil {
+				return err
+			}
+			reverted = append(reverted, mig)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status lists every known migration together with its applied state.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.ensureTables(ctx); err != nil {
+		return nil, err
+	}
+	done, err := m.appliedVersions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		st := Status{Version: mig.Version, Name: mig.Name}
+		if at, ok := done[mig.Version]; ok {
+			at := at
+			st.Applied = true
+			st.AppliedAt = &at
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+func (m *Migrator) apply(ctx context.Context, mig Migration) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(mig.Up).Error; err != nil {
+			return fmt.Errorf("apply %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		return tx.Exec(
+			"INSERT INTO "+versionTable+" (version, name, applied_at) VALUES (?, ?, now())",
+			mig.Version, mig.Name,
+		).Error
+	})
+}
+
+func (m *Migrator) revert(ctx context.Context, mig Migration) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(mig.Down).Error; err != nil {
+			return fmt.Errorf("revert %d_%s: %w", mig.Version, mig.Name, err)
+		}
+		return tx.Exec("DELETE FROM "+versionTable+" WHERE version = ?", mig.Version).Error
+	})
+}
+
+func (m *Migrator) appliedVersions(ctx context.Context) (map[int64]time.Time, error) {
+	type row struct {
+		Version   int64     `gorm:"column:version"`
+		AppliedAt time.Time `gorm:"column:applied_at"`
+	}
+	var rows []row
+	if err := m.db.WithContext(ctx).Table(versionTable).Select("version, applied_at").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[int64]time.Time, len(rows))
+	for _, r := range rows {
+		out[r.Version] = r.AppliedAt
+	}
+	return out, nil
+}
+
+func (m *Migrator) ensureTables(ctx context.Context) error {
+	return m.db.WithContext(ctx).Exec(`
+CREATE TABLE IF NOT EXISTS ` + versionTable + ` (
+    version    BIGINT PRIMARY KEY,
+    name       VARCHAR(255) NOT NULL,
+    applied_at TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+CREATE TABLE IF NOT EXISTS ` + lockTable + ` (
+    id        INTEGER PRIMARY KEY CHECK (id = 1),
+    locked_by VARCHAR(255) NOT NULL,
+    locked_at TIMESTAMPTZ  NOT NULL DEFAULT now()
+);`).Error
+}
+
+// withLock serializes migrations across instances using a single-row lock table.
+// A lock older than LockStale is considered abandoned (e.g. a crashed deploy) and taken over.
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	deadline := time.Now().Add(m.LockTimeout)
+	for {
+		err := m.ensureTables(ctx)
+		if err == nil {
+			var ok bool
+			ok, err = m.tryLock(ctx)
+			if err == nil && ok {
+				break
+			}
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return fmt.Errorf("acquire migration lock: %w", err)
+			}
+			return fmt.Errorf("acquire migration lock: timed out after %s", m.LockTimeout)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+	defer m.unlock(context.Background())
+	return fn()
+}
+
+func (m *Migrator) tryLock(ctx context.Context) (bool, error) {
+	res := m.db.WithContext(ctx).Exec(
+		"INSERT INTO "+lockTable+" (id, locked_by, locked_at) VALUES (1, ?, now()) "+
+			"ON CONFLICT (id) DO UPDATE SET locked_by = EXCLUDED.locked_by, locked_at = EXCLUDED.locked_at "+
+			"WHERE "+lockTable+".locked_at < now() - make_interval(secs => ?)",
+		m.owner, int64(m.LockStale.Seconds()),
+	)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (m *Migrator) unlock(ctx context.Context) {
+	_ = m.db.WithContext(ctx).Exec("DELETE FROM "+lockTable+" WHERE id = 1 AND locked_by = ?", m.owner).Error
+}
@@ -0,0 +1,46 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/linkyfish/kxl_backend_go/migrations"
+)
+
+func TestLoad_EmbeddedMigrationsArePaired(t *testing.T) {
+	rows, err := Load(migrations.FS)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(rows) == 0 {
+		t.Fatalf("expected at least one embedded migration")
+	}
+	for i, m := range rows {
+		if m.Down == "" {
+			t.Errorf("migration %d_%s has no down script", m.Version, m.Name)
+		}
+		if i > 0 && rows[i-1].Version >= m.Version {
+			t.Errorf("migrations not strictly ordered at %d_%s", m.Version, m.Name)
+		}
+	}
+}
+
+func TestLoad_RejectsMalformedNames(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"missing direction": {"000001_init.sql": {Data: []byte("SELECT 1;")}},
+		"missing name":      {"000001.up.sql": {Data: []byte("SELECT 1;")}},
+		"bad version":       {"abc_init.up.sql": {Data: []byte("SELECT 1;")}},
+		"down only":         {"000001_init.down.sql": {Data: []byte("SELECT 1;")}},
+		"name conflict": {
+			"000001_init.up.sql":    {Data: []byte("SELECT 1;")},
+			"000001_other.down.sql": {Data: []byte("SELECT 1;")},
+		},
+	}
+	for name, fsys := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load(fsys); err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ type Article struct {
 	UUIDModel
 
 	Title       string     `gorm:"column:title" json:"title"`
+	Slug        string     `gorm:"column:slug" json:"slug"`
 	Summary     string     `gorm:"column:summary" json:"summary"`
 	Content     string     `gorm:"column:content" json:"content"`
 	CoverImage  *string    `gorm:"column:cover_image" json:"cover_image"`
@@ -13,7 +14,28 @@ type Article struct {
 	ViewCount   int        `gorm:"column:view_count" json:"view_count"`
 	Status      int16      `gorm:"column:status" json:"status"`
 	PublishedAt *time.Time `gorm:"column:published_at" json:"published_at"`
+	// PublishAt / UnpublishAt are pending schedule times, cleared once the
+	// scheduler has applied them.
+	PublishAt   *time.Time `gorm:"column:publish_at" json:"publish_at"`
+	UnpublishAt *time.Time `gorm:"column:unpublish_at" json:"unpublish_at"`
+
+	// ContentFormat is "html" or "markdown"; ContentHTML caches the
+	// rendering of Markdown content and is empty for HTML.
+	ContentFormat string `gorm:"column:content_format" json:"content_format"`
+	ContentHTML   string `gorm:"column:content_html" json:"-"`
+
+	// SummaryGenerated is set while Summary is an excerpt of the content
+	// rather than written by an editor.
+	SummaryGenerated bool `gorm:"column:summary_generated" json:"summary_generated"`
 }
 
 func (Article) TableName() string { return "articles" }
 
+// RenderedContent is the HTML to show for the article body.
+func (a Article) RenderedContent() string {
+	if a.ContentFormat == "markdown" {
+		return a.ContentHTML
+	}
+	return a.Content
+}
+
@@ -1,12 +1,17 @@
 package model
 
-import "gorm.io/datatypes"
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
 
 // CaseStudy maps to table `cases` (Go reserved word: case).
 type CaseStudy struct {
 	UUIDModel
 
 	ClientName        string         `gorm:"column:client_name" json:"client_name"`
+	Slug              string         `gorm:"column:slug" json:"slug"`
 	CoverImage        *string        `gorm:"column:cover_image" json:"cover_image"`
 	Summary           string         `gorm:"column:summary" json:"summary"`
 	Background        string         `gorm:"column:background" json:"background"`
@@ -17,6 +22,31 @@ type CaseStudy struct {
 	TestimonialTitle  *string        `gorm:"column:testimonial_title" json:"testimonial_title"`
 	CategoryID        *int           `gorm:"column:category_id" json:"category_id"`
 	Status            int16          `gorm:"column:status" json:"status"`
+	PublishedAt       *time.Time     `gorm:"column:published_at" json:"published_at"`
+	PublishAt         *time.Time     `gorm:"column:publish_at" json:"publish_at"`
+	UnpublishAt       *time.Time     `gorm:"column:unpublish_at" json:"unpublish_at"`
+
+	// ContentFormat ("html" or "markdown") applies to Background and
+	// Solution; the *HTML fields cache their rendering for Markdown.
+	ContentFormat  string `gorm:"column:content_format" json:"content_format"`
+	BackgroundHTML string `gorm:"column:background_html" json:"-"`
+	SolutionHTML   string `gorm:"column:solution_html" json:"-"`
 }
 
 func (CaseStudy) TableName() string { return "cases" }
+
+// RenderedBackground is the HTML to show for the background section.
+func (c CaseStudy) RenderedBackground() string {
+	if c.ContentFormat == "markdown" {
+		return c.BackgroundHTML
+	}
+	return c.Background
+}
+
+// RenderedSolution is the HTML to show for the solution section.
+func (c CaseStudy) RenderedSolution() string {
+	if c.ContentFormat == "markdown" {
+		return c.SolutionHTML
+	}
+	return c.Solution
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// ContentRevision is a snapshot of the editable fields of an article,
+// project or case, taken each time an admin saves it. Version counts up
+// from 1 per item; rows are never updated.
+type ContentRevision struct {
+	ID          int64          `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	ContentType string         `gorm:"column:content_type" json:"content_type"`
+	ContentID   string         `gorm:"type:uuid;column:content_id" json:"content_id"`
+	Version     int            `gorm:"column:version" json:"version"`
+	Data        datatypes.JSON `gorm:"type:jsonb;column:data" json:"data"`
+	AdminID     *string        `gorm:"type:uuid;column:admin_id" json:"admin_id"`
+	CreatedAt   time.Time      `gorm:"column:created_at" json:"created_at"`
+}
+
+func (ContentRevision) TableName() string { return "content_revisions" }
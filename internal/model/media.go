@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+const (
+	UploaderTypeUser  = "user"
+	UploaderTypeAdmin = "admin"
+)
+
+// Media is one uploaded original tracked by the media library. Path is the
+// storage key; Variants lists the keys of its derivatives. UploadCount is the
+// number of uploads deduplicated onto this file (by SHA256).
+type Media struct {
+	UUIDModel
+
+	Path          string         `gorm:"column:path" json:"path"`
+	Kind          string         `gorm:"column:kind" json:"kind"`
+	Mime          string         `gorm:"column:mime" json:"mime"`
+	Size          int64          `gorm:"column:size" json:"size"`
+	Width         *int           `gorm:"column:width" json:"width"`
+	Height        *int           `gorm:"column:height" json:"height"`
+	SHA256        string         `gorm:"column:sha256" json:"sha256"`
+	OriginalName  string         `gorm:"column:original_name" json:"original_name"`
+	UploaderType  string         `gorm:"column:uploader_type" json:"uploader_type"`
+	UploaderID    *string        `gorm:"type:uuid;column:uploader_id" json:"uploader_id"`
+	Variants      datatypes.JSON `gorm:"type:jsonb;column:variants" json:"variants"`
+	UploadCount   int            `gorm:"column:upload_count" json:"upload_count"`
+	RefCount      int            `gorm:"column:ref_count" json:"ref_count"`
+	LastScannedAt *time.Time     `gorm:"column:last_scanned_at" json:"last_scanned_at"`
+	OrphanedAt    *time.Time     `gorm:"column:orphaned_at" json:"orphaned_at"`
+}
+
+func (Media) TableName() string { return "media" }
@@ -1,14 +1,20 @@
 package model
 
+import "time"
+
 type Project struct {
 	UUIDModel
 
-	Name        string  `gorm:"column:name" json:"name"`
-	Description string  `gorm:"column:description" json:"description"`
-	CoverImage  *string `gorm:"column:cover_image" json:"cover_image"`
-	CategoryID  *int    `gorm:"column:category_id" json:"category_id"`
-	Status      int16   `gorm:"column:status" json:"status"`
-	SortOrder   int     `gorm:"column:sort_order" json:"sort_order"`
+	Name        string     `gorm:"column:name" json:"name"`
+	Slug        string     `gorm:"column:slug" json:"slug"`
+	Description string     `gorm:"column:description" json:"description"`
+	CoverImage  *string    `gorm:"column:cover_image" json:"cover_image"`
+	CategoryID  *int       `gorm:"column:category_id" json:"category_id"`
+	Status      int16      `gorm:"column:status" json:"status"`
+	SortOrder   int        `gorm:"column:sort_order" json:"sort_order"`
+	PublishedAt *time.Time `gorm:"column:published_at" json:"published_at"`
+	PublishAt   *time.Time `gorm:"column:publish_at" json:"publish_at"`
+	UnpublishAt *time.Time `gorm:"column:unpublish_at" json:"unpublish_at"`
 }
 
 func (Project) TableName() string { return "projects" }
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+const (
+	ResumableStatusUploading  = "uploading"
+	ResumableStatusFinalizing = "finalizing"
+	ResumableStatusCompleted  = "completed"
+	ResumableStatusFailed     = "failed"
+)
+
+// ResumableUpload tracks one tus upload. Parts holds the storage keys of the
+// received chunks in order.
+type ResumableUpload struct {
+	UUIDModel
+
+	Kind         string         `gorm:"column:kind" json:"kind"`
+	UploadLength int64          `gorm:"column:upload_length" json:"upload_length"`
+	UploadOffset int64          `gorm:"column:upload_offset" json:"upload_offset"`
+	Metadata     datatypes.JSON `gorm:"type:jsonb;column:metadata" json:"metadata"`
+	Parts        datatypes.JSON `gorm:"type:jsonb;column:parts" json:"parts"`
+	Status       string         `gorm:"column:status" json:"status"`
+	UploaderType string         `gorm:"column:uploader_type" json:"uploader_type"`
+	UploaderID   *string        `gorm:"type:uuid;column:uploader_id" json:"uploader_id"`
+	MediaID      *string        `gorm:"type:uuid;column:media_id" json:"media_id"`
+	URL          string         `gorm:"column:url" json:"url"`
+	ExpiresAt    time.Time      `gorm:"column:expires_at" json:"expires_at"`
+}
+
+func (ResumableUpload) TableName() string { return "resumable_uploads" }
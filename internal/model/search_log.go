@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// SearchQueryLog is one logged site search. Query is normalized (lowercase,
+// single spaces); IPHash is a salted hash, never the address itself.
+type SearchQueryLog struct {
+	ID          int64     `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	Query       string    `gorm:"column:query" json:"query"`
+	SearchType  string    `gorm:"column:search_type" json:"search_type"`
+	ResultCount int       `gorm:"column:result_count" json:"result_count"`
+	IPHash      string    `gorm:"column:ip_hash" json:"-"`
+	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (SearchQueryLog) TableName() string { return "search_query_logs" }
+
+// SearchClick records a visitor following a search result.
+type SearchClick struct {
+	ID         int64     `gorm:"primaryKey;autoIncrement;column:id" json:"id"`
+	Query      string    `gorm:"column:query" json:"query"`
+	ResultType string    `gorm:"column:result_type" json:"result_type"`
+	ResultID   string    `gorm:"column:result_id" json:"result_id"`
+	URL        string    `gorm:"column:url" json:"url"`
+	IPHash     string    `gorm:"column:ip_hash" json:"-"`
+	CreatedAt  time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (SearchClick) TableName() string { return "search_clicks" }
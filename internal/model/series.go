@@ -0,0 +1,27 @@
+package model
+
+// Series is an ordered group of articles, projects or cases. Kind "series"
+// is read in order (a multi-part tutorial); "collection" is a curated list.
+type Series struct {
+	UUIDModel
+
+	Kind        string  `gorm:"column:kind" json:"kind"`
+	Title       string  `gorm:"column:title" json:"title"`
+	Slug        string  `gorm:"column:slug" json:"slug"`
+	Description string  `gorm:"column:description" json:"description"`
+	CoverImage  *string `gorm:"column:cover_image" json:"cover_image"`
+	IsVisible   bool    `gorm:"column:is_visible" json:"is_visible"`
+	SortOrder   int     `gorm:"column:sort_order" json:"sort_order"`
+}
+
+func (Series) TableName() string { return "series" }
+
+// SeriesItem places one piece of content at Position (from 1) in a series.
+type SeriesItem struct {
+	SeriesID    string `gorm:"type:uuid;primaryKey;column:series_id" json:"series_id"`
+	ContentType string `gorm:"primaryKey;column:content_type" json:"content_type"`
+	ContentID   string `gorm:"type:uuid;primaryKey;column:content_id" json:"content_id"`
+	Position    int    `gorm:"column:position" json:"position"`
+}
+
+func (SeriesItem) TableName() string { return "series_items" }
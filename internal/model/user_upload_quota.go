@@ -0,0 +1,14 @@
+package model
+
+// UserUploadQuota tracks how much storage a user's uploads take. A nil
+// MaxBytes / MaxFiles means the configured default applies.
+type UserUploadQuota struct {
+	UserID    string `gorm:"type:uuid;primaryKey;column:user_id" json:"user_id"`
+	UsedBytes int64  `gorm:"column:used_bytes" json:"used_bytes"`
+	UsedFiles int64  `gorm:"column:used_files" json:"used_files"`
+	MaxBytes  *int64 `gorm:"column:max_bytes" json:"max_bytes"`
+	MaxFiles  *int64 `gorm:"column:max_files" json:"max_files"`
+	Timestamps
+}
+
+func (UserUploadQuota) TableName() string { return "user_upload_quotas" }
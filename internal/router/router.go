@@ -1,6 +1,8 @@
 package router
 
 import (
+	"time"
+
 	"github.com/go-redis/redis/v8"
 	kxlcfg "github.com/linkyfish/kxl_backend_go/internal/config"
 	"github.com/linkyfish/kxl_backend_go/internal/handler"
@@ -12,16 +14,22 @@ import (
 	"github.com/linkyfish/kxl_backend_go/internal/service"
 	kxlvalidator "github.com/linkyfish/kxl_backend_go/internal/validator"
 	"github.com/linkyfish/kxl_backend_go/pkg/session"
+	"github.com/linkyfish/kxl_backend_go/pkg/storage"
 	"github.com/labstack/echo/v4"
 	echomw "github.com/labstack/echo/v4/middleware"
 	"gorm.io/gorm"
 )
 
+// Deps are the shared dependencies built by cmd/api. A nil Storage falls back
+// to the local uploads directory; a nil SearchLog disables search analytics.
 type Deps struct {
-	Cfg    *kxlcfg.Config
-	DB     *gorm.DB
-	Redis  *redis.Client
-	Sess   *session.Manager
+	Cfg       *kxlcfg.Config
+	DB        *gorm.DB
+	Redis     *redis.Client
+	Sess      *session.Manager
+	Storage   storage.Storage
+	SearchLog *service.SearchLogService
+	Scheduler *service.PublishScheduler
 }
 
 // New creates an Echo instance with all API routes registered.
@@ -53,13 +61,20 @@ func New(deps Deps) *echo.Echo {
 	messageSvc := service.NewMessageService(deps.DB)
 	settingsSvc := service.NewSettingsService(deps.DB)
 	rbacSvc := service.NewRbacService(deps.DB, deps.Redis, deps.Cfg.Security.RbacCacheTTLSeconds)
-	uploadSvc := service.NewUploadService(deps.Cfg)
+	uploadSvc := service.NewUploadService(deps.Cfg, deps.DB, deps.Storage)
 	bannerSvc := service.NewBannerService(deps.DB)
 	testimonialSvc := service.NewTestimonialService(deps.DB)
 	solutionSvc := service.NewSolutionService(deps.DB)
 	partnerSvc := service.NewPartnerService(deps.DB)
 	friendlySvc := service.NewFriendlyLinkService(deps.DB)
-	searchSvc := service.NewSearchService(deps.DB)
+	searchSvc := service.NewSearchService(deps.Cfg, deps.DB, deps.Redis)
+	sanitizer := service.NewHTMLSanitizer(deps.Cfg.Sanitize)
+	revisionSvc := service.NewRevisionService(deps.DB, sanitizer)
+	previewSvc := service.NewPreviewService(deps.Cfg, deps.DB)
+	slugSvc := service.NewSlugService(deps.DB)
+	seriesSvc := service.NewSeriesService(deps.DB)
+	mediaSvc := service.NewMediaService(deps.DB, uploadSvc)
+	resumableSvc := service.NewResumableUploadService(deps.DB, uploadSvc, time.Duration(deps.Cfg.Uploads.ResumableExpireHours)*time.Hour)
 	systemConfigSvc := service.NewSystemConfigService(deps.DB)
 
 	// Health checks.
@@ -69,10 +84,16 @@ func New(deps Deps) *echo.Echo {
 
 	// Static assets and uploads.
 	e.Static("/static", "static")
-	if deps.Cfg != nil && deps.Cfg.Uploads.Dir != "" {
-		e.Static("/uploads", deps.Cfg.Uploads.Dir)
+	uploadHandler := &upload.UploadHandler{Uploads: uploadSvc}
+	kxlweb.SetImageVariantSource(uploadSvc)
+	kxlweb.SetHTMLSanitizer(sanitizer)
+	if local, ok := uploadSvc.Storage().(*storage.Local); ok {
+		// Resumable upload chunks share the directory but are not public.
+		e.GET("/uploads*", echo.StaticDirectoryHandler(echo.MustSubFS(e.Filesystem, local.Dir()), false), upload.RefusePrivate)
 	} else {
-		e.Static("/uploads", "uploads")
+		// Remote backends are proxied so "/uploads/..." URLs keep working when
+		// no CDN/public base URL is configured in front of the bucket.
+		e.GET("/uploads/*", uploadHandler.Serve)
 	}
 
 	// SSR website routes.
@@ -92,15 +113,15 @@ func New(deps Deps) *echo.Echo {
 	about := &kxlweb.AboutHandler{Settings: settingsSvc, Friendly: friendlySvc}
 	e.GET("/about", about.Index)
 
-	webArticles := &kxlweb.ArticleHandler{DB: deps.DB, Settings: settingsSvc, Friendly: friendlySvc, Articles: articleSvc}
+	webArticles := &kxlweb.ArticleHandler{DB: deps.DB, Settings: settingsSvc, Friendly: friendlySvc, Articles: articleSvc, Previews: previewSvc, Slugs: slugSvc, Series: seriesSvc}
 	e.GET("/articles", webArticles.List)
 	e.GET("/articles/:id", webArticles.Detail)
 
-	webProjects := &kxlweb.ProjectHandler{DB: deps.DB, Settings: settingsSvc, Friendly: friendlySvc, Projects: projectSvc}
+	webProjects := &kxlweb.ProjectHandler{DB: deps.DB, Settings: settingsSvc, Friendly: friendlySvc, Projects: projectSvc, Previews: previewSvc, Slugs: slugSvc}
 	e.GET("/projects", webProjects.List)
 	e.GET("/projects/:id", webProjects.Detail)
 
-	webCases := &kxlweb.CaseHandler{DB: deps.DB, Settings: settingsSvc, Friendly: friendlySvc, Cases: caseSvc, Projects: projectSvc}
+	webCases := &kxlweb.CaseHandler{DB: deps.DB, Settings: settingsSvc, Friendly: friendlySvc, Cases: caseSvc, Projects: projectSvc, Previews: previewSvc, Slugs: slugSvc}
 	e.GET("/cases", webCases.List)
 	e.GET("/cases/:id", webCases.Detail)
 
@@ -108,8 +129,9 @@ func New(deps Deps) *echo.Echo {
 	e.GET("/contact", contact.Index)
 	e.POST("/contact/submit", contact.Submit)
 
-	webSearch := &kxlweb.SearchHandler{Settings: settingsSvc, Friendly: friendlySvc, Search: searchSvc}
+	webSearch := &kxlweb.SearchHandler{Settings: settingsSvc, Friendly: friendlySvc, Search: searchSvc, Logs: deps.SearchLog}
 	e.GET("/search", webSearch.Index)
+	e.GET("/search/click", webSearch.Click)
 
 	webAuth := &kxlweb.AuthHandler{Settings: settingsSvc, Friendly: friendlySvc, Auth: authSvc, Sessions: deps.Sess}
 	e.GET("/login", webAuth.LoginPage)
@@ -135,20 +157,24 @@ func New(deps Deps) *echo.Echo {
 		userAuthed.POST("/users/change-password", userHandler.ChangePassword)
 
 		// Content endpoints.
-		articleHandler := &v1.ArticleHandler{DB: deps.DB, Articles: articleSvc}
+		articleHandler := &v1.ArticleHandler{DB: deps.DB, Articles: articleSvc, Slugs: slugSvc, Series: seriesSvc}
 		v1Group.GET("/articles", articleHandler.List)
 		v1Group.GET("/articles/:id", articleHandler.Detail)
 		v1Group.GET("/articles/:id/related", articleHandler.Related)
 		v1Group.GET("/articles/:id/navigation", articleHandler.Navigation)
 
-		projectHandler := &v1.ProjectHandler{DB: deps.DB, Projects: projectSvc}
+		projectHandler := &v1.ProjectHandler{DB: deps.DB, Projects: projectSvc, Slugs: slugSvc}
 		v1Group.GET("/projects", projectHandler.List)
 		v1Group.GET("/projects/:id", projectHandler.Detail)
 
-		caseHandler := &v1.CaseHandler{DB: deps.DB, Cases: caseSvc, Projects: projectSvc}
+		caseHandler := &v1.CaseHandler{DB: deps.DB, Cases: caseSvc, Projects: projectSvc, Slugs: slugSvc}
 		v1Group.GET("/cases", caseHandler.List)
 		v1Group.GET("/cases/:id", caseHandler.Detail)
 
+		seriesHandler := &v1.SeriesHandler{Series: seriesSvc}
+		v1Group.GET("/series", seriesHandler.List)
+		v1Group.GET("/series/:id", seriesHandler.Detail)
+
 		messageHandler := &v1.MessageHandler{Messages: messageSvc}
 		v1Group.POST("/messages", messageHandler.Submit)
 
@@ -172,15 +198,19 @@ func New(deps Deps) *echo.Echo {
 		friendlyHandler := &v1.FriendlyLinkHandler{FriendlyLinks: friendlySvc}
 		v1Group.GET("/friendly-links", friendlyHandler.List)
 
-		searchHandler := &v1.SearchHandler{SearchSvc: searchSvc}
+		searchHandler := &v1.SearchHandler{SearchSvc: searchSvc, Logs: deps.SearchLog}
 		v1Group.GET("/search", searchHandler.Search)
 		v1Group.GET("/search/suggestions", searchHandler.Suggestions)
 	}
 
-	// Upload API (shared path prefix like Rust/PHP backends).
-	uploadHandler := &upload.UploadHandler{Uploads: uploadSvc}
-	e.POST("/api/upload/image", uploadHandler.UploadImage)
-	e.POST("/api/upload/video", uploadHandler.UploadVideo)
+	// Upload API (shared path prefix like Rust/PHP backends). Uploads need a
+	// user session or a signed upload ticket and count against user quotas.
+	authUploader := kxlmw.AuthUploader(deps.DB, deps.Sess, uploadSvc)
+	authUser := kxlmw.AuthUser(deps.DB, deps.Sess)
+	e.POST("/api/upload/image", uploadHandler.UploadImage, authUploader, kxlmw.UploadRateLimit(deps.Redis, deps.Cfg, "image"))
+	e.POST("/api/upload/video", uploadHandler.UploadVideo, authUploader, kxlmw.UploadRateLimit(deps.Redis, deps.Cfg, "video"))
+	e.POST("/api/upload/tickets", uploadHandler.IssueTicket, authUser)
+	e.GET("/api/upload/quota", uploadHandler.Quota, authUser)
 
 	// Admin API (/api/admin/*).
 	adminGroup := e.Group("/api/admin")
@@ -188,21 +218,28 @@ func New(deps Deps) *echo.Echo {
 		adminAuthHandler := &admin.AuthHandler{Auth: authSvc, RBAC: rbacSvc, Sessions: deps.Sess}
 		adminGroup.POST("/auth/login", adminAuthHandler.Login)
 
-		adminAuthed := adminGroup.Group("", kxlmw.AuthAdmin(deps.DB, deps.Sess, rbacSvc))
+		// Writes to searchable content refresh the search suggestions.
+		adminAuthed := adminGroup.Group("",
+			kxlmw.AuthAdmin(deps.DB, deps.Sess, rbacSvc),
+			kxlmw.OnContentChange(searchSvc.InvalidateSuggestions,
+				"/api/admin/articles", "/api/admin/projects", "/api/admin/cases", "/api/admin/tags"),
+		)
 		adminAuthed.POST("/auth/logout", adminAuthHandler.Logout)
 		adminAuthed.GET("/auth/me", adminAuthHandler.Me)
 
-		userHandler := &admin.UserHandler{Users: userSvc}
+		userHandler := &admin.UserHandler{Users: userSvc, Uploads: uploadSvc}
 		adminAuthed.GET("/users", userHandler.ListUsers)
 		adminAuthed.GET("/users/:id", userHandler.DetailUser)
 		adminAuthed.PATCH("/users/:id/status", userHandler.UpdateUserStatus)
+		adminAuthed.GET("/users/:id/upload-quota", userHandler.UploadQuota)
+		adminAuthed.PUT("/users/:id/upload-quota", userHandler.UpdateUploadQuota)
 
 		adminAuthed.GET("/admins", userHandler.ListAdmins)
 		adminAuthed.POST("/admins", userHandler.CreateAdmin)
 		adminAuthed.PUT("/admins/:id", userHandler.UpdateAdmin)
 		adminAuthed.DELETE("/admins/:id", userHandler.DeleteAdmin)
 
-		articleAdminHandler := &admin.ArticleHandler{DB: deps.DB, Articles: articleSvc}
+		articleAdminHandler := &admin.ArticleHandler{DB: deps.DB, Articles: articleSvc, Revisions: revisionSvc, Slugs: slugSvc, Sanitizer: sanitizer}
 		adminAuthed.GET("/articles", articleAdminHandler.List)
 		adminAuthed.GET("/articles/:id", articleAdminHandler.Detail)
 		adminAuthed.POST("/articles", articleAdminHandler.Create)
@@ -210,8 +247,17 @@ func New(deps Deps) *echo.Echo {
 		adminAuthed.DELETE("/articles/:id", articleAdminHandler.Delete)
 		adminAuthed.PATCH("/articles/:id/status", articleAdminHandler.UpdateStatus)
 		adminAuthed.PUT("/articles/:id/tags", articleAdminHandler.SetTags)
-
-		projectHandler := &admin.ProjectHandler{DB: deps.DB, Projects: projectSvc}
+		articleRevisions := &admin.RevisionHandler{Revisions: revisionSvc, ContentType: service.ContentArticle, Resource: "articles"}
+		adminAuthed.GET("/articles/:id/revisions", articleRevisions.List)
+		adminAuthed.GET("/articles/:id/revisions/diff", articleRevisions.Diff)
+		adminAuthed.GET("/articles/:id/revisions/:version", articleRevisions.Detail)
+		adminAuthed.POST("/articles/:id/revisions/:version/restore", articleRevisions.Restore)
+		articleSchedule := &admin.PublishScheduleHandler{Scheduler: deps.Scheduler, ContentType: service.ContentArticle, Resource: "articles"}
+		adminAuthed.PUT("/articles/:id/schedule", articleSchedule.Update)
+		articlePreview := &admin.PreviewLinkHandler{Previews: previewSvc, ContentType: service.ContentArticle, Resource: "articles"}
+		adminAuthed.POST("/articles/:id/preview-link", articlePreview.Create)
+
+		projectHandler := &admin.ProjectHandler{DB: deps.DB, Projects: projectSvc, Revisions: revisionSvc, Slugs: slugSvc}
 		adminAuthed.GET("/projects", projectHandler.List)
 		adminAuthed.POST("/projects", projectHandler.Create)
 		adminAuthed.PUT("/projects/:id", projectHandler.Update)
@@ -230,8 +276,17 @@ func New(deps Deps) *echo.Echo {
 		adminAuthed.PUT("/projects/:id/versions/:version_id", projectHandler.UpdateVersion)
 		adminAuthed.DELETE("/projects/:id/versions/:version_id", projectHandler.DeleteVersion)
 		adminAuthed.PUT("/projects/:id/tags", projectHandler.SetTags)
-
-		caseAdminHandler := &admin.CaseHandler{DB: deps.DB, Cases: caseSvc, Projects: projectSvc}
+		projectRevisions := &admin.RevisionHandler{Revisions: revisionSvc, ContentType: service.ContentProject, Resource: "projects"}
+		adminAuthed.GET("/projects/:id/revisions", projectRevisions.List)
+		adminAuthed.GET("/projects/:id/revisions/diff", projectRevisions.Diff)
+		adminAuthed.GET("/projects/:id/revisions/:version", projectRevisions.Detail)
+		adminAuthed.POST("/projects/:id/revisions/:version/restore", projectRevisions.Restore)
+		projectSchedule := &admin.PublishScheduleHandler{Scheduler: deps.Scheduler, ContentType: service.ContentProject, Resource: "projects"}
+		adminAuthed.PUT("/projects/:id/schedule", projectSchedule.Update)
+		projectPreview := &admin.PreviewLinkHandler{Previews: previewSvc, ContentType: service.ContentProject, Resource: "projects"}
+		adminAuthed.POST("/projects/:id/preview-link", projectPreview.Create)
+
+		caseAdminHandler := &admin.CaseHandler{DB: deps.DB, Cases: caseSvc, Projects: projectSvc, Revisions: revisionSvc, Slugs: slugSvc, Sanitizer: sanitizer}
 		adminAuthed.GET("/cases", caseAdminHandler.List)
 		adminAuthed.GET("/cases/:id", caseAdminHandler.Detail)
 		adminAuthed.POST("/cases", caseAdminHandler.Create)
@@ -239,6 +294,23 @@ func New(deps Deps) *echo.Echo {
 		adminAuthed.DELETE("/cases/:id", caseAdminHandler.Delete)
 		adminAuthed.PATCH("/cases/:id/status", caseAdminHandler.UpdateStatus)
 		adminAuthed.PUT("/cases/:id/projects", caseAdminHandler.SetProjects)
+		caseRevisions := &admin.RevisionHandler{Revisions: revisionSvc, ContentType: service.ContentCase, Resource: "cases"}
+		adminAuthed.GET("/cases/:id/revisions", caseRevisions.List)
+		adminAuthed.GET("/cases/:id/revisions/diff", caseRevisions.Diff)
+		adminAuthed.GET("/cases/:id/revisions/:version", caseRevisions.Detail)
+		adminAuthed.POST("/cases/:id/revisions/:version/restore", caseRevisions.Restore)
+		caseSchedule := &admin.PublishScheduleHandler{Scheduler: deps.Scheduler, ContentType: service.ContentCase, Resource: "cases"}
+		adminAuthed.PUT("/cases/:id/schedule", caseSchedule.Update)
+		casePreview := &admin.PreviewLinkHandler{Previews: previewSvc, ContentType: service.ContentCase, Resource: "cases"}
+		adminAuthed.POST("/cases/:id/preview-link", casePreview.Create)
+
+		seriesAdminHandler := &admin.SeriesHandler{Series: seriesSvc}
+		adminAuthed.GET("/series", seriesAdminHandler.List)
+		adminAuthed.GET("/series/:id", seriesAdminHandler.Detail)
+		adminAuthed.POST("/series", seriesAdminHandler.Create)
+		adminAuthed.PUT("/series/:id", seriesAdminHandler.Update)
+		adminAuthed.DELETE("/series/:id", seriesAdminHandler.Delete)
+		adminAuthed.PUT("/series/:id/items", seriesAdminHandler.SetItems)
 
 		messageHandler := &admin.MessageHandler{Messages: messageSvc}
 		adminAuthed.GET("/messages", messageHandler.List)
@@ -279,11 +351,35 @@ func New(deps Deps) *echo.Echo {
 		adminAuthed.PUT("/rbac/roles/:code/permissions", rbacHandler.SetRolePermissions)
 
 		adminUploadHandler := &admin.UploadHandler{Uploads: uploadSvc}
-		adminAuthed.POST("/upload/image", adminUploadHandler.UploadImage)
-		adminAuthed.POST("/upload/video", adminUploadHandler.UploadVideo)
+		adminAuthed.POST("/upload/image", adminUploadHandler.UploadImage, kxlmw.UploadRateLimit(deps.Redis, deps.Cfg, "image"))
+		adminAuthed.POST("/upload/video", adminUploadHandler.UploadVideo, kxlmw.UploadRateLimit(deps.Redis, deps.Cfg, "video"))
 		adminAuthed.DELETE("/upload/*", adminUploadHandler.DeleteFile)
 
-		bannerAdminHandler := &admin.BannerHandler{Banners: bannerSvc}
+		resumableHandler := &admin.ResumableUploadHandler{Uploads: resumableSvc}
+		adminGroup.OPTIONS("/upload/tus", resumableHandler.Options)
+		adminGroup.OPTIONS("/upload/tus/:id", resumableHandler.Options)
+		tusRateLimit := kxlmw.ResumableUploadRateLimit(deps.Redis, deps.Cfg)
+		adminAuthed.POST("/upload/tus", resumableHandler.Create, tusRateLimit)
+		adminAuthed.HEAD("/upload/tus/:id", resumableHandler.Head)
+		adminAuthed.PATCH("/upload/tus/:id", resumableHandler.Patch, tusRateLimit)
+		adminAuthed.DELETE("/upload/tus/:id", resumableHandler.Delete)
+
+		searchAnalyticsHandler := &admin.SearchAnalyticsHandler{Logs: deps.SearchLog}
+		adminAuthed.GET("/search/top-queries", searchAnalyticsHandler.TopQueries)
+		adminAuthed.GET("/search/zero-results", searchAnalyticsHandler.ZeroResults)
+		adminAuthed.GET("/search/click-through", searchAnalyticsHandler.ClickThrough)
+
+		mediaHandler := &admin.MediaHandler{
+			Media:       mediaSvc,
+			OrphanGrace: time.Duration(deps.Cfg.Uploads.OrphanGraceHours) * time.Hour,
+		}
+		adminAuthed.GET("/media", mediaHandler.List)
+		adminAuthed.POST("/media/scan", mediaHandler.Scan)
+		adminAuthed.POST("/media/gc", mediaHandler.GC)
+		adminAuthed.GET("/media/:id", mediaHandler.Detail)
+		adminAuthed.DELETE("/media/:id", mediaHandler.Delete)
+
+		bannerAdminHandler := &admin.BannerHandler{Banners: bannerSvc, Sanitizer: sanitizer}
 		adminAuthed.GET("/banners", bannerAdminHandler.List)
 		adminAuthed.GET("/banners/:id", bannerAdminHandler.Detail)
 		adminAuthed.POST("/banners", bannerAdminHandler.Create)
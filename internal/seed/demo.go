@@ -0,0 +1,161 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/linkyfish/kxl_backend_go/internal/model"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// seedDemo loads a small set of published content for local development.
+// It does nothing when any project, article or case already exists.
+func seedDemo(ctx context.Context, db *gorm.DB) (bool, error) {
+	var existing int64
+	for _, m := range []interface{}{&model.Project{}, &model.Article{}, &model.CaseStudy{}} {
+		var n int64
+		if err := db.WithContext(ctx).Model(m).Count(&n).Error; err != nil {
+			return false, fmt.Errorf("seed demo: %w", err)
+		}
+		existing += n
+	}
+	if existing > 0 {
+		return false, nil
+	}
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+		strPtr := func(s string) *string { return &s }
+
+		info := &model.CompanyInfo{
+			Name:         "开心乐科技",
+			Description:  "专注企业数字化转型的软件服务商。",
+			Phone:        "400-000-0000",
+			Email:        "hello@example.com",
+			Address:      "上海市浦东新区示例路 1 号",
+			WorkingHours: "周一至周五 9:00-18:00",
+			HeroTitle:    "让软件为业务增长提速",
+			HeroSubtitle: "从咨询、设计到交付与运维的一站式服务",
+			StatsYears:   strPtr("10+"),
+			StatsClients: strPtr("200+"),
+		}
+		if err := tx.Create(info).Error; err != nil {
+			return err
+		}
+
+		projectCat := &model.Category{Name: "企业应用", Type: "project", SortOrder: 1}
+		articleCat := &model.Category{Name: "公司新闻", Type: "article", SortOrder: 1}
+		caseCat := &model.Category{Name: "制造业", Type: "case", SortOrder: 1}
+		for _, c := range []*model.Category{projectCat, articleCat, caseCat} {
+			if err := tx.Create(c).Error; err != nil {
+				return err
+			}
+		}
+
+		projectTag := &model.Tag{Name: "ERP", Type: "project"}
+		articleTag := &model.Tag{Name: "行业动态", Type: "article"}
+		for _, t := range []*model.Tag{projectTag, articleTag} {
+			if err := tx.Create(t).Error; err != nil {
+				return err
+			}
+		}
+
+		project := &model.Project{
+			Name:        "云端 ERP 系统",
+			Slug:        "cloud-erp",
+			Description: "覆盖采购、库存、生产与财务的一体化 ERP 平台。",
+			CategoryID:  &projectCat.ID,
+			Status:      1,
+			PublishedAt: &now,
+			SortOrder:   1,
+		}
+		if err := tx.Create(project).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&model.ProjectTag{ProjectID: project.ID, TagID: projectTag.ID}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&model.ProjectFeature{ProjectID: project.ID, Name: "多组织架构", Description: "支持集团化多公司核算。", SortOrder: 1}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&model.ProjectVersion{ProjectID: project.ID, Version: "1.0.0", ReleaseDate: now, Changelog: "首个正式版本。"}).Error; err != nil {
+			return err
+		}
+
+		article := &model.Article{
+			Title:         "开心乐科技发布云端 ERP 1.0",
+			Slug:          "cloud-erp-1-0-released",
+			Summary:       "新一代云端 ERP 正式上线，帮助制造企业打通业务数据。",
+			Content:       "<p>新一代云端 ERP 正式上线。</p><h2>主要特性</h2><p>多组织架构、移动审批与实时报表。</p>",
+			ContentFormat: "html",
+			CategoryID:    &articleCat.ID,
+			Status:        1,
+			PublishedAt:   &now,
+		}
+		if err := tx.Create(article).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&model.ArticleTag{ArticleID: article.ID, TagID: articleTag.ID}).Error; err != nil {
+			return err
+		}
+
+		cs := &model.CaseStudy{
+			ClientName:    "示例制造集团",
+			Slug:          "demo-manufacturing-group",
+			Summary:       "上线三个月内库存周转率提升 30%。",
+			Background:    "<p>多工厂数据分散，月结周期长。</p>",
+			Solution:      "<p>部署云端 ERP，统一主数据与财务核算。</p>",
+			ContentFormat: "html",
+			Results:       datatypes.JSON(`[{"label":"库存周转率","value":"+30%"}]`),
+			CategoryID:    &caseCat.ID,
+			Status:        1,
+			PublishedAt:   &now,
+		}
+		if err := tx.Create(cs).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&model.CaseProject{CaseID: cs.ID, ProjectID: project.ID}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(&model.Banner{
+			Title:     "数字化转型，从这里开始",
+			Subtitle:  strPtr("一站式企业软件服务"),
+			Link:      strPtr("/projects"),
+			LinkText:  "了解产品",
+			SortOrder: 1,
+			IsVisible: true,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&model.Solution{
+			Name:        "智能制造",
+			Description: "打通设备、生产与供应链数据。",
+			Link:        "/cases",
+			SortOrder:   1,
+			IsVisible:   true,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&model.Testimonial{
+			Name:      "王经理",
+			Company:   strPtr("示例制造集团"),
+			Content:   "项目交付准时，系统稳定可靠。",
+			Rating:    5,
+			SortOrder: 1,
+			IsVisible: true,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&model.Milestone{Year: now.Year(), Content: "云端 ERP 1.0 发布", SortOrder: 1}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&model.TeamMember{Name: "张三", Title: "技术总监", Bio: "十年企业软件研发经验。", SortOrder: 1}).Error
+	})
+	if err != nil {
+		return false, fmt.Errorf("seed demo: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,186 @@
+// Package seed bootstraps a fresh database: permission catalog, system roles,
+// the first super_admin account and (optionally) demo content.
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	kxlerrors "github.com/linkyfish/kxl_backend_go/internal/errors"
+	"github.com/linkyfish/kxl_backend_go/internal/model"
+	"github.com/linkyfish/kxl_backend_go/internal/service"
+	"github.com/linkyfish/kxl_backend_go/internal/util"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Options struct {
+	AdminUsername string
+	// AdminPassword is generated when empty and reported back in Result.
+	AdminPassword string
+	Demo          bool
+	// Rbac, when set, has its cached admin role permissions dropped after
+	// grants are added.
+	Rbac *service.RbacService
+}
+
+type Result struct {
+	Permissions       int
+	RolesCreated      []string
+	GrantsAdded       int
+	AdminUsername     string
+	AdminCreated      bool
+	GeneratedPassword string
+	DemoLoaded        bool
+}
+
+// Run is idempotent: existing roles and admin accounts are left untouched, permission
+// names/descriptions are refreshed from service.PermissionCatalog, and the admin role
+// is granted the default permissions that are new to the catalog. Grants an operator
+// revoked are not restored.
+func Run(ctx context.Context, db *gorm.DB, opts Options) (*Result, error) {
+	if db == nil {
+		return nil, fmt.Errorf("db not configured")
+	}
+	if opts.AdminUsername == "" {
+		opts.AdminUsername = "admin"
+	}
+	res := &Result{AdminUsername: opts.AdminUsername}
+
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		added, err := seedPermissions(tx)
+		if err != nil {
+			return err
+		}
+		res.Permissions = len(service.PermissionCatalog)
+
+		created, err := seedRoles(tx)
+		if err != nil {
+			return err
+		}
+		res.RolesCreated = created
+
+		// A new admin role gets every default; an existing one only the
+		// defaults this run added to the catalog.
+		for _, code := range created {
+			if code == "admin" {
+				added = nil
+			}
+		}
+		granted, err := seedAdminGrants(tx, added)
+		if err != nil {
+			return err
+		}
+		res.GrantsAdded = granted
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.GrantsAdded > 0 {
+		opts.Rbac.InvalidateRolePermissions(ctx, "admin")
+	}
+
+	password := opts.AdminPassword
+	if password == "" {
+		generated, err := util.RandomString(20)
+		if err != nil {
+			return nil, err
+		}
+		password = generated
+	}
+	users := service.NewUserService(db)
+	if _, err := users.CreateAdmin(ctx, opts.AdminUsername, password, "super_admin", 1); err != nil {
+		var be *kxlerrors.BusinessError
+		if !errors.As(err, &be) || be.Code != kxlerrors.CodeConflict {
+			return nil, err
+		}
+	} else {
+		res.AdminCreated = true
+		if opts.AdminPassword == "" {
+			res.GeneratedPassword = password
+		}
+	}
+
+	if opts.Demo {
+		loaded, err := seedDemo(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+		res.DemoLoaded = loaded
+	}
+	return res, nil
+}
+
+// seedPermissions upserts the catalog and returns the codes that were not in
+// the table before.
+func seedPermissions(tx *gorm.DB) (map[string]bool, error) {
+	var existing []string
+	if err := tx.Model(&model.AdminPermission{}).Pluck("code", &existing).Error; err != nil {
+		return nil, fmt.Errorf("seed permissions: %w", err)
+	}
+	known := make(map[string]bool, len(existing))
+	for _, code := range existing {
+		known[code] = true
+	}
+
+	added := map[string]bool{}
+	rows := make([]model.AdminPermission, 0, len(service.PermissionCatalog))
+	for _, p := range service.PermissionCatalog {
+		p.IsSystem = true
+		rows = append(rows, p)
+		if !known[p.Code] {
+			added[p.Code] = true
+		}
+	}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "code"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "group_name", "description", "is_system", "updated_at"}),
+	}).Create(&rows).Error; err != nil {
+		return nil, fmt.Errorf("seed permissions: %w", err)
+	}
+	return added, nil
+}
+
+func seedRoles(tx *gorm.DB) ([]string, error) {
+	created := []string{}
+	for _, r := range service.SystemRoles {
+		var count int64
+		if err := tx.Model(&model.AdminRole{}).Where("code = ?", r.Code).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("seed roles: %w", err)
+		}
+		if count > 0 {
+			continue
+		}
+		r.IsSystem = true
+		if err := tx.Create(&r).Error; err != nil {
+			return nil, fmt.Errorf("seed roles: %w", err)
+		}
+		created = append(created, r.Code)
+	}
+	return created, nil
+}
+
+// seedAdminGrants grants the admin role the catalog defaults in only, or every
+// default when only is nil (the role was just created). Limiting an existing
+// role to codes new to the catalog lets permissions added in later releases
+// reach existing installs without undoing revocations made through the API.
+// super_admin is implicit "*"; only the regular admin role needs explicit grants.
+func seedAdminGrants(tx *gorm.DB, only map[string]bool) (int, error) {
+	codes := service.DefaultAdminPermissions()
+	rows := make([]model.AdminRolePermission, 0, len(codes))
+	for _, code := range codes {
+		if only == nil || only[code] {
+			rows = append(rows, model.AdminRolePermission{RoleCode: "admin", PermissionCode: code})
+		}
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows)
+	if result.Error != nil {
+		return 0, fmt.Errorf("seed role permissions: %w", result.Error)
+	}
+	return int(result.RowsAffected), nil
+}
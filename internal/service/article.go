@@ -22,7 +22,7 @@ func (s *ArticleService) ListPublic(ctx context.Context, page, pageSize int64, c
 	if s == nil || s.db == nil {
 		return nil, 0, kxlerrors.Internal("db not configured")
 	}
-	q := s.db.WithContext(ctx).Model(&model.Article{}).Where("status = ?", 1)
+	q := s.db.WithContext(ctx).Model(&model.Article{}).Scopes(Published)
 	if categoryID != nil {
 		q = q.Where("category_id = ?", *categoryID)
 	}
@@ -46,6 +46,18 @@ func (s *ArticleService) ListPublic(ctx context.Context, page, pageSize int64, c
 }
 
 func (s *ArticleService) GetPublic(ctx context.Context, id string) (*model.Article, error) {
+	a, err := s.GetPreview(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !isPublished(a.Status, a.PublishedAt) {
+		return nil, kxlerrors.NotFound("not found: article not found")
+	}
+	return a, nil
+}
+
+// GetPreview returns the article whatever its status, for signed preview links.
+func (s *ArticleService) GetPreview(ctx context.Context, id string) (*model.Article, error) {
 	if s == nil || s.db == nil {
 		return nil, kxlerrors.Internal("db not configured")
 	}
@@ -56,9 +68,6 @@ func (s *ArticleService) GetPublic(ctx context.Context, id string) (*model.Artic
 		}
 		return nil, kxlerrors.Internal("db error")
 	}
-	if a.Status != 1 {
-		return nil, kxlerrors.NotFound("not found: article not found")
-	}
 	return &a, nil
 }
 
@@ -79,7 +88,7 @@ func (s *ArticleService) RelatedPublic(ctx context.Context, id string) ([]model.
 	}
 
 	q := s.db.WithContext(ctx).Model(&model.Article{}).
-		Where("status = ?", 1).
+		Scopes(Published).
 		Where("id <> ?", a.ID)
 	if a.CategoryID != nil {
 		q = q.Where("category_id = ?", *a.CategoryID)
@@ -109,7 +118,8 @@ func (s *ArticleService) NavigationPublic(ctx context.Context, id string) (*stri
 		err := s.db.WithContext(ctx).
 			Table("articles").
 			Select("id").
-			Where("status = ? AND published_at < ?", 1, publishedAt).
+			Scopes(Published).
+			Where("published_at < ?", publishedAt).
 			Order("published_at desc").
 			Limit(1).
 			Take(&row).Error
@@ -126,7 +136,8 @@ func (s *ArticleService) NavigationPublic(ctx context.Context, id string) (*stri
 		err := s.db.WithContext(ctx).
 			Table("articles").
 			Select("id").
-			Where("status = ? AND published_at > ?", 1, publishedAt).
+			Scopes(Published).
+			Where("published_at > ?", publishedAt).
 			Order("published_at asc").
 			Limit(1).
 			Take(&row).Error
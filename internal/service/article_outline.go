@@ -0,0 +1,244 @@
+package service
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/linkyfish/kxl_backend_go/internal/model"
+	"github.com/linkyfish/kxl_backend_go/internal/util"
+	"github.com/yuin/goldmark/ast"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Reading speeds used for ReadingMinutes: CJK text is read per character,
+// everything else per word.
+const (
+	cjkCharsPerMinute = 400
+	wordsPerMinute    = 200
+)
+
+// ExcerptMaxRunes is the length of summaries generated from article content.
+const ExcerptMaxRunes = 120
+
+// TOCEntry is one heading of an article.
+type TOCEntry struct {
+	Level int    `json:"level"`
+	ID    string `json:"id"`
+	Text  string `json:"text"`
+}
+
+// ArticleOutline is what the detail pages show around an article body.
+// HTML is the body with an id on every heading, so TOC links resolve.
+// WordCount counts CJK characters and other words alike; CharCount is every
+// non-space character.
+type ArticleOutline struct {
+	HTML           string     `json:"-"`
+	TOC            []TOCEntry `json:"toc"`
+	WordCount      int        `json:"word_count"`
+	CharCount      int        `json:"char_count"`
+	ReadingMinutes int        `json:"reading_minutes"`
+}
+
+// OutlineArticle builds the outline of the rendered content of a.
+func OutlineArticle(a *model.Article) *ArticleOutline {
+	return OutlineHTML(a.RenderedContent())
+}
+
+// OutlineHTML collects the h1–h4 headings of body into a table of contents,
+// giving headings without an id one derived from their text, and measures
+// the text. Unparseable input is returned as is with an empty TOC.
+func OutlineHTML(body string) *ArticleOutline {
+	out := &ArticleOutline{HTML: body, TOC: []TOCEntry{}}
+	nodes, err := html.ParseFragment(strings.NewReader(body), &html.Node{Type: html.ElementNode, DataAtom: atom.Body, Data: "body"})
+	if err != nil {
+		out.ReadingMinutes = 1
+		return out
+	}
+
+	ids := newHeadingIDs()
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if level := headingLevel(n); level > 0 {
+			title := strings.Join(strings.Fields(nodeText(n, false)), " ")
+			id := attr(n, "id")
+			if id == "" {
+				id = ids.generate(title)
+				n.Attr = append(n.Attr, html.Attribute{Key: "id", Val: id})
+			} else {
+				ids.put(id)
+			}
+			if level <= 4 && title != "" {
+				out.TOC = append(out.TOC, TOCEntry{Level: level, ID: id, Text: title})
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	var buf, text strings.Builder
+	for _, n := range nodes {
+		walk(n)
+		text.WriteString(nodeText(n, false))
+		if err := html.Render(&buf, n); err != nil {
+			buf.Reset()
+			buf.WriteString(body)
+			break
+		}
+	}
+	out.HTML = buf.String()
+
+	cjk, words, chars := countText(text.String())
+	out.WordCount, out.CharCount = cjk+words, chars
+	minutes := float64(cjk)/cjkCharsPerMinute + float64(words)/wordsPerMinute
+	out.ReadingMinutes = int(math.Max(1, math.Ceil(minutes)))
+	return out
+}
+
+// Excerpt returns the first ExcerptMaxRunes characters of the text of body,
+// cut after the last sentence end that fits when there is one, with "…"
+// appended when text was dropped.
+func Excerpt(body string) string {
+	nodes, err := html.ParseFragment(strings.NewReader(body), &html.Node{Type: html.ElementNode, DataAtom: atom.Body, Data: "body"})
+	if err != nil {
+		return ""
+	}
+	var parts []string
+	for _, n := range nodes {
+		parts = append(parts, nodeText(n, true))
+	}
+	text := strings.Join(strings.Fields(strings.Join(parts, " ")), " ")
+	if utf8.RuneCountInString(text) <= ExcerptMaxRunes {
+		return text
+	}
+	runes := []rune(text)[:ExcerptMaxRunes]
+	// Prefer a whole sentence if one ends in the second half.
+	for i := len(runes) - 1; i >= ExcerptMaxRunes/2; i-- {
+		if strings.ContainsRune("。！？!?；;", runes[i]) {
+			return string(runes[:i+1])
+		}
+	}
+	return strings.TrimRight(string(runes), " ，,、：:") + "…"
+}
+
+// headingIDs hands out unique heading ids: the pinyin slug of the heading
+// text, "section" when that is empty, with -2, -3, … on repeats.
+type headingIDs map[string]bool
+
+func newHeadingIDs() headingIDs { return headingIDs{} }
+
+// Generate and Put let goldmark use the same ids for Markdown headings.
+func (ids headingIDs) Generate(value []byte, _ ast.NodeKind) []byte {
+	return []byte(ids.generate(string(value)))
+}
+
+func (ids headingIDs) Put(value []byte) { ids.put(string(value)) }
+
+func (ids headingIDs) generate(text string) string {
+	base := util.Slugify(text)
+	if base == "" {
+		base = "section"
+	}
+	id := base
+	for n := 2; ids[id]; n++ {
+		id = base + "-" + strconv.Itoa(n)
+	}
+	ids[id] = true
+	return id
+}
+
+func (ids headingIDs) put(id string) { ids[id] = true }
+
+func headingLevel(n *html.Node) int {
+	if n.Type != html.ElementNode {
+		return 0
+	}
+	switch n.DataAtom {
+	case atom.H1:
+		return 1
+	case atom.H2:
+		return 2
+	case atom.H3:
+		return 3
+	case atom.H4:
+		return 4
+	case atom.H5:
+		return 5
+	case atom.H6:
+		return 6
+	}
+	return 0
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// nodeText concatenates the text under n, without heading anchor links
+// ("#"). skipCode also leaves out code blocks and tables, which make poor
+// excerpts.
+func nodeText(n *html.Node, skipCode bool) string {
+	var b strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+			return
+		case n.Type == html.ElementNode && n.DataAtom == atom.A && strings.Contains(attr(n, "class"), "heading-anchor"):
+			return
+		case n.Type == html.ElementNode && skipCode && (n.DataAtom == atom.Pre || n.DataAtom == atom.Table):
+			return
+		}
+		if n.Type == html.ElementNode && n.DataAtom != 0 && isBlock(n.DataAtom) {
+			b.WriteByte(' ')
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+func isBlock(a atom.Atom) bool {
+	switch a {
+	case atom.P, atom.Div, atom.Li, atom.Br, atom.Blockquote, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6, atom.Tr, atom.Td, atom.Th:
+		return true
+	}
+	return false
+}
+
+// countText returns the CJK characters, the other words and the non-space
+// characters of s.
+func countText(s string) (cjk, words, chars int) {
+	inWord := false
+	for _, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+			inWord = false
+			continue
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			cjk++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				words++
+			}
+			inWord = true
+		default:
+			// Punctuation separates words but is not one.
+			inWord = false
+		}
+		chars++
+	}
+	return cjk, words, chars
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/linkyfish/kxl_backend_go/internal/model"
+)
+
+func TestOutlineHTML(t *testing.T) {
+	body := `<h2>主要特性</h2><p>多组织架构与 mobile approval。</p><h3 id="custom">Setup</h3><h2>主要特性</h2><h5>Deep</h5>`
+	o := OutlineHTML(body)
+
+	wantTOC := []TOCEntry{
+		{Level: 2, ID: "zhu-yao-te-xing", Text: "主要特性"},
+		{Level: 3, ID: "custom", Text: "Setup"},
+		{Level: 2, ID: "zhu-yao-te-xing-2", Text: "主要特性"},
+	}
+	if !reflect.DeepEqual(o.TOC, wantTOC) {
+		t.Errorf("TOC = %+v, want %+v", o.TOC, wantTOC)
+	}
+	for _, want := range []string{`<h2 id="zhu-yao-te-xing">`, `<h3 id="custom">`, `<h2 id="zhu-yao-te-xing-2">`, `<h5 id="deep">`} {
+		if !strings.Contains(o.HTML, want) {
+			t.Errorf("HTML missing %q: %s", want, o.HTML)
+		}
+	}
+	// 4+4+6 CJK characters, "mobile approval Setup Deep" is 4 words.
+	if o.WordCount != 18 || o.ReadingMinutes != 1 {
+		t.Errorf("WordCount = %d, ReadingMinutes = %d", o.WordCount, o.ReadingMinutes)
+	}
+}
+
+func TestOutlineReadingTimeIsCJKAware(t *testing.T) {
+	cjk := OutlineHTML("<p>" + strings.Repeat("字", 1200) + "</p>")
+	if cjk.ReadingMinutes != 3 {
+		t.Errorf("1200 CJK characters: ReadingMinutes = %d, want 3", cjk.ReadingMinutes)
+	}
+	en := OutlineHTML("<p>" + strings.Repeat("word ", 1000) + "</p>")
+	if en.ReadingMinutes != 5 || en.WordCount != 1000 {
+		t.Errorf("1000 words: ReadingMinutes = %d, WordCount = %d", en.ReadingMinutes, en.WordCount)
+	}
+}
+
+func TestOutlineMarkdownKeepsRenderedIDs(t *testing.T) {
+	a := &model.Article{ContentFormat: ContentFormatMarkdown, Content: "## 主要特性\n\ntext\n\n## 主要特性\n", Summary: "s"}
+	if err := RenderArticle(a); err != nil {
+		t.Fatal(err)
+	}
+	o := OutlineArticle(a)
+	if len(o.TOC) != 2 || o.TOC[0].ID != "zhu-yao-te-xing" || o.TOC[1].ID != "zhu-yao-te-xing-2" || o.TOC[0].Text != "主要特性" {
+		t.Errorf("TOC = %+v", o.TOC)
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	if got := Excerpt("<h2>Intro</h2><p>Short <b>text</b>.</p><pre>code()</pre>"); got != "Intro Short text." {
+		t.Errorf("Excerpt = %q", got)
+	}
+	long := "<p>" + strings.Repeat("开心乐科技", 14) + "。" + strings.Repeat("云端", 40) + "</p>"
+	if got := Excerpt(long); got != strings.Repeat("开心乐科技", 14)+"。" {
+		t.Errorf("sentence cut: %q", got)
+	}
+	noStop := "<p>" + strings.Repeat("字", 200) + "</p>"
+	if got := Excerpt(noStop); got != strings.Repeat("字", ExcerptMaxRunes)+"…" {
+		t.Errorf("hard cut: %q", got)
+	}
+
+	a := &model.Article{ContentFormat: ContentFormatHTML, Content: "<p>Body</p>"}
+	if err := RenderArticle(a); err != nil {
+		t.Fatal(err)
+	}
+	if a.Summary != "Body" || !a.SummaryGenerated {
+		t.Errorf("generated summary = %q (%v)", a.Summary, a.SummaryGenerated)
+	}
+	// A generated summary follows content edits; an editor's does not.
+	a.Content = "<p>New body</p>"
+	if err := RenderArticle(a); err != nil {
+		t.Fatal(err)
+	}
+	if a.Summary != "New body" {
+		t.Errorf("stale generated summary = %q", a.Summary)
+	}
+	a.Summary, a.SummaryGenerated = "Mine", false
+	if err := RenderArticle(a); err != nil {
+		t.Fatal(err)
+	}
+	if a.Summary != "Mine" {
+		t.Errorf("editor summary replaced: %q", a.Summary)
+	}
+}
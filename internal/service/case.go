@@ -21,7 +21,7 @@ func (s *CaseService) ListPublic(ctx context.Context, page, pageSize int64, cate
 	if s == nil || s.db == nil {
 		return nil, 0, kxlerrors.Internal("db not configured")
 	}
-	q := s.db.WithContext(ctx).Model(&model.CaseStudy{}).Where("status = ?", 1)
+	q := s.db.WithContext(ctx).Model(&model.CaseStudy{}).Scopes(Published)
 	if categoryID != nil {
 		q = q.Where("category_id = ?", *categoryID)
 	}
@@ -45,6 +45,18 @@ func (s *CaseService) ListPublic(ctx context.Context, page, pageSize int64, cate
 }
 
 func (s *CaseService) GetPublic(ctx context.Context, id string) (*model.CaseStudy, error) {
+	c, err := s.GetPreview(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !isPublished(c.Status, c.PublishedAt) {
+		return nil, kxlerrors.NotFound("not found: case not found")
+	}
+	return c, nil
+}
+
+// GetPreview returns the case whatever its status, for signed preview links.
+func (s *CaseService) GetPreview(ctx context.Context, id string) (*model.CaseStudy, error) {
 	if s == nil || s.db == nil {
 		return nil, kxlerrors.Internal("db not configured")
 	}
@@ -55,9 +67,6 @@ func (s *CaseService) GetPublic(ctx context.Context, id string) (*model.CaseStud
 		}
 		return nil, kxlerrors.Internal("db error")
 	}
-	if c.Status != 1 {
-		return nil, kxlerrors.NotFound("not found: case not found")
-	}
 	return &c, nil
 }
 
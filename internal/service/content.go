@@ -0,0 +1,42 @@
+package service
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Content types shared by revisions, scheduled publishing, slugs and series.
+const (
+	ContentArticle = "article"
+	ContentProject = "project"
+	ContentCase    = "case"
+)
+
+// contentTables maps the content types to their tables, the path of their
+// SSR detail pages and the column holding their title.
+var contentTables = map[string]struct {
+	table    string
+	notFound string
+	path     string
+	title    string
+}{
+	ContentArticle: {"articles", "not found: article not found", "/articles/", "title"},
+	ContentProject: {"projects", "not found: project not found", "/projects/", "name"},
+	ContentCase:    {"cases", "not found: case not found", "/cases/", "client_name"},
+}
+
+// publishedCond is what visitors may see of articles, projects and cases:
+// published, with a publish time that has been reached.
+const publishedCond = "status = 1 AND published_at <= now()"
+
+// Published scopes a query on articles, projects or cases to published
+// content, e.g. db.Model(&model.Project{}).Scopes(service.Published).
+func Published(db *gorm.DB) *gorm.DB {
+	return db.Where(publishedCond)
+}
+
+// isPublished is publishedCond for a loaded row.
+func isPublished(status int16, publishedAt *time.Time) bool {
+	return status == 1 && publishedAt != nil && !publishedAt.After(time.Now())
+}
@@ -0,0 +1,354 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+)
+
+var errMalformedImage = errors.New("malformed image")
+
+// stripImageMetadata removes EXIF, XMP, IPTC and text metadata (camera
+// details, GPS coordinates, ...) from an uploaded image. Pixels are left
+// untouched except for JPEGs carrying an EXIF orientation, which are
+// re-encoded upright since the tag that told viewers to rotate them is gone.
+// JPEGs too large to decode safely (maxDerivativePixels) keep a minimal EXIF
+// block holding only the orientation instead. Colour profiles are kept, also
+// across the re-encode. GIFs are returned as-is.
+func stripImageMetadata(ext string, data []byte) ([]byte, error) {
+	switch ext {
+	case "jpg":
+		if o := jpegOrientation(data); o > 1 && o <= 8 {
+			cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxDerivativePixels {
+				stripped, err := stripJPEG(data)
+				if err != nil {
+					return nil, err
+				}
+				return insertJPEGSegment(stripped, orientationSegment(o)), nil
+			}
+			img, err := jpeg.Decode(bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			var buf bytes.Buffer
+			if err := jpeg.Encode(&buf, orientImage(img, o), &jpeg.Options{Quality: 90}); err != nil {
+				return nil, err
+			}
+			enc := buf.Bytes()
+			icc := jpegSegments(data, 0xE2)
+			if len(icc) == 0 {
+				return enc, nil
+			}
+			out := make([]byte, 0, len(enc)+len(icc))
+			out = append(out, enc[:2]...)
+			out = append(out, icc...)
+			return append(out, enc[2:]...), nil
+		}
+		return stripJPEG(data)
+	case "png":
+		return stripPNG(data)
+	case "webp":
+		return stripWebP(data)
+	default:
+		return data, nil
+	}
+}
+
+// stripJPEG drops APP1 (EXIF/XMP), APP13 (IPTC) and comment segments without
+// re-encoding. APP0 (JFIF), APP2 (ICC) and APP14 (Adobe) are kept as they
+// affect how the image is decoded. The output ends at the first EOI, which
+// drops trailing data such as the MPF preview images phones append (each
+// with its own EXIF block).
+func stripJPEG(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, errMalformedImage
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xFF, 0xD8)
+	i := 2
+	for {
+		for i < len(data) && data[i] == 0xFF && i+1 < len(data) && data[i+1] == 0xFF {
+			i++ // fill bytes
+		}
+		if i+4 > len(data) || data[i] != 0xFF {
+			return nil, errMalformedImage
+		}
+		marker := data[i+1]
+		n := int(binary.BigEndian.Uint16(data[i+2:]))
+		if n < 2 || i+2+n > len(data) {
+			return nil, errMalformedImage
+		}
+		if marker == 0xDA {
+			// Start of scan: the scans and the tables between them are
+			// copied verbatim up to the end of the image.
+			end, err := jpegImageEnd(data, i)
+			if err != nil {
+				return nil, err
+			}
+			return append(out, data[i:end]...), nil
+		}
+		switch marker {
+		case 0xE1, 0xED, 0xFE:
+		default:
+			out = append(out, data[i:i+2+n]...)
+		}
+		i += 2 + n
+	}
+}
+
+// jpegImageEnd returns the offset just past the EOI marker of a JPEG whose
+// first scan starts at sos. Inside entropy-coded data 0xFF is followed only
+// by stuffing (0x00) or restart markers; other markers are segments between
+// progressive scans and are skipped by their length.
+func jpegImageEnd(data []byte, sos int) (int, error) {
+	i := sos
+	for i+1 < len(data) {
+		if data[i] != 0xFF {
+			i++
+			continue
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xD9:
+			return i + 2, nil
+		case marker == 0x00 || marker == 0xFF || (marker >= 0xD0 && marker <= 0xD7):
+			i++
+		default:
+			if i+4 > len(data) {
+				return 0, errMalformedImage
+			}
+			n := int(binary.BigEndian.Uint16(data[i+2:]))
+			if n < 2 || i+2+n > len(data) {
+				return 0, errMalformedImage
+			}
+			i += 2 + n
+		}
+	}
+	return 0, errMalformedImage
+}
+
+// orientationSegment builds an APP1 segment whose EXIF block holds nothing
+// but the orientation tag.
+func orientationSegment(orientation int) []byte {
+	seg := []byte{
+		0xFF, 0xE1, 0x00, 0x22,
+		'E', 'x', 'i', 'f', 0, 0,
+		'M', 'M', 0x00, 0x2A, 0x00, 0x00, 0x00, 0x08, // TIFF header, IFD0 at 8
+		0x00, 0x01, // one entry
+		0x01, 0x12, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, // orientation, SHORT
+		0x00, 0x00, 0x00, 0x00, // no next IFD
+	}
+	binary.BigEndian.PutUint16(seg[28:], uint16(orientation))
+	return seg
+}
+
+// insertJPEGSegment adds seg after SOI and a leading APP0 (JFIF), which must
+// stay first.
+func insertJPEGSegment(data, seg []byte) []byte {
+	at := 2
+	if len(data) >= 6 && data[2] == 0xFF && data[3] == 0xE0 {
+		if n := int(binary.BigEndian.Uint16(data[4:])); at+2+n <= len(data) {
+			at += 2 + n
+		}
+	}
+	out := make([]byte, 0, len(data)+len(seg))
+	out = append(out, data[:at]...)
+	out = append(out, seg...)
+	return append(out, data[at:]...)
+}
+
+// jpegOrientation returns the EXIF orientation (1-8) of a JPEG, or 0 when it
+// has none.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 0
+	}
+	i := 2
+	for i+4 <= len(data) && data[i] == 0xFF {
+		marker := data[i+1]
+		n := int(binary.BigEndian.Uint16(data[i+2:]))
+		if marker == 0xDA || n < 2 || i+2+n > len(data) {
+			return 0
+		}
+		seg := data[i+4 : i+2+n]
+		if marker == 0xE1 && bytes.HasPrefix(seg, []byte("Exif\x00\x00")) {
+			return exifOrientation(seg[6:])
+		}
+		i += 2 + n
+	}
+	return 0
+}
+
+// jpegSegments returns the concatenated segments (marker included) with the
+// given marker that precede the first scan.
+func jpegSegments(data []byte, want byte) []byte {
+	var out []byte
+	i := 2
+	for i+4 <= len(data) && data[i] == 0xFF {
+		marker := data[i+1]
+		n := int(binary.BigEndian.Uint16(data[i+2:]))
+		if marker == 0xDA || n < 2 || i+2+n > len(data) {
+			break
+		}
+		if marker == want {
+			out = append(out, data[i:i+2+n]...)
+		}
+		i += 2 + n
+	}
+	return out
+}
+
+// exifOrientation reads tag 0x0112 from IFD0 of a TIFF-structured EXIF block.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return 0
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for e := 0; e < count; e++ {
+		off := ifd + 2 + e*12
+		if off+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[off:]) == 0x0112 && order.Uint16(tiff[off+2:]) == 3 {
+			return int(order.Uint16(tiff[off+8:]))
+		}
+	}
+	return 0
+}
+
+// orientImage applies an EXIF orientation so the result displays upright
+// without the tag.
+func orientImage(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	src := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	w, h := b.Dx(), b.Dy()
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored horizontally
+				sx, sy = w-1-x, y
+			case 3: // rotated 180°
+				sx, sy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				sx, sy = x, h-1-y
+			case 5: // transposed
+				sx, sy = y, x
+			case 6: // needs a 90° clockwise turn
+				sx, sy = y, h-1-x
+			case 7: // transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // needs a 90° counter-clockwise turn
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], src.Pix[src.PixOffset(sx, sy):src.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
+
+// stripPNG drops the eXIf, text and timestamp chunks. Chunks are copied with
+// their CRCs, so nothing is re-encoded.
+func stripPNG(data []byte) ([]byte, error) {
+	const sig = "\x89PNG\r\n\x1a\n"
+	if !bytes.HasPrefix(data, []byte(sig)) {
+		return nil, errMalformedImage
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, sig...)
+	i := len(sig)
+	for i < len(data) {
+		if i+8 > len(data) {
+			return nil, errMalformedImage
+		}
+		n := int(binary.BigEndian.Uint32(data[i:]))
+		end := i + 12 + n
+		if n < 0 || end > len(data) {
+			return nil, errMalformedImage
+		}
+		typ := string(data[i+4 : i+8])
+		switch typ {
+		case "eXIf", "tEXt", "zTXt", "iTXt", "tIME":
+		default:
+			out = append(out, data[i:end]...)
+		}
+		if typ == "IEND" {
+			// Anything appended after the image is dropped too.
+			return out, nil
+		}
+		i = end
+	}
+	return nil, errMalformedImage
+}
+
+// stripWebP drops the EXIF and XMP chunks of an extended (VP8X) WebP and
+// clears the matching feature flags. Simple WebPs cannot carry metadata.
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errMalformedImage
+	}
+	if total := 8 + int(binary.LittleEndian.Uint32(data[4:])); total >= 12 && total < len(data) {
+		data = data[:total]
+	}
+	out := make([]byte, 12, len(data))
+	copy(out, data[:12])
+	i := 12
+	for i < len(data) {
+		if i+8 > len(data) {
+			return nil, errMalformedImage
+		}
+		fourcc := string(data[i : i+4])
+		n := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + 8 + n + n%2
+		if n < 0 || end > len(data) {
+			return nil, errMalformedImage
+		}
+		switch fourcc {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte(nil), data[i:end]...)
+			if n > 0 {
+				chunk[8] &^= 0x08 | 0x04 // EXIF and XMP present
+			}
+			out = append(out, chunk...)
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, nil
+}
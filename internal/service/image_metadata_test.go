@@ -0,0 +1,177 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// exifSegment builds an APP1 segment with a big-endian IFD0 holding the
+// orientation tag and a dummy GPS IFD pointer.
+func exifSegment(orientation uint16) []byte {
+	var tiff bytes.Buffer
+	tiff.WriteString("MM")
+	_ = binary.Write(&tiff, binary.BigEndian, uint16(42))
+	_ = binary.Write(&tiff, binary.BigEndian, uint32(8))
+	_ = binary.Write(&tiff, binary.BigEndian, uint16(2))
+	for _, e := range [][3]uint32{{0x0112, 3, uint32(orientation) << 16}, {0x8825, 4, 0}} {
+		_ = binary.Write(&tiff, binary.BigEndian, uint16(e[0]))
+		_ = binary.Write(&tiff, binary.BigEndian, uint16(e[1]))
+		_ = binary.Write(&tiff, binary.BigEndian, uint32(1))
+		_ = binary.Write(&tiff, binary.BigEndian, e[2])
+	}
+	_ = binary.Write(&tiff, binary.BigEndian, uint32(0))
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	seg := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+func testJPEG(t *testing.T, w, h int, orientation uint16) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{uint8(x * 4), uint8(y * 4), 0, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	out := append([]byte{}, data[:2]...)
+	out = append(out, exifSegment(orientation)...)
+	return append(out, data[2:]...)
+}
+
+func TestStripImageMetadata_JPEG(t *testing.T) {
+	src := testJPEG(t, 40, 20, 1)
+	if jpegOrientation(src) != 1 {
+		t.Fatalf("fixture orientation = %d", jpegOrientation(src))
+	}
+	got, err := stripImageMetadata("jpg", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(got, []byte("Exif")) {
+		t.Fatal("EXIF survived")
+	}
+	// Without a rotation the scan data is copied, not re-encoded.
+	if len(src)-len(got) != len(exifSegment(1)) {
+		t.Fatalf("expected only the APP1 segment to be dropped: %d -> %d bytes", len(src), len(got))
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(got)); err != nil {
+		t.Fatalf("stripped jpeg does not decode: %v", err)
+	}
+}
+
+func TestStripImageMetadata_JPEGOrientation(t *testing.T) {
+	got, err := stripImageMetadata("jpg", testJPEG(t, 40, 20, 6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(got, []byte("Exif")) {
+		t.Fatal("EXIF survived")
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(got))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Width != 20 || cfg.Height != 40 {
+		t.Fatalf("orientation 6 not applied: %dx%d", cfg.Width, cfg.Height)
+	}
+}
+
+func TestStripImageMetadata_JPEGTrailingData(t *testing.T) {
+	src := testJPEG(t, 40, 20, 1)
+	// Phones append MPF preview images, each with its own EXIF, after EOI.
+	withPreview := append(append([]byte{}, src...), testJPEG(t, 8, 8, 1)...)
+	got, err := stripImageMetadata("jpg", withPreview)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(got, []byte("Exif")) {
+		t.Fatal("EXIF of the appended image survived")
+	}
+	if !bytes.HasSuffix(got, []byte{0xFF, 0xD9}) || len(got) != len(src)-len(exifSegment(1)) {
+		t.Fatalf("output not cut at EOI: %d bytes", len(got))
+	}
+}
+
+func TestStripImageMetadata_JPEGOrientationTooLarge(t *testing.T) {
+	src := testJPEG(t, 40, 20, 6)
+	// Claim a 20000x20000 canvas in SOF0 so decoding is refused.
+	sof := bytes.Index(src, []byte{0xFF, 0xC0})
+	binary.BigEndian.PutUint16(src[sof+5:], 20000)
+	binary.BigEndian.PutUint16(src[sof+7:], 20000)
+
+	got, err := stripImageMetadata("jpg", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jpegOrientation(got) != 6 {
+		t.Fatalf("orientation = %d, want 6 kept", jpegOrientation(got))
+	}
+	if len(got) >= len(src) {
+		t.Fatalf("original EXIF not replaced: %d -> %d bytes", len(src), len(got))
+	}
+}
+
+func TestOrientImage(t *testing.T) {
+	// 2x1 image: red at (0,0), blue at (1,0).
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	red, blue := color.NRGBA{255, 0, 0, 255}, color.NRGBA{0, 0, 255, 255}
+	img.Set(0, 0, red)
+	img.Set(1, 0, blue)
+
+	cases := map[int][2]color.NRGBA{ // orientation -> first, last pixel
+		2: {blue, red},
+		3: {blue, red},
+		6: {red, blue}, // turned clockwise: red ends up on top
+		8: {blue, red},
+	}
+	for o, want := range cases {
+		dst := orientImage(img, o).(*image.NRGBA)
+		b := dst.Bounds()
+		first := dst.NRGBAAt(0, 0)
+		last := dst.NRGBAAt(b.Dx()-1, b.Dy()-1)
+		if first != want[0] || last != want[1] {
+			t.Errorf("orientation %d: got %v..%v, want %v..%v", o, first, last, want[0], want[1])
+		}
+	}
+}
+
+func TestStripImageMetadata_PNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	// Insert a tEXt chunk right after IHDR (8 byte signature + 25 byte chunk).
+	text := []byte("Comment\x00lat=52.1,lon=4.3")
+	chunk := make([]byte, 8, 12+len(text))
+	binary.BigEndian.PutUint32(chunk, uint32(len(text)))
+	copy(chunk[4:], "tEXt")
+	chunk = append(chunk, text...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+	withText := append(append(append([]byte{}, data[:33]...), chunk...), data[33:]...)
+
+	got, err := stripImageMetadata("png", withText)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatal("tEXt chunk not removed cleanly")
+	}
+	if _, err := png.Decode(bytes.NewReader(got)); err != nil {
+		t.Fatalf("stripped png does not decode: %v", err)
+	}
+}
@@ -0,0 +1,421 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/linkyfish/kxl_backend_go/internal/model"
+	"golang.org/x/image/draw"
+)
+
+// Derivatives are skipped for images above this many pixels so a small but
+// hostile file cannot make us allocate gigabytes while decoding.
+const maxDerivativePixels = 50_000_000
+
+const variantCacheTTL = 5 * time.Minute
+
+var defaultImageVariantWidths = []int{320, 640, 1280}
+
+// ImageVariant is a resized copy of an uploaded image. URL keeps the source
+// format; WebP is the same size encoded as lossless WebP. Only PNG sources
+// get one (when it is smaller): lossless WebP loses to JPEG on photos.
+type ImageVariant struct {
+	Width int    `json:"width"`
+	URL   string `json:"url"`
+	WebP  string `json:"webp,omitempty"`
+}
+
+// derivedImage is what generateVariants produced for one original; Keys are
+// the storage keys written, recorded on the media row for later cleanup.
+type derivedImage struct {
+	Width    int
+	Height   int
+	Variants []ImageVariant
+	Keys     []string
+}
+
+type variantCacheEntry struct {
+	variants []ImageVariant
+	expires  time.Time
+}
+
+// variantKey maps "images/2024/05/<uuid>.jpg" to "images/2024/05/<uuid>_w640.jpg".
+func variantKey(key string, width int, ext string) string {
+	return fmt.Sprintf("%s_w%d.%s", strings.TrimSuffix(key, path.Ext(key)), width, ext)
+}
+
+// derivableExt reports whether images with this extension get derivatives.
+// GIFs are left alone so animations survive.
+func derivableExt(ext string) bool {
+	switch ext {
+	case "jpg", "png", "webp":
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *UploadService) variantWidths() []int {
+	widths := defaultImageVariantWidths
+	if s != nil && s.cfg != nil {
+		widths = s.cfg.Uploads.ImageVariantWidths
+	}
+	out := make([]int, 0, len(widths))
+	seen := map[int]bool{}
+	for _, w := range widths {
+		if w > 0 && !seen[w] {
+			seen[w] = true
+			out = append(out, w)
+		}
+	}
+	sort.Ints(out)
+	return out
+}
+
+// stripImageMetadata reports whether uploaded images are cleaned of EXIF and
+// other metadata before they are stored.
+func (s *UploadService) stripImageMetadata() bool {
+	if s == nil || s.cfg == nil {
+		return true
+	}
+	return s.cfg.Uploads.ImageStripMetadata
+}
+
+// stripVideoMetadata reports whether uploaded videos are cleaned of their
+// metadata boxes before they are stored.
+func (s *UploadService) stripVideoMetadata() bool {
+	if s == nil || s.cfg == nil {
+		return true
+	}
+	return s.cfg.Uploads.VideoStripMetadata
+}
+
+func (s *UploadService) webpEnabled() bool {
+	if s == nil || s.cfg == nil {
+		return true
+	}
+	return s.cfg.Uploads.ImageWebP
+}
+
+// generateVariants decodes the stored original and writes one resized copy
+// per configured width narrower than the source (never upscaling), plus
+// lossless WebP renditions of PNG sources where they come out smaller. The source
+// dimensions are reported even when no variants are made. On failure every
+// variant written so far is removed.
+func (s *UploadService) generateVariants(ctx context.Context, key, ext string, data []byte) (derivedImage, error) {
+	var out derivedImage
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return out, err
+	}
+	// Normally a no-op since stripping already applied it, but derivatives
+	// must come out upright when metadata stripping is turned off.
+	orientation := 0
+	if ext == "jpg" {
+		orientation = jpegOrientation(data)
+	}
+	out.Width, out.Height = cfg.Width, cfg.Height
+	if orientation >= 5 && orientation <= 8 {
+		out.Width, out.Height = cfg.Height, cfg.Width
+	}
+	widths := s.variantWidths()
+	if !derivableExt(ext) || len(widths) == 0 || widths[0] >= out.Width || cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxDerivativePixels {
+		return out, nil
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return out, err
+	}
+	src = orientImage(src, orientation)
+
+	encode := func(mime string, img image.Image) (*bytes.Buffer, error) {
+		var buf bytes.Buffer
+		if err := encodeImage(&buf, mime, img); err != nil {
+			return nil, err
+		}
+		return &buf, nil
+	}
+	put := func(k, mime string, buf *bytes.Buffer) error {
+		if err := s.store.Put(ctx, k, buf, int64(buf.Len()), mime); err != nil {
+			return err
+		}
+		out.Keys = append(out.Keys, k)
+		return nil
+	}
+
+	for _, width := range widths {
+		if width >= out.Width {
+			break
+		}
+		dst := resizeToWidth(src, width)
+		v := ImageVariant{Width: width}
+		var buf *bytes.Buffer
+		if ext != "webp" {
+			k := variantKey(key, width, ext)
+			if buf, err = encode(mimeForExt(ext), dst); err != nil {
+				break
+			}
+			size := buf.Len()
+			if err = put(k, mimeForExt(ext), buf); err != nil {
+				break
+			}
+			v.URL = s.PublicURL(k)
+			if ext == "png" && s.webpEnabled() {
+				// The WebP encoder is lossless: it beats PNG but not JPEG, and
+				// even for PNG it is only offered when it saves bytes.
+				var webp *bytes.Buffer
+				if webp, err = encode("image/webp", dst); err != nil {
+					break
+				}
+				if webp.Len() < size {
+					k := variantKey(key, width, "webp")
+					if err = put(k, "image/webp", webp); err != nil {
+						break
+					}
+					v.WebP = s.PublicURL(k)
+				}
+			}
+		} else {
+			k := variantKey(key, width, "webp")
+			if buf, err = encode("image/webp", dst); err != nil {
+				break
+			}
+			if err = put(k, "image/webp", buf); err != nil {
+				break
+			}
+			v.URL, v.WebP = s.PublicURL(k), s.PublicURL(k)
+		}
+		out.Variants = append(out.Variants, v)
+	}
+	if err != nil {
+		for _, k := range out.Keys {
+			_ = s.store.Delete(ctx, k)
+		}
+		out.Variants, out.Keys = nil, nil
+		return out, err
+	}
+	return out, nil
+}
+
+// ImageVariants returns the derivatives of a stored image URL, or nil when the
+// URL is not one of ours or was uploaded before derivatives existed. Results
+// are cached briefly since templates call this on every render.
+func (s *UploadService) ImageVariants(ctx context.Context, rawURL string) []ImageVariant {
+	if s == nil {
+		return nil
+	}
+	key, ok := s.KeyFromURL(rawURL)
+	if !ok {
+		return nil
+	}
+	ext := strings.TrimPrefix(strings.ToLower(path.Ext(key)), ".")
+	widths := s.variantWidths()
+	if !derivableExt(ext) || len(widths) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	s.variantMu.Lock()
+	if e, ok := s.variantCache[key]; ok && now.Before(e.expires) {
+		s.variantMu.Unlock()
+		return e.variants
+	}
+	s.variantMu.Unlock()
+
+	// Which widths exist depends on the source size and on whether WebP
+	// paid off, so the keys recorded on the media row are authoritative.
+	// Without one, the configured widths are probed in storage.
+	keys, ok := s.recordedVariantKeys(ctx, key)
+	if !ok {
+		for _, w := range widths {
+			for _, e := range []string{ext, "webp"} {
+				k := variantKey(key, w, e)
+				if _, err := s.store.Stat(ctx, k); err == nil {
+					keys = append(keys, k)
+				}
+				if ext == "webp" {
+					break
+				}
+			}
+		}
+	}
+	variants := s.variantsFromKeys(key, ext, keys)
+
+	s.variantMu.Lock()
+	if s.variantCache == nil || len(s.variantCache) > 10000 {
+		s.variantCache = make(map[string]variantCacheEntry)
+	}
+	s.variantCache[key] = variantCacheEntry{variants: variants, expires: now.Add(variantCacheTTL)}
+	s.variantMu.Unlock()
+	return variants
+}
+
+// recordedVariantKeys returns the derivative keys stored on the media row of
+// key, or false when there is no such row.
+func (s *UploadService) recordedVariantKeys(ctx context.Context, key string) ([]string, bool) {
+	if s.db == nil {
+		return nil, false
+	}
+	var rows []model.Media
+	if err := s.db.WithContext(ctx).Select("variants").Where("path = ?", key).Limit(1).Find(&rows).Error; err != nil || len(rows) == 0 {
+		return nil, false
+	}
+	var keys []string
+	if err := json.Unmarshal(rows[0].Variants, &keys); err != nil {
+		return nil, false
+	}
+	return keys, true
+}
+
+// variantsFromKeys groups derivative keys of key by width, narrowest first.
+// Widths without a copy in the source format (or WebP for WebP sources) are
+// left out.
+func (s *UploadService) variantsFromKeys(key, ext string, keys []string) []ImageVariant {
+	prefix := strings.TrimSuffix(key, path.Ext(key)) + "_w"
+	byWidth := map[int]*ImageVariant{}
+	for _, k := range keys {
+		rest, ok := strings.CutPrefix(k, prefix)
+		if !ok {
+			continue
+		}
+		num, kext, ok := strings.Cut(rest, ".")
+		width, err := strconv.Atoi(num)
+		if !ok || err != nil || width <= 0 {
+			continue
+		}
+		v := byWidth[width]
+		if v == nil {
+			v = &ImageVariant{Width: width}
+			byWidth[width] = v
+		}
+		switch kext {
+		case "webp":
+			v.WebP = s.PublicURL(k)
+			if ext == "webp" {
+				v.URL = v.WebP
+			}
+		case ext:
+			v.URL = s.PublicURL(k)
+		}
+	}
+	var out []ImageVariant
+	for _, v := range byWidth {
+		if v.URL != "" {
+			out = append(out, *v)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Width < out[j].Width })
+	return out
+}
+
+// deleteVariants removes the derivatives of an original, best effort. Keys
+// recorded on the media row win over the currently configured widths, which
+// may have changed since the upload.
+func (s *UploadService) deleteVariants(ctx context.Context, key string, m *model.Media) {
+	for _, k := range s.variantKeys(key, m) {
+		_ = s.store.Delete(ctx, k)
+	}
+	s.variantMu.Lock()
+	delete(s.variantCache, key)
+	s.variantMu.Unlock()
+}
+
+func (s *UploadService) variantKeys(key string, m *model.Media) []string {
+	if m != nil && len(m.Variants) > 0 {
+		var keys []string
+		if err := json.Unmarshal(m.Variants, &keys); err == nil {
+			return keys
+		}
+	}
+	ext := strings.TrimPrefix(strings.ToLower(path.Ext(key)), ".")
+	if !derivableExt(ext) {
+		return nil
+	}
+	var keys []string
+	for _, w := range s.variantWidths() {
+		keys = append(keys, variantKey(key, w, ext))
+		if ext != "webp" {
+			keys = append(keys, variantKey(key, w, "webp"))
+		}
+	}
+	return keys
+}
+
+// KeyFromURL maps a URL produced by PublicURL (or the legacy "/uploads/..."
+// form) back to its storage key.
+func (s *UploadService) KeyFromURL(rawURL string) (string, bool) {
+	rawURL = strings.TrimSpace(rawURL)
+	if i := strings.IndexAny(rawURL, "?#"); i >= 0 {
+		rawURL = rawURL[:i]
+	}
+	for _, prefix := range []string{strings.TrimRight(s.PublicURL(""), "/"), "/uploads"} {
+		if prefix != "" && strings.HasPrefix(rawURL, prefix+"/") {
+			key := strings.TrimPrefix(rawURL, prefix+"/")
+			if key == "" {
+				return "", false
+			}
+			return key, true
+		}
+	}
+	return "", false
+}
+
+func resizeToWidth(src image.Image, width int) image.Image {
+	b := src.Bounds()
+	if width > b.Dx() {
+		width = b.Dx()
+	}
+	height := (b.Dy()*width + b.Dx()/2) / b.Dx()
+	if height < 1 {
+		height = 1
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+	return dst
+}
+
+func encodeImage(w io.Writer, mime string, img image.Image) error {
+	switch mime {
+	case "image/jpeg":
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: 82})
+	case "image/png":
+		return png.Encode(w, img)
+	case "image/webp":
+		return nativewebp.Encode(w, img, nil)
+	default:
+		return fmt.Errorf("unsupported image type %s", mime)
+	}
+}
+
+func mimeForExt(ext string) string {
+	switch ext {
+	case "jpg":
+		return "image/jpeg"
+	case "png":
+		return "image/png"
+	case "gif":
+		return "image/gif"
+	case "webp":
+		return "image/webp"
+	default:
+		return ""
+	}
+}
+
+func logVariantError(key string, err error) {
+	log.Printf("upload: image derivatives for %s failed: %v", key, err)
+}
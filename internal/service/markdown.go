@@ -0,0 +1,125 @@
+package service
+
+import (
+	"bytes"
+	"strings"
+
+	kxlerrors "github.com/linkyfish/kxl_backend_go/internal/errors"
+	"github.com/linkyfish/kxl_backend_go/internal/model"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	gmutil "github.com/yuin/goldmark/util"
+)
+
+// Content formats of articles and cases.
+const (
+	ContentFormatHTML     = "html"
+	ContentFormatMarkdown = "markdown"
+)
+
+// markdown renders GitHub-flavoured Markdown (tables, strikethrough, task
+// lists, autolinks) with highlighted fenced code and linkable headings,
+// whose ids are made by headingIDs like those of HTML articles. Raw
+// HTML in the source is dropped, so rendered output needs no sanitizing.
+var markdown = goldmark.New(
+	goldmark.WithExtensions(
+		extension.GFM,
+		highlighting.NewHighlighting(highlighting.WithStyle("github")),
+	),
+	goldmark.WithParserOptions(
+		parser.WithAutoHeadingID(),
+		parser.WithASTTransformers(gmutil.Prioritized(headingAnchors{}, 1000)),
+	),
+)
+
+// headingAnchors appends a "#" link to every heading that has an id.
+type headingAnchors struct{}
+
+func (headingAnchors) Transform(doc *ast.Document, _ text.Reader, _ parser.Context) {
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		h, ok := n.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		id, ok := h.AttributeString("id")
+		if !ok {
+			return ast.WalkSkipChildren, nil
+		}
+		idBytes, _ := id.([]byte)
+		link := ast.NewLink()
+		link.Destination = append([]byte("#"), idBytes...)
+		link.SetAttributeString("class", []byte("heading-anchor"))
+		link.AppendChild(link, ast.NewString([]byte("#")))
+		h.AppendChild(h, link)
+		return ast.WalkSkipChildren, nil
+	})
+}
+
+// NormalizeContentFormat defaults an empty format to HTML and rejects
+// unknown ones.
+func NormalizeContentFormat(format string) (string, error) {
+	switch format {
+	case "":
+		return ContentFormatHTML, nil
+	case ContentFormatHTML, ContentFormatMarkdown:
+		return format, nil
+	}
+	return "", kxlerrors.Validation("validation error: content_format must be html or markdown")
+}
+
+// RenderMarkdown converts Markdown source to HTML.
+func RenderMarkdown(src string) (string, error) {
+	var buf bytes.Buffer
+	ctx := parser.NewContext(parser.WithIDs(newHeadingIDs()))
+	if err := markdown.Convert([]byte(src), &buf, parser.WithContext(ctx)); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// renderContent returns the HTML to cache for src: rendered Markdown, or
+// nothing for HTML content, which is served as stored.
+func renderContent(format, src string) (string, error) {
+	if format != ContentFormatMarkdown {
+		return "", nil
+	}
+	out, err := RenderMarkdown(src)
+	if err != nil {
+		return "", kxlerrors.Internal("markdown render error")
+	}
+	return out, nil
+}
+
+// RenderArticle refreshes the cached HTML of a and, when the summary is
+// empty or was generated before, sets it to an excerpt of the content, so
+// generated summaries follow content edits; call before saving it.
+func RenderArticle(a *model.Article) error {
+	html, err := renderContent(a.ContentFormat, a.Content)
+	if err != nil {
+		return err
+	}
+	a.ContentHTML = html
+	if strings.TrimSpace(a.Summary) == "" || a.SummaryGenerated {
+		a.Summary = Excerpt(a.RenderedContent())
+		a.SummaryGenerated = true
+	}
+	return nil
+}
+
+// RenderCase refreshes the cached HTML of cs; call before saving it.
+func RenderCase(cs *model.CaseStudy) error {
+	background, err := renderContent(cs.ContentFormat, cs.Background)
+	if err != nil {
+		return err
+	}
+	solution, err := renderContent(cs.ContentFormat, cs.Solution)
+	if err != nil {
+		return err
+	}
+	cs.BackgroundHTML, cs.SolutionHTML = background, solution
+	return nil
+}
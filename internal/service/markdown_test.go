@@ -0,0 +1,60 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/linkyfish/kxl_backend_go/internal/model"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	src := "## Setup guide\n\n| a | b |\n|---|---|\n| 1 | 2 |\n\n```go\nfunc main() {}\n```\n\n<script>alert(1)</script>\n"
+	out, err := RenderMarkdown(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<h2 id="setup-guide">Setup guide<a href="#setup-guide" class="heading-anchor">#</a></h2>`,
+		"<table>",
+		"<td>1</td>",
+		`<pre style=`,
+		`<span style="color:#cf222e">func</span>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "<script>") {
+		t.Errorf("raw HTML kept:\n%s", out)
+	}
+}
+
+func TestRenderArticle(t *testing.T) {
+	a := &model.Article{Content: "# Hi", ContentFormat: ContentFormatMarkdown}
+	if err := RenderArticle(a); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(a.RenderedContent(), `<h1 id="hi">`) {
+		t.Errorf("RenderedContent() = %q", a.RenderedContent())
+	}
+
+	a.ContentFormat = ContentFormatHTML
+	if err := RenderArticle(a); err != nil {
+		t.Fatal(err)
+	}
+	if a.ContentHTML != "" || a.RenderedContent() != "# Hi" {
+		t.Errorf("html content rendered: %q / %q", a.ContentHTML, a.RenderedContent())
+	}
+}
+
+func TestNormalizeContentFormat(t *testing.T) {
+	if f, err := NormalizeContentFormat(""); err != nil || f != ContentFormatHTML {
+		t.Errorf(`NormalizeContentFormat("") = %q, %v`, f, err)
+	}
+	if f, err := NormalizeContentFormat("markdown"); err != nil || f != ContentFormatMarkdown {
+		t.Errorf(`NormalizeContentFormat("markdown") = %q, %v`, f, err)
+	}
+	if _, err := NormalizeContentFormat("rst"); err == nil {
+		t.Error("unknown format accepted")
+	}
+}
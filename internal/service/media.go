@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	kxlerrors "github.com/linkyfish/kxl_backend_go/internal/errors"
+	"github.com/linkyfish/kxl_backend_go/internal/model"
+	"gorm.io/gorm"
+)
+
+// mediaRefSource is a column that may hold upload URLs, either as the whole
+// value (cover images, logos) or embedded in rich text (article content).
+type mediaRefSource struct {
+	Table    string
+	IDColumn string
+	Column   string
+}
+
+// mediaRefSources lists every place content can point at an upload. New
+// image/URL columns must be added here or their files will be collected as
+// orphans. Revisions count too, so restoring one never brings back a deleted
+// image.
+var mediaRefSources = []mediaRefSource{
+	{"projects", "id", "cover_image"},
+	{"project_features", "id", "icon"},
+	{"project_media", "id", "url"},
+	{"articles", "id", "cover_image"},
+	{"articles", "id", "content"},
+	{"cases", "id", "cover_image"},
+	{"cases", "id", "background"},
+	{"cases", "id", "solution"},
+	{"team_members", "id", "avatar"},
+	{"banners", "id", "image"},
+	{"banners", "id", "highlight"},
+	{"testimonials", "id", "avatar"},
+	{"solutions", "id", "icon"},
+	{"partners", "id", "logo"},
+	{"friendly_links", "id", "logo"},
+	{"system_configs", "id", "value"},
+	{"series", "id", "cover_image"},
+	{"content_revisions", "id", "data"},
+}
+
+// uploadKeyPattern matches storage keys produced by UploadService, with or
+// without a public base URL in front. Group 1 is the key stem shared by an
+// original and all of its derivatives (e.g. "images/2024/05/<uuid>").
+var uploadKeyPattern = regexp.MustCompile(`((?:images|videos)/\d{4}/\d{2}/[A-Za-z0-9-]+)(?:_w\d+)?\.[A-Za-z0-9]+`)
+
+// MediaReference is one row pointing at a media file.
+type MediaReference struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+	ID     string `json:"id"`
+}
+
+type MediaFilter struct {
+	Keyword      string
+	Kind         string
+	UploaderType string
+	UploaderID   string
+	Orphaned     *bool
+}
+
+type MediaScanResult struct {
+	Scanned    int `json:"scanned"`
+	Referenced int `json:"referenced"`
+	Orphaned   int `json:"orphaned"`
+}
+
+type MediaGCResult struct {
+	Scan       MediaScanResult `json:"scan"`
+	Deleted    []string        `json:"deleted"`
+	FreedBytes int64           `json:"freed_bytes"`
+	DryRun     bool            `json:"dry_run"`
+}
+
+type MediaService struct {
+	db      *gorm.DB
+	uploads *UploadService
+}
+
+func NewMediaService(db *gorm.DB, uploads *UploadService) *MediaService {
+	return &MediaService{db: db, uploads: uploads}
+}
+
+func (s *MediaService) List(ctx context.Context, f MediaFilter, page, pageSize int64) ([]model.Media, int64, error) {
+	if s == nil || s.db == nil {
+		return nil, 0, kxlerrors.Internal("db not configured")
+	}
+	q := s.db.WithContext(ctx).Model(&model.Media{})
+	if kw := strings.TrimSpace(f.Keyword); kw != "" {
+		pattern := "%" + kw + "%"
+		q = q.Where("(path ILIKE ? OR original_name ILIKE ?)", pattern, pattern)
+	}
+	if f.Kind != "" {
+		q = q.Where("kind = ?", f.Kind)
+	}
+	if f.UploaderType != "" {
+		q = q.Where("uploader_type = ?", f.UploaderType)
+	}
+	if f.UploaderID != "" {
+		q = q.Where("uploader_id = ?", f.UploaderID)
+	}
+	if f.Orphaned != nil {
+		if *f.Orphaned {
+			q = q.Where("orphaned_at IS NOT NULL")
+		} else {
+			q = q.Where("orphaned_at IS NULL")
+		}
+	}
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, kxlerrors.Internal("db error")
+	}
+	var rows []model.Media
+	if err := q.Order("created_at desc").Order("id asc").
+		Offset(int((page - 1) * pageSize)).Limit(int(pageSize)).
+		Find(&rows).Error; err != nil {
+		return nil, 0, kxlerrors.Internal("db error")
+	}
+	return rows, total, nil
+}
+
+func (s *MediaService) Get(ctx context.Context, id string) (*model.Media, error) {
+	if s == nil || s.db == nil {
+		return nil, kxlerrors.Internal("db not configured")
+	}
+	var row model.Media
+	if err := s.db.WithContext(ctx).Where("id = ?", id).First(&row).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, kxlerrors.NotFound("not found: media not found")
+		}
+		return nil, kxlerrors.Internal("db error")
+	}
+	return &row, nil
+}
+
+// URL returns the public URL of a media row.
+func (s *MediaService) URL(m *model.Media) string {
+	return s.uploads.PublicURL(m.Path)
+}
+
+// References lists the rows currently pointing at m (or one of its variants).
+func (s *MediaService) References(ctx context.Context, m *model.Media) ([]MediaReference, error) {
+	if s == nil || s.db == nil {
+		return nil, kxlerrors.Internal("db not configured")
+	}
+	stem := mediaStem(m.Path)
+	refs := []MediaReference{}
+	err := s.eachReference(ctx, "%"+stem+"%", func(src mediaRefSource, id, found string) {
+		if found == stem {
+			refs = append(refs, MediaReference{Table: src.Table, Column: src.Column, ID: id})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
+
+// Delete removes a media file, its variants and its row. Referenced media is
+// refused unless force is set.
+func (s *MediaService) Delete(ctx context.Context, id string, force bool) error {
+	m, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !force {
+		refs, err := s.References(ctx, m)
+		if err != nil {
+			return err
+		}
+		if len(refs) > 0 {
+			return kxlerrors.Conflict("conflict: media is still referenced")
+		}
+	}
+	return s.uploads.PurgeRelativePath(ctx, m.Path)
+}
+
+// Scan recomputes ref_count for every media row. Rows that drop to zero get
+// orphaned_at stamped (keeping an earlier stamp); referenced rows and site-user
+// uploads are cleared.
+func (s *MediaService) Scan(ctx context.Context) (MediaScanResult, error) {
+	var out MediaScanResult
+	if s == nil || s.db == nil {
+		return out, kxlerrors.Internal("db not configured")
+	}
+
+	counts := map[string]int{}
+	err := s.eachReference(ctx, "", func(_ mediaRefSource, _ string, stem string) {
+		counts[stem]++
+	})
+	if err != nil {
+		return out, err
+	}
+
+	var rows []model.Media
+	if err := s.db.WithContext(ctx).Select("id", "path", "uploader_type", "ref_count", "orphaned_at").Find(&rows).Error; err != nil {
+		return out, kxlerrors.Internal("db error")
+	}
+	now := time.Now().UTC()
+	for _, m := range rows {
+		n := counts[mediaStem(m.Path)]
+		updates := map[string]interface{}{"ref_count": n, "last_scanned_at": now}
+		if n > 0 {
+			updates["orphaned_at"] = nil
+			out.Referenced++
+		} else if !mediaCollectable(&m) {
+			updates["orphaned_at"] = nil
+		} else {
+			if m.OrphanedAt == nil {
+				updates["orphaned_at"] = now
+			}
+			out.Orphaned++
+		}
+		if err := s.db.WithContext(ctx).Model(&model.Media{}).Where("id = ?", m.ID).UpdateColumns(updates).Error; err != nil {
+			return out, kxlerrors.Internal("db error")
+		}
+		out.Scanned++
+	}
+	return out, nil
+}
+
+// GC scans references, then deletes media that has been unreferenced for at
+// least grace and was uploaded at least grace ago (so a file uploaded for a
+// draft that is not saved yet survives). Site-user uploads are never
+// collected. Each candidate is re-checked right before deletion.
+func (s *MediaService) GC(ctx context.Context, grace time.Duration, dryRun bool) (*MediaGCResult, error) {
+	scan, err := s.Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := &MediaGCResult{Scan: scan, Deleted: []string{}, DryRun: dryRun}
+
+	cutoff := time.Now().UTC().Add(-grace)
+	var rows []model.Media
+	if err := s.db.WithContext(ctx).
+		Where("ref_count = 0 AND orphaned_at IS NOT NULL AND orphaned_at <= ? AND created_at <= ?", cutoff, cutoff).
+		Where("uploader_type <> ?", model.UploaderTypeUser).
+		Order("created_at asc").Find(&rows).Error; err != nil {
+		return nil, kxlerrors.Internal("db error")
+	}
+	for i := range rows {
+		m := &rows[i]
+		if !mediaGCCandidate(m, cutoff) {
+			continue
+		}
+		refs, err := s.References(ctx, m)
+		if err != nil {
+			return out, err
+		}
+		if len(refs) > 0 {
+			continue
+		}
+		if !dryRun {
+			if err := s.uploads.PurgeRelativePath(ctx, m.Path); err != nil {
+				return out, err
+			}
+		}
+		out.Deleted = append(out.Deleted, m.Path)
+		out.FreedBytes += m.Size
+	}
+	return out, nil
+}
+
+// mediaCollectable reports whether m may be marked orphaned and collected.
+// Nothing in mediaRefSources points at files site users upload through
+// /api/upload, so those rows are kept until the upload itself is deleted.
+func mediaCollectable(m *model.Media) bool {
+	return m.UploaderType != model.UploaderTypeUser
+}
+
+// mediaGCCandidate is the in-memory form of GC's query: collectable, without
+// references, and both orphaned and uploaded no later than cutoff.
+func mediaGCCandidate(m *model.Media, cutoff time.Time) bool {
+	return mediaCollectable(m) && m.RefCount == 0 &&
+		m.OrphanedAt != nil && !m.OrphanedAt.After(cutoff) && !m.CreatedAt.After(cutoff)
+}
+
+// eachReference calls fn for every upload key stem found in the reference
+// sources. like narrows the rows fetched ("" means every row that could
+// contain an upload URL). Columns are matched as text, which also covers
+// JSONB (revision snapshots).
+func (s *MediaService) eachReference(ctx context.Context, like string, fn func(src mediaRefSource, id, stem string)) error {
+	for _, src := range mediaRefSources {
+		value := src.Column + "::text"
+		q := s.db.WithContext(ctx).Table(src.Table).
+			Select(src.IDColumn + "::text AS id, " + value + " AS value")
+		if like != "" {
+			q = q.Where(value+" LIKE ?", like)
+		} else {
+			q = q.Where("("+value+" LIKE ? OR "+value+" LIKE ?)", "%images/%", "%videos/%")
+		}
+		var rows []struct {
+			ID    string
+			Value *string
+		}
+		if err := q.Scan(&rows).Error; err != nil {
+			return kxlerrors.Internal("db error")
+		}
+		for _, r := range rows {
+			if r.Value == nil {
+				continue
+			}
+			seen := map[string]bool{}
+			for _, m := range uploadKeyPattern.FindAllStringSubmatch(*r.Value, -1) {
+				if !seen[m[1]] {
+					seen[m[1]] = true
+					fn(src, r.ID, m[1])
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// mediaStem strips the extension from a key: "images/2024/05/x.jpg" -> "images/2024/05/x".
+func mediaStem(key string) string {
+	if m := uploadKeyPattern.FindStringSubmatch(key); m != nil {
+		return m[1]
+	}
+	if i := strings.LastIndex(key, "."); i > strings.LastIndex(key, "/") {
+		return key[:i]
+	}
+	return key
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/linkyfish/kxl_backend_go/internal/model"
+)
+
+func TestUploadKeyPattern_MatchesOriginalsAndVariants(t *testing.T) {
+	const stem = "images/2024/05/0b6a3c1e-8d8f-4a55-9a77-2a1f0c4b9e21"
+	text := `<p><img src="/uploads/` + stem + `_w640.webp"></p>` +
+		`<a href="https://cdn.example.com/uploads/` + stem + `.jpg">x</a>` +
+		`<video src="/uploads/videos/2024/06/abc-def.mp4"></video>`
+
+	var got []string
+	for _, m := range uploadKeyPattern.FindAllStringSubmatch(text, -1) {
+		got = append(got, m[1])
+	}
+	want := []string{stem, stem, "videos/2024/06/abc-def"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+
+	if s := mediaStem(stem + ".png"); s != stem {
+		t.Fatalf("mediaStem = %q", s)
+	}
+}
+
+func TestMediaGCCandidate_KeepsUserUploads(t *testing.T) {
+	cutoff := time.Now().UTC().Add(-time.Hour)
+	orphaned := cutoff.Add(-time.Hour)
+	m := model.Media{UploaderType: model.UploaderTypeAdmin, OrphanedAt: &orphaned}
+	m.CreatedAt = orphaned
+	if !mediaGCCandidate(&m, cutoff) {
+		t.Fatal("unreferenced admin upload past the grace period should be collected")
+	}
+
+	m.UploaderType = model.UploaderTypeUser
+	if mediaCollectable(&m) || mediaGCCandidate(&m, cutoff) {
+		t.Fatal("unreferenced user upload must survive GC")
+	}
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	kxlcfg "github.com/linkyfish/kxl_backend_go/internal/config"
+	kxlerrors "github.com/linkyfish/kxl_backend_go/internal/errors"
+	"github.com/linkyfish/kxl_backend_go/internal/util"
+	"gorm.io/gorm"
+)
+
+const previewPurpose = "content-preview"
+
+// maxPreviewTTL caps the lifetime an admin may ask for.
+const maxPreviewTTL = 30 * 24 * time.Hour
+
+// PreviewLink opens the SSR detail page of unpublished content for anyone
+// holding it, until it expires.
+type PreviewLink struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type previewClaims struct {
+	Type string `json:"t"`
+	ID   string `json:"id"`
+	Exp  int64  `json:"exp"`
+}
+
+// PreviewService signs and checks draft preview links. A token is bound to
+// one article, project or case; it cannot be revoked before it expires
+// except by rotating security.signing_secret.
+type PreviewService struct {
+	cfg *kxlcfg.Config
+	db  *gorm.DB
+}
+
+func NewPreviewService(cfg *kxlcfg.Config, db *gorm.DB) *PreviewService {
+	return &PreviewService{cfg: cfg, db: db}
+}
+
+// Issue signs a preview link for typ/id, pointing at the content's current
+// slug (or its id when it has none). ttl <= 0 uses
+// security.preview_ttl_seconds.
+func (s *PreviewService) Issue(ctx context.Context, typ, id string, ttl time.Duration) (*PreviewLink, error) {
+	if s == nil || s.db == nil {
+		return nil, kxlerrors.Internal("db not configured")
+	}
+	ct, ok := contentTables[typ]
+	if !ok {
+		return nil, kxlerrors.Validation("validation error: unknown content type")
+	}
+	if ttl > maxPreviewTTL {
+		return nil, kxlerrors.Validation("validation error: preview links may last at most 30 days")
+	}
+	if ttl <= 0 {
+		ttl = 72 * time.Hour
+		if s.cfg != nil && s.cfg.Security.PreviewTTLSeconds > 0 {
+			ttl = time.Duration(s.cfg.Security.PreviewTTLSeconds) * time.Second
+		}
+	}
+	secret := s.signingSecret()
+	if secret == "" {
+		return nil, kxlerrors.Internal("preview links not configured")
+	}
+
+	// Link the canonical URL: the id URL of slugged content answers with a
+	// permanent redirect, which browsers cache with the token in it.
+	var rows []struct{ Slug string }
+	if err := s.db.WithContext(ctx).Table(ct.table).Select("slug").Where("id = ?", id).Limit(1).Scan(&rows).Error; err != nil {
+		return nil, kxlerrors.Internal("db error")
+	}
+	if len(rows) == 0 {
+		return nil, kxlerrors.NotFound(ct.notFound)
+	}
+	key := rows[0].Slug
+	if key == "" {
+		key = id
+	}
+
+	exp := time.Now().Add(ttl).UTC().Truncate(time.Second)
+	token, err := util.SignToken(secret, previewPurpose, previewClaims{Type: typ, ID: id, Exp: exp.Unix()})
+	if err != nil {
+		return nil, kxlerrors.Internal("failed to sign preview token")
+	}
+	return &PreviewLink{
+		Token:     token,
+		URL:       ct.path + url.PathEscape(key) + "?preview=" + url.QueryEscape(token),
+		ExpiresAt: exp,
+	}, nil
+}
+
+// Verify reports whether token is an unexpired preview token for typ/id.
+func (s *PreviewService) Verify(token, typ, id string) bool {
+	if s == nil || token == "" {
+		return false
+	}
+	var claims previewClaims
+	if err := util.VerifyToken(s.signingSecret(), previewPurpose, token, &claims); err != nil {
+		return false
+	}
+	return claims.Type == typ && claims.ID == id && time.Now().Before(time.Unix(claims.Exp, 0))
+}
+
+func (s *PreviewService) signingSecret() string {
+	if s.cfg == nil {
+		return ""
+	}
+	return s.cfg.Security.SigningSecret
+}
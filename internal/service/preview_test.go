@@ -0,0 +1,33 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	kxlcfg "github.com/linkyfish/kxl_backend_go/internal/config"
+	"github.com/linkyfish/kxl_backend_go/internal/util"
+)
+
+func TestPreviewVerify(t *testing.T) {
+	svc := NewPreviewService(&kxlcfg.Config{Security: kxlcfg.SecurityConfig{SigningSecret: "test-secret"}}, nil)
+	exp := time.Now().Add(time.Hour).Unix()
+	token, _ := util.SignToken("test-secret", previewPurpose, previewClaims{Type: ContentArticle, ID: "a1", Exp: exp})
+
+	if !svc.Verify(token, ContentArticle, "a1") {
+		t.Fatal("valid token rejected")
+	}
+	if svc.Verify(token, ContentArticle, "a2") || svc.Verify(token, ContentCase, "a1") {
+		t.Fatal("token accepted for other content")
+	}
+	expired, _ := util.SignToken("test-secret", previewPurpose, previewClaims{Type: ContentArticle, ID: "a1", Exp: 1})
+	if svc.Verify(expired, ContentArticle, "a1") {
+		t.Fatal("expired token accepted")
+	}
+	ticket, _ := util.SignToken("test-secret", uploadTicketPurpose, previewClaims{Type: ContentArticle, ID: "a1", Exp: exp})
+	if svc.Verify(ticket, ContentArticle, "a1") {
+		t.Fatal("token for another purpose accepted")
+	}
+	if NewPreviewService(&kxlcfg.Config{}, nil).Verify(token, ContentArticle, "a1") {
+		t.Fatal("token accepted without a signing secret")
+	}
+}
@@ -22,7 +22,7 @@ func (s *ProjectService) ListPublic(ctx context.Context, page, pageSize int64, c
 	if s == nil || s.db == nil {
 		return nil, 0, kxlerrors.Internal("db not configured")
 	}
-	q := s.db.WithContext(ctx).Model(&model.Project{}).Where("status = ?", 1)
+	q := s.db.WithContext(ctx).Model(&model.Project{}).Scopes(Published)
 	if categoryID != nil {
 		q = q.Where("category_id = ?", *categoryID)
 	}
@@ -46,6 +46,18 @@ func (s *ProjectService) ListPublic(ctx context.Context, page, pageSize int64, c
 }
 
 func (s *ProjectService) GetPublic(ctx context.Context, id string) (*model.Project, error) {
+	p, err := s.GetPreview(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !isPublished(p.Status, p.PublishedAt) {
+		return nil, kxlerrors.NotFound("not found: project not found")
+	}
+	return p, nil
+}
+
+// GetPreview returns the project whatever its status, for signed preview links.
+func (s *ProjectService) GetPreview(ctx context.Context, id string) (*model.Project, error) {
 	if s == nil || s.db == nil {
 		return nil, kxlerrors.Internal("db not configured")
 	}
@@ -56,9 +68,6 @@ func (s *ProjectService) GetPublic(ctx context.Context, id string) (*model.Proje
 		}
 		return nil, kxlerrors.Internal("db error")
 	}
-	if p.Status != 1 {
-		return nil, kxlerrors.NotFound("not found: project not found")
-	}
 	return &p, nil
 }
 
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	kxlerrors "github.com/linkyfish/kxl_backend_go/internal/errors"
+	"github.com/linkyfish/kxl_backend_go/internal/util"
+	"gorm.io/gorm"
+)
+
+// publishLockKey makes sure only one instance applies schedules at a time.
+const publishLockKey = "scheduler:publish:lock"
+
+// releaseLockScript deletes the lock only if this instance still holds it.
+var releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+  return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// PublishScheduler publishes and unpublishes articles, projects and cases
+// at their scheduled publish_at / unpublish_at times. Several instances may
+// run it: each tick takes a Redis lock, and without Redis it runs unlocked
+// (fine for a single instance; the updates are idempotent anyway).
+type PublishScheduler struct {
+	db       *gorm.DB
+	redis    *redis.Client
+	interval time.Duration
+	onChange func(ctx context.Context)
+}
+
+// NewPublishScheduler builds the scheduler; onChange, if set, is called after
+// a tick changed what is published (e.g. to drop search caches).
+func NewPublishScheduler(db *gorm.DB, redisClient *redis.Client, interval time.Duration, onChange func(ctx context.Context)) *PublishScheduler {
+	return &PublishScheduler{db: db, redis: redisClient, interval: interval, onChange: onChange}
+}
+
+// Schedule sets (or, with nil, clears) when typ/id gets published and
+// unpublished.
+func (s *PublishScheduler) Schedule(ctx context.Context, typ, id string, publishAt, unpublishAt *time.Time) error {
+	if s == nil || s.db == nil {
+		return kxlerrors.Internal("db not configured")
+	}
+	ct, ok := contentTables[typ]
+	if !ok {
+		return kxlerrors.Validation("validation error: unknown content type")
+	}
+	now := time.Now()
+	if publishAt != nil && !publishAt.After(now) {
+		return kxlerrors.Validation("validation error: publish_at must be in the future")
+	}
+	if unpublishAt != nil && !unpublishAt.After(now) {
+		return kxlerrors.Validation("validation error: unpublish_at must be in the future")
+	}
+	if publishAt != nil && unpublishAt != nil && !unpublishAt.After(*publishAt) {
+		return kxlerrors.Validation("validation error: unpublish_at must be after publish_at")
+	}
+
+	res := s.db.WithContext(ctx).Table(ct.table).Where("id = ?", id).Updates(map[string]interface{}{
+		"publish_at":   utcTime(publishAt),
+		"unpublish_at": utcTime(unpublishAt),
+		"updated_at":   now.UTC(),
+	})
+	if res.Error != nil {
+		return kxlerrors.Internal("db error")
+	}
+	if res.RowsAffected == 0 {
+		return kxlerrors.NotFound(ct.notFound)
+	}
+	return nil
+}
+
+// Run applies due schedules every interval until ctx is done. A zero
+// interval disables the scheduler.
+func (s *PublishScheduler) Run(ctx context.Context) {
+	if s == nil || s.db == nil || s.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if n, err := s.RunOnce(ctx); err != nil {
+			log.Printf("publish scheduler: %v", err)
+		} else if n > 0 {
+			log.Printf("publish scheduler: %d item(s) changed", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce applies the schedules that are due and returns how many rows
+// changed. It does nothing while another instance holds the lock.
+func (s *PublishScheduler) RunOnce(ctx context.Context) (int64, error) {
+	if s == nil || s.db == nil {
+		return 0, kxlerrors.Internal("db not configured")
+	}
+	if s.redis != nil {
+		token := util.NewUUID()
+		ttl := s.interval
+		if ttl < 10*time.Second {
+			ttl = 10 * time.Second
+		}
+		locked, err := s.redis.SetNX(ctx, publishLockKey, token, ttl).Result()
+		if err != nil {
+			return 0, err
+		}
+		if !locked {
+			return 0, nil
+		}
+		defer releaseLockScript.Run(context.Background(), s.redis, []string{publishLockKey}, token)
+	}
+
+	var changed int64
+	db := s.db.WithContext(ctx)
+	for _, typ := range []string{ContentArticle, ContentProject, ContentCase} {
+		table := contentTables[typ].table
+		// Publishing first: when both times have passed the item ends up
+		// offline, as it would have had the scheduler run in between.
+		res := db.Exec("UPDATE " + table + " SET status = 1, published_at = publish_at, publish_at = NULL, updated_at = now() " +
+			"WHERE publish_at <= now()")
+		if res.Error != nil {
+			return changed, res.Error
+		}
+		changed += res.RowsAffected
+		res = db.Exec("UPDATE " + table + " SET status = 0, published_at = NULL, unpublish_at = NULL, updated_at = now() " +
+			"WHERE unpublish_at <= now()")
+		if res.Error != nil {
+			return changed, res.Error
+		}
+		changed += res.RowsAffected
+	}
+	if changed > 0 && s.onChange != nil {
+		s.onChange(ctx)
+	}
+	return changed, nil
+}
+
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
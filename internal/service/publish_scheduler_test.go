@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestIsPublished(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+	cases := []struct {
+		status int16
+		at     *time.Time
+		want   bool
+	}{
+		{1, &past, true},
+		{1, &future, false},
+		{1, nil, false},
+		{0, &past, false},
+	}
+	for _, c := range cases {
+		if got := isPublished(c.status, c.at); got != c.want {
+			t.Errorf("isPublished(%d, %v) = %v, want %v", c.status, c.at, got, c.want)
+		}
+	}
+}
+
+func TestScheduleValidation(t *testing.T) {
+	// Validation runs before any query, so an unconnected DB is enough.
+	s := NewPublishScheduler(&gorm.DB{}, nil, time.Minute, nil)
+	ctx := context.Background()
+	past := time.Now().Add(-time.Minute)
+	soon := time.Now().Add(time.Hour)
+	later := soon.Add(time.Hour)
+
+	if err := s.Schedule(ctx, "page", "x", &soon, nil); err == nil {
+		t.Error("unknown content type accepted")
+	}
+	if err := s.Schedule(ctx, ContentArticle, "x", &past, nil); err == nil {
+		t.Error("publish_at in the past accepted")
+	}
+	if err := s.Schedule(ctx, ContentArticle, "x", nil, &past); err == nil {
+		t.Error("unpublish_at in the past accepted")
+	}
+	if err := s.Schedule(ctx, ContentArticle, "x", &later, &soon); err == nil {
+		t.Error("unpublish_at before publish_at accepted")
+	}
+}
@@ -91,6 +91,25 @@ func (s *RbacService) InvalidateRolePermissions(ctx context.Context, roleCode st
 	_ = s.redis.Del(ctx, cacheKey).Err()
 }
 
+// FlushCache drops every cached role permission set and returns how many keys were removed.
+func (s *RbacService) FlushCache(ctx context.Context) (int, error) {
+	if s == nil || s.redis == nil {
+		return 0, kxlerrors.Internal("redis not configured")
+	}
+	removed := 0
+	iter := s.redis.Scan(ctx, 0, "rbac:role_permissions:*", 200).Iterator()
+	for iter.Next(ctx) {
+		if err := s.redis.Del(ctx, iter.Val()).Err(); err != nil {
+			return removed, kxlerrors.Internal("redis error")
+		}
+		removed++
+	}
+	if err := iter.Err(); err != nil {
+		return removed, kxlerrors.Internal("redis error")
+	}
+	return removed, nil
+}
+
 func HasPermission(role string, permissions []string, code string) bool {
 	if role == "super_admin" {
 		return true
@@ -0,0 +1,53 @@
+package service
+
+import "github.com/linkyfish/kxl_backend_go/internal/model"
+
+// PermissionCatalog lists every permission code checked by the admin handlers.
+// Keep it in sync when adding AdminRequirePermission calls; `kxl-api seed` upserts it.
+var PermissionCatalog = []model.AdminPermission{
+	{Code: "dashboard:view", Name: "查看仪表盘", GroupName: "dashboard", Description: "View dashboard statistics"},
+
+	{Code: "projects:read", Name: "查看项目", GroupName: "projects", Description: "List and view projects"},
+	{Code: "projects:write", Name: "编辑项目", GroupName: "projects", Description: "Create, update and delete projects and their features, media and versions"},
+	{Code: "articles:read", Name: "查看文章", GroupName: "articles", Description: "List and view articles"},
+	{Code: "articles:write", Name: "编辑文章", GroupName: "articles", Description: "Create, update, publish and delete articles"},
+	{Code: "cases:read", Name: "查看案例", GroupName: "cases", Description: "List and view cases"},
+	{Code: "cases:write", Name: "编辑案例", GroupName: "cases", Description: "Create, update, publish and delete cases"},
+
+	{Code: "messages:read", Name: "查看留言", GroupName: "messages", Description: "List and view contact messages"},
+	{Code: "messages:write", Name: "处理留言", GroupName: "messages", Description: "Update status, notes and delete contact messages"},
+
+	{Code: "settings:read", Name: "查看站点设置", GroupName: "settings", Description: "View categories, tags, company info, banners and other site settings"},
+	{Code: "settings:write", Name: "编辑站点设置", GroupName: "settings", Description: "Modify categories, tags, company info, banners and other site settings"},
+
+	{Code: "search:read", Name: "查看搜索统计", GroupName: "search", Description: "View search queries, zero-result queries and click-through"},
+
+	{Code: "upload:read", Name: "查看媒体库", GroupName: "upload", Description: "Browse the media library and see where files are referenced"},
+	{Code: "upload:write", Name: "上传文件", GroupName: "upload", Description: "Upload images and videos"},
+	{Code: "upload:delete", Name: "删除文件", GroupName: "upload", Description: "Delete uploaded files and run media garbage collection"},
+
+	{Code: "users:read", Name: "查看用户", GroupName: "users", Description: "List and view site users"},
+	{Code: "users:write", Name: "管理用户", GroupName: "users", Description: "Enable or disable site users"},
+	{Code: "admins:manage", Name: "管理管理员", GroupName: "admins", Description: "Create, update and delete admin accounts"},
+	{Code: "rbac:manage", Name: "管理角色权限", GroupName: "rbac", Description: "Manage admin roles and their permissions"},
+}
+
+// SystemRoles are created by `kxl-api seed` and cannot be deleted through the API.
+// super_admin always has every permission (see HasPermission); admin gets DefaultAdminPermissions.
+var SystemRoles = []model.AdminRole{
+	{Code: "super_admin", Name: "超级管理员", Description: "Full access to every admin feature"},
+	{Code: "admin", Name: "管理员", Description: "Content management without account and role administration"},
+}
+
+// DefaultAdminPermissions is granted to the `admin` role when seed creates it; later seed
+// runs grant only the defaults that are new to the catalog.
+func DefaultAdminPermissions() []string {
+	out := make([]string, 0, len(PermissionCatalog))
+	for _, p := range PermissionCatalog {
+		if p.GroupName == "admins" || p.GroupName == "rbac" {
+			continue
+		}
+		out = append(out, p.Code)
+	}
+	return out
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// Every permission code checked by an admin handler must be part of the seeded catalog,
+// otherwise non-super admins can never be granted access to that endpoint.
+func TestPermissionCatalogCoversHandlerChecks(t *testing.T) {
+	known := make(map[string]struct{}, len(PermissionCatalog))
+	for _, p := range PermissionCatalog {
+		known[p.Code] = struct{}{}
+	}
+
+	re := regexp.MustCompile(`Admin(?:Require|Has)Permission\(c, "([^"]+)"\)`)
+	root := filepath.Join("..", "handler")
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, m := range re.FindAllStringSubmatch(string(raw), -1) {
+			if _, ok := known[m[1]]; !ok {
+				t.Errorf("%s checks %q which is missing from PermissionCatalog", path, m[1])
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk handlers: %v", err)
+	}
+}
@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	kxlerrors "github.com/linkyfish/kxl_backend_go/internal/errors"
+	"github.com/linkyfish/kxl_backend_go/internal/model"
+	"github.com/linkyfish/kxl_backend_go/internal/util"
+	"gorm.io/gorm"
+)
+
+// resumablePartPrefix is where received chunks live until finalization. It is
+// never served publicly (see UploadService.Open and the router).
+const resumablePartPrefix = "tmp/resumable/"
+
+// ResumableUploadService implements the server side of the tus 1.0 core
+// protocol (plus creation, termination and expiration). Chunks are written to
+// the storage backend so any instance can accept the next PATCH; the finished
+// upload goes through the same mime detection and media bookkeeping as a
+// regular multipart upload.
+type ResumableUploadService struct {
+	db      *gorm.DB
+	uploads *UploadService
+	expire  time.Duration
+}
+
+func NewResumableUploadService(db *gorm.DB, uploads *UploadService, expire time.Duration) *ResumableUploadService {
+	if expire <= 0 {
+		expire = 24 * time.Hour
+	}
+	return &ResumableUploadService{db: db, uploads: uploads, expire: expire}
+}
+
+// MaxSize is advertised to clients as Tus-Max-Size.
+func (s *ResumableUploadService) MaxSize() int64 {
+	return s.uploads.maxSizeBytes(UploadKindVideo)
+}
+
+// Create registers a new upload of length bytes. meta is the decoded
+// Upload-Metadata ("filename", "filetype", ...).
+func (s *ResumableUploadService) Create(ctx context.Context, length int64, meta map[string]string, by Uploader) (*model.ResumableUpload, error) {
+	if s == nil || s.db == nil {
+		return nil, kxlerrors.Internal("db not configured")
+	}
+	if length <= 0 {
+		return nil, kxlerrors.Validation("validation error: invalid Upload-Length")
+	}
+	if length > s.MaxSize() {
+		return nil, kxlerrors.New(kxlerrors.CodeValidationError, "validation error: file too large", http.StatusRequestEntityTooLarge, nil)
+	}
+
+	// Opportunistic cleanup keeps abandoned chunks from piling up without a
+	// separate job.
+	_, _ = s.CleanupExpired(ctx, 20)
+
+	metaJSON, _ := json.Marshal(meta)
+	row := &model.ResumableUpload{
+		Kind:         UploadKindVideo,
+		UploadLength: length,
+		Metadata:     metaJSON,
+		Parts:        []byte("[]"),
+		Status:       model.ResumableStatusUploading,
+		UploaderType: by.Type,
+		ExpiresAt:    time.Now().UTC().Add(s.expire),
+	}
+	if by.ID != "" {
+		id := by.ID
+		row.UploaderID = &id
+	}
+	if err := s.db.WithContext(ctx).Create(row).Error; err != nil {
+		return nil, kxlerrors.Internal("db error")
+	}
+	return row, nil
+}
+
+// Get returns an upload owned by by. Uploads of other accounts and expired
+// unfinished uploads are reported as not found.
+func (s *ResumableUploadService) Get(ctx context.Context, id string, by Uploader) (*model.ResumableUpload, error) {
+	if s == nil || s.db == nil {
+		return nil, kxlerrors.Internal("db not configured")
+	}
+	var row model.ResumableUpload
+	if err := s.db.WithContext(ctx).Where("id = ?", id).First(&row).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, kxlerrors.NotFound("not found: upload not found")
+		}
+		return nil, kxlerrors.Internal("db error")
+	}
+	if row.UploaderType != by.Type || (row.UploaderID != nil && *row.UploaderID != by.ID) {
+		return nil, kxlerrors.NotFound("not found: upload not found")
+	}
+	if row.Status != model.ResumableStatusCompleted && time.Now().After(row.ExpiresAt) {
+		return nil, kxlerrors.NotFound("not found: upload not found")
+	}
+	return &row, nil
+}
+
+// Append writes the bytes read from body at offset. Bytes that arrived before
+// the client went away are kept, so the client can resume from the returned
+// offset. When the last byte arrives the upload is finalized and the result
+// carries the media URL.
+func (s *ResumableUploadService) Append(ctx context.Context, id string, offset int64, body io.Reader, by Uploader) (*model.ResumableUpload, error) {
+	row, err := s.Get(ctx, id, by)
+	if err != nil {
+		return nil, err
+	}
+	if row.Status != model.ResumableStatusUploading {
+		return nil, kxlerrors.Conflict("conflict: upload is already complete")
+	}
+	if offset != row.UploadOffset {
+		return nil, kxlerrors.Conflict("conflict: offset mismatch")
+	}
+	if offset == row.UploadLength {
+		// Every byte is in but a previous finalization did not run.
+		return s.finalize(ctx, row, by)
+	}
+
+	// Spool to a temp file first: the body may stop at any point and only the
+	// bytes we actually received should become a part.
+	tmp, err := os.CreateTemp("", "kxl-resumable-*")
+	if err != nil {
+		return nil, kxlerrors.Internal("io error")
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+	n, copyErr := io.Copy(tmp, io.LimitReader(body, row.UploadLength-offset))
+	if n == 0 {
+		if copyErr != nil {
+			return nil, kxlerrors.Validation("validation error: failed to read chunk")
+		}
+		return row, nil
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, kxlerrors.Internal("io error")
+	}
+
+	// The suffix keeps a retried PATCH for the same offset from overwriting
+	// (and, on losing the update below, deleting) the part another one recorded.
+	partKey := fmt.Sprintf("%s%s/%020d-%s", resumablePartPrefix, row.ID, offset, util.NewUUID())
+	if err := s.uploads.store.Put(ctx, partKey, tmp, n, "application/octet-stream"); err != nil {
+		return nil, kxlerrors.Internal("io error: failed to write file")
+	}
+
+	// Optimistic update: a concurrent PATCH for the same offset loses here.
+	partJSON, _ := json.Marshal([]string{partKey})
+	res := s.db.WithContext(ctx).Model(&model.ResumableUpload{}).
+		Where("id = ? AND upload_offset = ? AND status = ?", row.ID, offset, model.ResumableStatusUploading).
+		Updates(map[string]interface{}{
+			"upload_offset": gorm.Expr("upload_offset + ?", n),
+			"parts":         gorm.Expr("parts || ?::jsonb", string(partJSON)),
+			"expires_at":    time.Now().UTC().Add(s.expire),
+			"updated_at":    time.Now().UTC(),
+		})
+	if res.Error != nil || res.RowsAffected == 0 {
+		_ = s.uploads.store.Delete(ctx, partKey)
+		if res.Error != nil {
+			return nil, kxlerrors.Internal("db error")
+		}
+		return nil, kxlerrors.Conflict("conflict: offset mismatch")
+	}
+
+	row, err = s.Get(ctx, id, by)
+	if err != nil {
+		return nil, err
+	}
+	if row.UploadOffset == row.UploadLength {
+		return s.finalize(ctx, row, by)
+	}
+	return row, nil
+}
+
+// Terminate discards an unfinished upload and its chunks.
+func (s *ResumableUploadService) Terminate(ctx context.Context, id string, by Uploader) error {
+	row, err := s.Get(ctx, id, by)
+	if err != nil {
+		return err
+	}
+	if row.Status == model.ResumableStatusFinalizing {
+		return kxlerrors.Conflict("conflict: upload is being finalized")
+	}
+	s.deleteParts(ctx, row)
+	if err := s.db.WithContext(ctx).Delete(&model.ResumableUpload{}, "id = ?", row.ID).Error; err != nil {
+		return kxlerrors.Internal("db error")
+	}
+	return nil
+}
+
+// CleanupExpired removes up to limit uploads past their expiry: unfinished
+// ones with their chunks, and the rows of completed ones, whose media lives
+// on in the media library.
+func (s *ResumableUploadService) CleanupExpired(ctx context.Context, limit int) (int, error) {
+	if s == nil || s.db == nil {
+		return 0, kxlerrors.Internal("db not configured")
+	}
+	var rows []model.ResumableUpload
+	q := s.db.WithContext(ctx).
+		Where("expires_at < ?", time.Now().UTC()).
+		Order("expires_at asc")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	if err := q.Find(&rows).Error; err != nil {
+		return 0, kxlerrors.Internal("db error")
+	}
+	for i := range rows {
+		s.deleteParts(ctx, &rows[i])
+		if err := s.db.WithContext(ctx).Delete(&model.ResumableUpload{}, "id = ?", rows[i].ID).Error; err != nil {
+			return i, kxlerrors.Internal("db error")
+		}
+	}
+	return len(rows), nil
+}
+
+// Metadata decodes the stored Upload-Metadata.
+func (s *ResumableUploadService) Metadata(row *model.ResumableUpload) map[string]string {
+	meta := map[string]string{}
+	_ = json.Unmarshal(row.Metadata, &meta)
+	return meta
+}
+
+func (s *ResumableUploadService) finalize(ctx context.Context, row *model.ResumableUpload, by Uploader) (*model.ResumableUpload, error) {
+	// Claim the row so only one request assembles the file.
+	res := s.db.WithContext(ctx).Model(&model.ResumableUpload{}).
+		Where("id = ? AND status = ?", row.ID, model.ResumableStatusUploading).
+		Updates(map[string]interface{}{"status": model.ResumableStatusFinalizing, "updated_at": time.Now().UTC()})
+	if res.Error != nil {
+		return nil, kxlerrors.Internal("db error")
+	}
+	if res.RowsAffected == 0 {
+		return nil, kxlerrors.Conflict("conflict: upload is already complete")
+	}
+
+	var parts []string
+	_ = json.Unmarshal(row.Parts, &parts)
+	r := &partsReader{ctx: ctx, s: s, keys: parts}
+	result, saveErr := s.uploads.save(ctx, r, row.UploadLength, s.Metadata(row)["filename"], row.Kind, by)
+	r.Close()
+	s.deleteParts(ctx, row)
+
+	if saveErr != nil {
+		_ = s.db.WithContext(ctx).Model(&model.ResumableUpload{}).Where("id = ?", row.ID).
+			Updates(map[string]interface{}{"status": model.ResumableStatusFailed, "parts": gorm.Expr("'[]'::jsonb"), "updated_at": time.Now().UTC()}).Error
+		return nil, saveErr
+	}
+
+	updates := map[string]interface{}{
+		"status":     model.ResumableStatusCompleted,
+		"url":        result.URL,
+		"parts":      gorm.Expr("'[]'::jsonb"),
+		"updated_at": time.Now().UTC(),
+	}
+	if result.ID != "" {
+		updates["media_id"] = result.ID
+	}
+	if err := s.db.WithContext(ctx).Model(&model.ResumableUpload{}).Where("id = ?", row.ID).Updates(updates).Error; err != nil {
+		return nil, kxlerrors.Internal("db error")
+	}
+	row.Status = model.ResumableStatusCompleted
+	row.URL = result.URL
+	if result.ID != "" {
+		row.MediaID = &result.ID
+	}
+	return row, nil
+}
+
+func (s *ResumableUploadService) deleteParts(ctx context.Context, row *model.ResumableUpload) {
+	var parts []string
+	_ = json.Unmarshal(row.Parts, &parts)
+	for _, k := range parts {
+		_ = s.uploads.store.Delete(ctx, k)
+	}
+}
+
+// partsReader concatenates the stored chunks, opening one at a time.
+type partsReader struct {
+	ctx  context.Context
+	s    *ResumableUploadService
+	keys []string
+	cur  io.ReadCloser
+}
+
+func (r *partsReader) Read(p []byte) (int, error) {
+	for {
+		if r.cur == nil {
+			if len(r.keys) == 0 {
+				return 0, io.EOF
+			}
+			rc, _, err := r.s.uploads.store.Open(r.ctx, r.keys[0])
+			if err != nil {
+				return 0, err
+			}
+			r.cur, r.keys = rc, r.keys[1:]
+		}
+		n, err := r.cur.Read(p)
+		if err == io.EOF {
+			_ = r.cur.Close()
+			r.cur = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *partsReader) Close() {
+	if r.cur != nil {
+		_ = r.cur.Close()
+		r.cur = nil
+	}
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/linkyfish/kxl_backend_go/pkg/storage"
+)
+
+func TestPartsReader_ConcatenatesChunks(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewLocal(t.TempDir())
+	s := NewResumableUploadService(nil, NewUploadService(nil, nil, store), 0)
+
+	chunks := []string{"hello ", "", "resumable ", "world"}
+	var keys []string
+	for i, c := range chunks {
+		key := resumablePartPrefix + "test/" + string(rune('a'+i))
+		if err := store.Put(ctx, key, strings.NewReader(c), int64(len(c)), "application/octet-stream"); err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+	}
+
+	r := &partsReader{ctx: ctx, s: s, keys: keys}
+	defer r.Close()
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, []byte("hello resumable world")) {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestUploadOpen_RefusesChunks(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewLocal(t.TempDir())
+	key := resumablePartPrefix + "x/00000000000000000000"
+	if err := store.Put(ctx, key, strings.NewReader("secret"), 6, "application/octet-stream"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := NewUploadService(nil, nil, store).Open(ctx, key); err == nil {
+		t.Fatal("expected chunk to be hidden")
+	}
+	for _, k := range []string{"/tmp/resumable/x", "%74mp/resumable/x", "images/../tmp/resumable/x", "./tmp", "%zz"} {
+		if !IsPrivateUploadKey(k) {
+			t.Errorf("%q not treated as private", k)
+		}
+	}
+	if IsPrivateUploadKey("images/2024/05/tmp.jpg") {
+		t.Error("regular image treated as private")
+	}
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+
+	kxlerrors "github.com/linkyfish/kxl_backend_go/internal/errors"
+	"github.com/linkyfish/kxl_backend_go/internal/model"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// revisionType lists the fields a revision snapshots and restores: the
+// editable content of the row, not its status, counters or timestamps.
+// Field names are both the JSON names of the model and its columns. render,
+// if set, sanitizes the restored fields and recomputes the derived columns.
+type revisionType struct {
+	newRow  func() interface{}
+	fields  []string
+	render  func(row interface{}, san *HTMLSanitizer) error
+	derived []string
+}
+
+var revisionTypes = map[string]revisionType{
+	ContentArticle: {
+		newRow: func() interface{} { return &model.Article{} },
+		fields: []string{"title", "summary", "summary_generated", "content", "content_format", "cover_image", "category_id"},
+		render: func(row interface{}, san *HTMLSanitizer) error {
+			a := row.(*model.Article)
+			// Revisions from before content formats existed are HTML.
+			if a.ContentFormat == "" {
+				a.ContentFormat = ContentFormatHTML
+			}
+			san.Article(a)
+			return RenderArticle(a)
+		},
+		derived: []string{"content_html"},
+	},
+	ContentProject: {
+		newRow: func() interface{} { return &model.Project{} },
+		fields: []string{"name", "description", "cover_image", "category_id", "sort_order"},
+	},
+	ContentCase: {
+		newRow: func() interface{} { return &model.CaseStudy{} },
+		fields: []string{"client_name", "cover_image", "summary", "background", "solution", "content_format",
+			"results", "testimonial", "testimonial_author", "testimonial_title", "category_id"},
+		render: func(row interface{}, san *HTMLSanitizer) error {
+			cs := row.(*model.CaseStudy)
+			if cs.ContentFormat == "" {
+				cs.ContentFormat = ContentFormatHTML
+			}
+			san.Case(cs)
+			return RenderCase(cs)
+		},
+		derived: []string{"background_html", "solution_html"},
+	},
+}
+
+// RevisionService keeps the edit history of articles, projects and cases.
+type RevisionService struct {
+	db        *gorm.DB
+	sanitizer *HTMLSanitizer
+}
+
+func NewRevisionService(db *gorm.DB, sanitizer *HTMLSanitizer) *RevisionService {
+	return &RevisionService{db: db, sanitizer: sanitizer}
+}
+
+// RevisionFieldChange is one field that differs between two revisions.
+type RevisionFieldChange struct {
+	Field string          `json:"field"`
+	From  json.RawMessage `json:"from"`
+	To    json.RawMessage `json:"to"`
+}
+
+// Record snapshots content typ/id as it is in tx, so call it inside the
+// transaction that saved the row. The row is locked until tx ends, which
+// keeps version numbers of concurrent saves apart.
+func (s *RevisionService) Record(tx *gorm.DB, typ, id, adminID string) (*model.ContentRevision, error) {
+	rt, ok := revisionTypes[typ]
+	if !ok {
+		return nil, kxlerrors.Validation("validation error: unknown content type")
+	}
+
+	row := rt.newRow()
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(row).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, kxlerrors.NotFound(contentTables[typ].notFound)
+		}
+		return nil, kxlerrors.Internal("db error")
+	}
+	data, err := snapshotFields(row, rt.fields)
+	if err != nil {
+		return nil, kxlerrors.Internal("snapshot error")
+	}
+
+	var last int
+	if err := tx.Model(&model.ContentRevision{}).
+		Where("content_type = ? AND content_id = ?", typ, id).
+		Select("COALESCE(MAX(version), 0)").Scan(&last).Error; err != nil {
+		return nil, kxlerrors.Internal("db error")
+	}
+	rev := &model.ContentRevision{
+		ContentType: typ,
+		ContentID:   id,
+		Version:     last + 1,
+		Data:        data,
+	}
+	if adminID != "" {
+		rev.AdminID = &adminID
+	}
+	if err := tx.Create(rev).Error; err != nil {
+		return nil, kxlerrors.Internal("db error")
+	}
+	return rev, nil
+}
+
+// List returns the revisions of typ/id, newest first, without their data.
+func (s *RevisionService) List(ctx context.Context, typ, id string, page, pageSize int64) ([]model.ContentRevision, int64, error) {
+	if s == nil || s.db == nil {
+		return nil, 0, kxlerrors.Internal("db not configured")
+	}
+	if _, ok := revisionTypes[typ]; !ok {
+		return nil, 0, kxlerrors.Validation("validation error: unknown content type")
+	}
+	q := s.db.WithContext(ctx).Model(&model.ContentRevision{}).
+		Where("content_type = ? AND content_id = ?", typ, id)
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, kxlerrors.Internal("db error")
+	}
+	var rows []model.ContentRevision
+	if err := q.Session(&gorm.Session{}).Omit("data").Order("version desc").
+		Offset(int((page - 1) * pageSize)).Limit(int(pageSize)).
+		Find(&rows).Error; err != nil {
+		return nil, 0, kxlerrors.Internal("db error")
+	}
+	return rows, total, nil
+}
+
+// Get returns one revision of typ/id.
+func (s *RevisionService) Get(ctx context.Context, typ, id string, version int) (*model.ContentRevision, error) {
+	if s == nil || s.db == nil {
+		return nil, kxlerrors.Internal("db not configured")
+	}
+	if _, ok := revisionTypes[typ]; !ok {
+		return nil, kxlerrors.Validation("validation error: unknown content type")
+	}
+	return s.get(s.db.WithContext(ctx), typ, id, version)
+}
+
+func (s *RevisionService) get(tx *gorm.DB, typ, id string, version int) (*model.ContentRevision, error) {
+	var rev model.ContentRevision
+	if err := tx.Where("content_type = ? AND content_id = ? AND version = ?", typ, id, version).
+		First(&rev).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, kxlerrors.NotFound("not found: revision not found")
+		}
+		return nil, kxlerrors.Internal("db error")
+	}
+	return &rev, nil
+}
+
+// Diff lists the fields that differ from revision from to revision to, in
+// field order.
+func (s *RevisionService) Diff(ctx context.Context, typ, id string, from, to int) ([]RevisionFieldChange, error) {
+	a, err := s.Get(ctx, typ, id, from)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.Get(ctx, typ, id, to)
+	if err != nil {
+		return nil, err
+	}
+	return diffFields(a.Data, b.Data, revisionTypes[typ].fields)
+}
+
+// Restore writes the fields of revision version back to typ/id and records
+// the result as a new revision; history is never rewritten.
+func (s *RevisionService) Restore(ctx context.Context, typ, id string, version int, adminID string) (*model.ContentRevision, error) {
+	if s == nil || s.db == nil {
+		return nil, kxlerrors.Internal("db not configured")
+	}
+	rt, ok := revisionTypes[typ]
+	if !ok {
+		return nil, kxlerrors.Validation("validation error: unknown content type")
+	}
+
+	var out *model.ContentRevision
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		rev, err := s.get(tx, typ, id, version)
+		if err != nil {
+			return err
+		}
+		row := rt.newRow()
+		if err := json.Unmarshal(rev.Data, row); err != nil {
+			return kxlerrors.Internal("snapshot error")
+		}
+		columns := append(append([]string{}, rt.fields...), rt.derived...)
+		if rt.render != nil {
+			if err := rt.render(row, s.sanitizer); err != nil {
+				return err
+			}
+		}
+		// updated_at is selected so GORM stamps it like any other save.
+		res := tx.Model(rt.newRow()).Where("id = ?", id).
+			Select(append(columns, "updated_at")).Updates(row)
+		if res.Error != nil {
+			return kxlerrors.Internal("db error")
+		}
+		if res.RowsAffected == 0 {
+			return kxlerrors.NotFound(contentTables[typ].notFound)
+		}
+		out, err = s.Record(tx, typ, id, adminID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// snapshotFields encodes the given JSON fields of row as an object.
+func snapshotFields(row interface{}, fields []string) (datatypes.JSON, error) {
+	raw, err := json.Marshal(row)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &all); err != nil {
+		return nil, err
+	}
+	out := make(map[string]json.RawMessage, len(fields))
+	for _, f := range fields {
+		if v, ok := all[f]; ok {
+			out[f] = v
+		}
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		return nil, err
+	}
+	return datatypes.JSON(data), nil
+}
+
+// diffFields compares two snapshots field by field. Values are compacted
+// first, so formatting differences (jsonb reflows nested JSON) don't count;
+// a missing field compares as null.
+func diffFields(from, to []byte, fields []string) ([]RevisionFieldChange, error) {
+	var a, b map[string]json.RawMessage
+	if err := json.Unmarshal(from, &a); err != nil {
+		return nil, kxlerrors.Internal("snapshot error")
+	}
+	if err := json.Unmarshal(to, &b); err != nil {
+		return nil, kxlerrors.Internal("snapshot error")
+	}
+	changes := []RevisionFieldChange{}
+	for _, f := range fields {
+		x, y := compactJSON(a[f]), compactJSON(b[f])
+		if !bytes.Equal(x, y) {
+			changes = append(changes, RevisionFieldChange{Field: f, From: x, To: y})
+		}
+	}
+	return changes, nil
+}
+
+func compactJSON(v json.RawMessage) json.RawMessage {
+	if len(v) == 0 {
+		return json.RawMessage("null")
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, v); err != nil {
+		return v
+	}
+	return buf.Bytes()
+}
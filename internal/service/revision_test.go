@@ -0,0 +1,45 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/linkyfish/kxl_backend_go/internal/model"
+)
+
+func TestRevisionSnapshotAndDiff(t *testing.T) {
+	cat := 2
+	fields := revisionTypes[ContentArticle].fields
+	a, err := snapshotFields(&model.Article{Title: "旧标题", Content: "正文", ViewCount: 9}, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snap map[string]interface{}
+	if err := json.Unmarshal(a, &snap); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := snap["view_count"]; ok {
+		t.Fatal("snapshot contains view_count")
+	}
+	if snap["title"] != "旧标题" || len(snap) != len(fields) {
+		t.Fatalf("snapshot = %v", snap)
+	}
+
+	b, _ := snapshotFields(&model.Article{Title: "新标题", Content: "正文", CategoryID: &cat}, fields)
+	changes, err := diffFields(a, b, fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0].Field != "title" || changes[1].Field != "category_id" {
+		t.Fatalf("changes = %+v", changes)
+	}
+	if string(changes[1].From) != "null" || string(changes[1].To) != "2" {
+		t.Fatalf("category change = %s -> %s", changes[1].From, changes[1].To)
+	}
+
+	// jsonb reformats nested JSON; that is not a change.
+	changes, _ = diffFields([]byte(`{"results":[{"a":1}]}`), []byte(`{"results": [{"a": 1}]}`), []string{"results"})
+	if len(changes) != 0 {
+		t.Fatalf("formatting counted as change: %+v", changes)
+	}
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	kxlcfg "github.com/linkyfish/kxl_backend_go/internal/config"
+	kxlerrors "github.com/linkyfish/kxl_backend_go/internal/errors"
+	"github.com/linkyfish/kxl_backend_go/internal/model"
+	"github.com/microcosm-cc/bluemonday"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"gorm.io/gorm"
+)
+
+// HTMLSanitizer strips admin-authored rich text down to the configured
+// allowlist. A nil *HTMLSanitizer uses the default allowlist.
+type HTMLSanitizer struct {
+	policy *bluemonday.Policy
+}
+
+// checkboxType is the only input type kept: GFM task lists need nothing else,
+// and other types would let content fake form controls.
+var checkboxType = regexp.MustCompile(`(?i)^checkbox$`)
+
+func NewHTMLSanitizer(cfg kxlcfg.SanitizeConfig) *HTMLSanitizer {
+	p := bluemonday.NewPolicy()
+	p.AllowElements(cfg.Tags...)
+	for _, attr := range cfg.Attributes {
+		if el, name, ok := strings.Cut(attr, "."); ok {
+			if el == "input" && name == "type" {
+				p.AllowAttrs(name).Matching(checkboxType).OnElements(el)
+				continue
+			}
+			p.AllowAttrs(name).OnElements(el)
+		} else {
+			p.AllowAttrs(attr).Globally()
+		}
+	}
+	p.AllowURLSchemes(cfg.URLSchemes...)
+	p.AllowRelativeURLs(true)
+	p.RequireParseableURLs(true)
+	p.AddTargetBlankToFullyQualifiedLinks(false)
+	p.RequireNoFollowOnLinks(false)
+	// Highlighted code blocks from the Markdown renderer use inline colours.
+	p.AllowStyles("color", "background-color", "font-weight", "font-style", "text-decoration", "display").
+		OnElements("pre", "span")
+	return &HTMLSanitizer{policy: p}
+}
+
+var defaultHTMLSanitizer = NewHTMLSanitizer(kxlcfg.SanitizeConfig{
+	Tags:       kxlcfg.DefaultSanitizeTags,
+	Attributes: kxlcfg.DefaultSanitizeAttributes,
+	URLSchemes: kxlcfg.DefaultSanitizeURLSchemes,
+})
+
+// Sanitize returns html with everything outside the allowlist removed.
+func (s *HTMLSanitizer) Sanitize(html string) string {
+	if s == nil {
+		s = defaultHTMLSanitizer
+	}
+	return dropNonCheckboxInputs(s.policy.Sanitize(html))
+}
+
+// dropNonCheckboxInputs removes input elements that are not checkboxes. The
+// policy already drops other type values, but an input without a type is a
+// text field.
+func dropNonCheckboxInputs(s string) string {
+	if !strings.Contains(s, "<input") {
+		return s
+	}
+	var b strings.Builder
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return b.String()
+		}
+		raw := string(z.Raw())
+		if tt == html.StartTagToken || tt == html.SelfClosingTagToken {
+			if tok := z.Token(); tok.DataAtom == atom.Input && !isCheckbox(tok) {
+				continue
+			}
+		}
+		b.WriteString(raw)
+	}
+}
+
+func isCheckbox(tok html.Token) bool {
+	for _, a := range tok.Attr {
+		if a.Key == "type" {
+			return checkboxType.MatchString(a.Val)
+		}
+	}
+	return false
+}
+
+// sameHTML reports whether a and b parse to the same markup, so that
+// serialization differences alone (<br> vs <br/>, &nbsp; vs U+00A0) are not
+// reported as changes.
+func sameHTML(a, b string) bool {
+	return a == b || renderFragment(a) == renderFragment(b)
+}
+
+func renderFragment(s string) string {
+	nodes, err := html.ParseFragment(strings.NewReader(s), &html.Node{Type: html.ElementNode, DataAtom: atom.Body, Data: "body"})
+	if err != nil {
+		return s
+	}
+	var b strings.Builder
+	for _, n := range nodes {
+		if err := html.Render(&b, n); err != nil {
+			return s
+		}
+	}
+	return b.String()
+}
+
+// SanitizeOpt is Sanitize for optional fields.
+func (s *HTMLSanitizer) SanitizeOpt(v *string) *string {
+	if v == nil {
+		return nil
+	}
+	out := s.Sanitize(*v)
+	return &out
+}
+
+// Article sanitizes the content of a before it is saved. Markdown content
+// is left alone: its renderer drops raw HTML.
+func (s *HTMLSanitizer) Article(a *model.Article) {
+	if a.ContentFormat != ContentFormatMarkdown {
+		a.Content = s.Sanitize(a.Content)
+	}
+}
+
+// Case sanitizes the background and solution of cs before it is saved.
+func (s *HTMLSanitizer) Case(cs *model.CaseStudy) {
+	if cs.ContentFormat != ContentFormatMarkdown {
+		cs.Background = s.Sanitize(cs.Background)
+		cs.Solution = s.Sanitize(cs.Solution)
+	}
+}
+
+// SanitizeChange is one stored field the sanitizer rewrote.
+type SanitizeChange struct {
+	Table       string
+	ID          string
+	Field       string
+	BytesBefore int
+	BytesAfter  int
+}
+
+// SanitizeService cleans rich text saved before sanitizing on write existed.
+type SanitizeService struct {
+	db        *gorm.DB
+	sanitizer *HTMLSanitizer
+}
+
+func NewSanitizeService(db *gorm.DB, sanitizer *HTMLSanitizer) *SanitizeService {
+	return &SanitizeService{db: db, sanitizer: sanitizer}
+}
+
+// sanitizeTargets are the stored HTML fields, with the condition that
+// selects rows holding HTML.
+var sanitizeTargets = []struct {
+	table, field, where string
+}{
+	{"articles", "content", "content_format = 'html'"},
+	{"cases", "background", "content_format = 'html'"},
+	{"cases", "solution", "content_format = 'html'"},
+	{"banners", "highlight", "highlight IS NOT NULL"},
+}
+
+// Existing sanitizes every stored HTML field and returns what changed; a
+// field that only serializes differently is left as it is. With
+// dryRun it only reports. Rows are updated without touching updated_at or
+// the revision history.
+func (s *SanitizeService) Existing(ctx context.Context, dryRun bool) ([]SanitizeChange, error) {
+	if s == nil || s.db == nil {
+		return nil, kxlerrors.Internal("db not configured")
+	}
+	changes := []SanitizeChange{}
+	for _, t := range sanitizeTargets {
+		var rows []struct {
+			ID    string
+			Value string
+		}
+		if err := s.db.WithContext(ctx).Table(t.table).
+			Select("id::text AS id, " + t.field + " AS value").Where(t.where).Order("id").
+			Find(&rows).Error; err != nil {
+			return changes, kxlerrors.Internal("db error")
+		}
+		for _, r := range rows {
+			clean := s.sanitizer.Sanitize(r.Value)
+			if sameHTML(clean, r.Value) {
+				continue
+			}
+			if !dryRun {
+				if err := s.db.WithContext(ctx).Table(t.table).Where("id::text = ?", r.ID).
+					UpdateColumn(t.field, clean).Error; err != nil {
+					return changes, kxlerrors.Internal("db error")
+				}
+			}
+			changes = append(changes, SanitizeChange{
+				Table: t.table, ID: r.ID, Field: t.field,
+				BytesBefore: len(r.Value), BytesAfter: len(clean),
+			})
+		}
+	}
+	return changes, nil
+}
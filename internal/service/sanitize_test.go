@@ -0,0 +1,88 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	kxlcfg "github.com/linkyfish/kxl_backend_go/internal/config"
+	"github.com/linkyfish/kxl_backend_go/internal/model"
+)
+
+func TestSanitizeDefaultAllowlist(t *testing.T) {
+	var s *HTMLSanitizer // nil uses the defaults
+	cases := []struct{ in, want string }{
+		{`<p>ok<script>alert(1)</script></p>`, `<p>ok</p>`},
+		{`<img src="/uploads/a.png" onerror="alert(1)" alt="a">`, `<img src="/uploads/a.png" alt="a">`},
+		{`<a href="javascript:alert(1)">x</a>`, `x`},
+		{`<a href="https://example.com" target="_blank">x</a>`, `<a href="https://example.com" target="_blank">x</a>`},
+		{`<iframe src="https://evil.example"></iframe><h2 class="t">标题</h2>`, `<h2 class="t">标题</h2>`},
+	}
+	for _, c := range cases {
+		if got := s.Sanitize(c.in); got != c.want {
+			t.Errorf("Sanitize(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestSanitizeConfiguredAllowlist(t *testing.T) {
+	s := NewHTMLSanitizer(kxlcfg.SanitizeConfig{Tags: []string{"p", "a"}, Attributes: []string{"a.href"}, URLSchemes: []string{"https"}})
+	in := `<p class="x"><a href="http://a.example" title="t">a</a><a href="https://b.example">b</a><img src="/i.png"></p>`
+	want := `<p>a<a href="https://b.example">b</a></p>`
+	if got := s.Sanitize(in); got != want {
+		t.Errorf("Sanitize = %q, want %q", got, want)
+	}
+}
+
+func TestSanitizeKeepsRenderedMarkdown(t *testing.T) {
+	out, err := RenderMarkdown("## Intro\n\n```go\nfunc main() {}\n```\n\n- [x] done\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clean := (*HTMLSanitizer)(nil).Sanitize(out)
+	for _, want := range []string{`<h2 id="intro">`, `class="heading-anchor"`, `<span style="color: #cf222e">func</span>`, `type="checkbox"`} {
+		if !strings.Contains(clean, want) {
+			t.Errorf("sanitized markdown missing %q:\n%s", want, clean)
+		}
+	}
+}
+
+func TestSanitizeArticleSkipsMarkdown(t *testing.T) {
+	var s *HTMLSanitizer
+	a := &model.Article{ContentFormat: ContentFormatMarkdown, Content: "a <b> c"}
+	s.Article(a)
+	if a.Content != "a <b> c" {
+		t.Errorf("markdown source changed: %q", a.Content)
+	}
+	a.ContentFormat = ContentFormatHTML
+	a.Content = `<p onclick="x()">hi</p>`
+	s.Article(a)
+	if a.Content != `<p>hi</p>` {
+		t.Errorf("html content = %q", a.Content)
+	}
+}
+
+func TestSanitizeRestrictsInputAndID(t *testing.T) {
+	var s *HTMLSanitizer
+	cases := []struct{ in, want string }{
+		{`<li><input type="checkbox" checked="" disabled=""> done</li>`, `<li><input type="checkbox" checked="" disabled=""> done</li>`},
+		{`<p><input type="password"><input type="submit" disabled><input checked>x</p>`, `<p>x</p>`},
+		{`<h2 id="intro">a</h2><p id="x">b</p><img id="y" src="/a.png">`, `<h2 id="intro">a</h2><p>b</p><img src="/a.png">`},
+	}
+	for _, c := range cases {
+		if got := s.Sanitize(c.in); got != c.want {
+			t.Errorf("Sanitize(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestSameHTMLIgnoresSerialization(t *testing.T) {
+	var s *HTMLSanitizer
+	in := `<p>a<br>b&nbsp;c &amp; 'd'</p>`
+	if !sameHTML(s.Sanitize(in), in) {
+		t.Errorf("clean markup reported as changed: %q", s.Sanitize(in))
+	}
+	in = `<p onclick="x()">a<br></p>`
+	if sameHTML(s.Sanitize(in), in) {
+		t.Error("removed attribute not reported")
+	}
+}
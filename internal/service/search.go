@@ -2,21 +2,44 @@ package service
 
 import (
 	"context"
+	"net/url"
 	"regexp"
 	"sort"
 	"strings"
+	"time"
 
+	"github.com/go-redis/redis/v8"
+	kxlcfg "github.com/linkyfish/kxl_backend_go/internal/config"
 	kxlerrors "github.com/linkyfish/kxl_backend_go/internal/errors"
-	"github.com/linkyfish/kxl_backend_go/internal/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type SearchService struct {
-	db *gorm.DB
+	db         *gorm.DB
+	redis      *redis.Client
+	mode       string
+	minClients int
+	entities   []SearchEntity
 }
 
-func NewSearchService(db *gorm.DB) *SearchService {
-	return &SearchService{db: db}
+// NewSearchService builds the site search. cfg.Search.Mode picks full-text
+// or trigram matching; a nil cfg means full-text. Redis, when set, caches
+// suggestions and tracks popular queries, which are suggested once
+// cfg.Search.SuggestMinClients visitors ran them. The DefaultSearchEntities
+// are registered; RegisterEntity adds more.
+func NewSearchService(cfg *kxlcfg.Config, db *gorm.DB, redisClient *redis.Client) *SearchService {
+	mode := kxlcfg.SearchModeFulltext
+	minClients := 3
+	if cfg != nil {
+		if cfg.Search.Mode == kxlcfg.SearchModeTrigram {
+			mode = kxlcfg.SearchModeTrigram
+		}
+		if cfg.Search.SuggestMinClients > 0 {
+			minClients = cfg.Search.SuggestMinClients
+		}
+	}
+	return &SearchService{db: db, redis: redisClient, mode: mode, minClients: minClients, entities: DefaultSearchEntities()}
 }
 
 type SearchResultItem struct {
@@ -26,214 +49,287 @@ type SearchResultItem struct {
 	Summary   string `json:"summary"`
 	Highlight string `json:"highlight"`
 	URL       string `json:"url"`
+	// ClickURL leads to URL through /search/click, which counts the click
+	// for search analytics.
+	ClickURL string `json:"click_url"`
+}
+
+// SearchOptions narrows a search. Type is a registered entity type
+// (anything else searches all of them); CategoryID and TagID keep results in
+// that category / carrying that tag, excluding the entities without
+// categories or tags. Cursor continues from a previous page. Client
+// identifies the visitor (their IP) when counting popular queries; it does
+// not change the results.
+type SearchOptions struct {
+	Type       string
+	CategoryID *int
+	TagID      *int
+	Cursor     string
+	Client     string
+}
+
+// SearchResults is one page of hits. Next is an opaque cursor for the
+// following page (pass it as SearchOptions.Cursor), empty on the last page.
+type SearchResults struct {
+	Items []SearchResultItem
+	Total int64
+	Next  string
 }
 
-func (s *SearchService) Search(ctx context.Context, q string, typ *string, page, pageSize int64) ([]SearchResultItem, int64, error) {
+// Search returns page of the published content matching q, most relevant
+// first. With opts.Cursor set, the page after that cursor is returned instead
+// and page is ignored.
+func (s *SearchService) Search(ctx context.Context, q string, opts SearchOptions, page, pageSize int64) (*SearchResults, error) {
 	if s == nil || s.db == nil {
-		return nil, 0, kxlerrors.Internal("db not configured")
+		return nil, kxlerrors.Internal("db not configured")
 	}
 	q = strings.TrimSpace(q)
 	if q == "" {
-		return nil, 0, kxlerrors.Validation("validation error: q is required")
+		return nil, kxlerrors.Validation("validation error: q is required")
 	}
 
-	highlight := makeHighlighter(q)
-
-	offset := (page - 1) * pageSize
-	fetchLimit := page * pageSize
-
-	if typ != nil {
-		switch *typ {
-		case "project":
-			items, total, err := s.searchProjects(ctx, q, pageSize, offset)
-			if err != nil {
-				return nil, 0, err
-			}
-			out := make([]SearchResultItem, 0, len(items))
-			for _, p := range items {
-				out = append(out, SearchResultItem{
-					ID:        p.ID,
-					Type:      "project",
-					Title:     p.Name,
-					Summary:   p.Description,
-					Highlight: highlight(p.Description),
-					URL:       "/projects/" + p.ID,
-				})
-			}
-			return out, total, nil
-		case "article":
-			items, total, err := s.searchArticles(ctx, q, pageSize, offset)
-			if err != nil {
-				return nil, 0, err
-			}
-			out := make([]SearchResultItem, 0, len(items))
-			for _, a := range items {
-				out = append(out, SearchResultItem{
-					ID:        a.ID,
-					Type:      "article",
-					Title:     a.Title,
-					Summary:   a.Summary,
-					Highlight: highlight(a.Summary),
-					URL:       "/articles/" + a.ID,
-				})
-			}
-			return out, total, nil
-		case "case":
-			items, total, err := s.searchCases(ctx, q, pageSize, offset)
-			if err != nil {
-				return nil, 0, err
-			}
-			out := make([]SearchResultItem, 0, len(items))
-			for _, c := range items {
-				out = append(out, SearchResultItem{
-					ID:        c.ID,
-					Type:      "case",
-					Title:     c.ClientName,
-					Summary:   c.Summary,
-					Highlight: highlight(c.Summary),
-					URL:       "/cases/" + c.ID,
-				})
-			}
-			return out, total, nil
-		}
-	}
-
-	projects, t1, err := s.searchProjects(ctx, q, fetchLimit, 0)
+	res, err := s.search(ctx, q, opts, page, pageSize)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
-	articles, t2, err := s.searchArticles(ctx, q, fetchLimit, 0)
-	if err != nil {
-		return nil, 0, err
+	if page == 1 && opts.Cursor == "" && res.Total > 0 {
+		// Only first pages that found something feed the suggestions.
+		s.recordQuery(ctx, q, opts.Client)
 	}
-	cases, t3, err := s.searchCases(ctx, q, fetchLimit, 0)
-	if err != nil {
-		return nil, 0, err
-	}
-	total := t1 + t2 + t3
-
-	type hit struct {
-		ts   int64
-		item SearchResultItem
-	}
-	hits := make([]hit, 0, len(projects)+len(articles)+len(cases))
-	for _, p := range projects {
-		hits = append(hits, hit{
-			ts: p.CreatedAt.Unix(),
-			item: SearchResultItem{
-				ID:        p.ID,
-				Type:      "project",
-				Title:     p.Name,
-				Summary:   p.Description,
-				Highlight: highlight(p.Description),
-				URL:       "/projects/" + p.ID,
-			},
-		})
+	for i := range res.Items {
+		res.Items[i].ClickURL = SearchClickURL(q, res.Items[i].Type, res.Items[i].ID)
 	}
-	for _, a := range articles {
-		hits = append(hits, hit{
-			ts: a.CreatedAt.Unix(),
-			item: SearchResultItem{
-				ID:        a.ID,
-				Type:      "article",
-				Title:     a.Title,
-				Summary:   a.Summary,
-				Highlight: highlight(a.Summary),
-				URL:       "/articles/" + a.ID,
-			},
-		})
+	return res, nil
+}
+
+// ResultURL returns the page of search result typ/id, or false for an
+// unknown type, an id that cannot be one, or a row that is gone or hidden.
+func (s *SearchService) ResultURL(ctx context.Context, typ, id string) (string, bool) {
+	if s == nil || s.db == nil || id == "" || len(id) > maxLoggedResultIDLen {
+		return "", false
 	}
-	for _, c := range cases {
-		hits = append(hits, hit{
-			ts: c.CreatedAt.Unix(),
-			item: SearchResultItem{
-				ID:        c.ID,
-				Type:      "case",
-				Title:     c.ClientName,
-				Summary:   c.Summary,
-				Highlight: highlight(c.Summary),
-				URL:       "/cases/" + c.ID,
-			},
-		})
+	e, ok := s.entity(typ)
+	if !ok {
+		return "", false
 	}
-
-	sort.Slice(hits, func(i, j int) bool { return hits[i].ts > hits[j].ts })
-
-	start := int(offset)
-	if start > len(hits) {
-		start = len(hits)
+	ref := "''"
+	if e.Ref != "" {
+		ref = "CAST(coalesce(" + e.Ref + ", '') AS text)"
 	}
-	end := start + int(pageSize)
-	if end > len(hits) {
-		end = len(hits)
+	tx := s.db.WithContext(ctx).Table(e.Table).Select(ref+" AS ref").
+		Where("CAST("+e.Table+".id AS text) = ?", id)
+	if e.Visible != "" {
+		tx = tx.Where(e.Visible)
 	}
-
-	out := make([]SearchResultItem, 0, end-start)
-	for _, h := range hits[start:end] {
-		out = append(out, h.item)
+	var refs []string
+	if err := tx.Limit(1).Scan(&refs).Error; err != nil || len(refs) == 0 {
+		return "", false
 	}
-	return out, total, nil
+	return e.URL(id, refs[0]), true
 }
 
-func (s *SearchService) Suggestions(ctx context.Context) ([]string, error) {
-	// Keep behavior identical to PHP backend for now.
-	return []string{}, nil
+// SearchClickURL is the tracked link to result typ/id found by query q.
+func SearchClickURL(q, typ, id string) string {
+	v := url.Values{}
+	v.Set("q", q)
+	v.Set("type", typ)
+	v.Set("id", id)
+	return "/search/click?" + v.Encode()
 }
 
-func (s *SearchService) searchProjects(ctx context.Context, q string, limit, offset int64) ([]model.Project, int64, error) {
-	pattern := "%" + q + "%"
-	base := s.db.WithContext(ctx).Model(&model.Project{}).
-		Where("status = ?", 1).
-		Where("(name ILIKE ? OR description ILIKE ?)", pattern, pattern)
+// searchHit is one row of the union over all searchable tables.
+type searchHit struct {
+	Type      string    `gorm:"column:type"`
+	ID        string    `gorm:"column:id"`
+	Title     string    `gorm:"column:title"`
+	Summary   string    `gorm:"column:summary"`
+	Ref       string    `gorm:"column:ref"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+	Rank      float64   `gorm:"column:rank"`
+}
+
+// hitsOrder is the total order of search hits: most relevant first, then
+// newest, then type and id so ties (and thus cursors) are deterministic.
+const hitsOrder = "rank DESC, created_at DESC, type, id"
+
+// search runs a single UNION ALL over the matching tables, so counting,
+// ordering and paging across types all happen in the database.
+func (s *SearchService) search(ctx context.Context, q string, opts SearchOptions, page, pageSize int64) (*SearchResults, error) {
+	var after *searchCursor
+	if opts.Cursor != "" {
+		c, err := decodeSearchCursor(opts.Cursor, searchFingerprint(q, opts, s.mode))
+		if err != nil {
+			return nil, err
+		}
+		after = c
+	}
+
+	entities := s.entitiesFor(opts.Type)
+	branches := make([]interface{}, 0, len(entities))
+	placeholders := make([]string, 0, len(entities))
+	for _, e := range entities {
+		tx, rank, ok := s.query(ctx, e, q, opts)
+		if !ok {
+			continue
+		}
+		branches = append(branches, tx.Select(
+			"CAST(? AS text) AS type, CAST("+e.Table+".id AS text) AS id, coalesce("+e.Title+", '') AS title, "+
+				"coalesce("+e.summary()+", '') AS summary, CAST(coalesce("+e.ref()+", '') AS text) AS ref, "+
+				e.Table+".created_at AS created_at, CAST(? AS double precision) AS rank", e.Type, rank))
+		placeholders = append(placeholders, "(?)")
+	}
+	if len(branches) == 0 {
+		return &SearchResults{Items: []SearchResultItem{}}, nil
+	}
+	hits := func() *gorm.DB {
+		return s.db.WithContext(ctx).Table("("+strings.Join(placeholders, " UNION ALL ")+") AS hits", branches...)
+	}
 
 	var total int64
-	if err := base.Count(&total).Error; err != nil {
-		return nil, 0, kxlerrors.Internal("db error")
+	if err := hits().Count(&total).Error; err != nil {
+		return nil, kxlerrors.Internal("db error")
+	}
+
+	tx := hits()
+	if after != nil {
+		tx = tx.Where("((rank < ?) OR (rank = ? AND created_at < ?) OR (rank = ? AND created_at = ? AND (type, id) > (?, ?)))",
+			after.Rank, after.Rank, after.CreatedAt, after.Rank, after.CreatedAt, after.Type, after.ID)
+	} else {
+		tx = tx.Offset(int((page - 1) * pageSize))
 	}
-	var rows []model.Project
-	if err := base.Order("created_at desc").Limit(int(limit)).Offset(int(offset)).Find(&rows).Error; err != nil {
-		return nil, 0, kxlerrors.Internal("db error")
+	// One extra row tells whether a next page exists.
+	var rows []searchHit
+	if err := tx.Order(hitsOrder).Limit(int(pageSize) + 1).Find(&rows).Error; err != nil {
+		return nil, kxlerrors.Internal("db error")
 	}
-	return rows, total, nil
-}
 
-func (s *SearchService) searchArticles(ctx context.Context, q string, limit, offset int64) ([]model.Article, int64, error) {
-	pattern := "%" + q + "%"
-	base := s.db.WithContext(ctx).Model(&model.Article{}).
-		Where("status = ?", 1).
-		Where("(title ILIKE ? OR summary ILIKE ? OR content ILIKE ?)", pattern, pattern, pattern)
+	res := &SearchResults{Total: total}
+	if int64(len(rows)) > pageSize {
+		rows = rows[:pageSize]
+		last := rows[len(rows)-1]
+		res.Next = encodeSearchCursor(searchCursor{
+			Rank:        last.Rank,
+			CreatedAt:   last.CreatedAt,
+			Type:        last.Type,
+			ID:          last.ID,
+			Fingerprint: searchFingerprint(q, opts, s.mode),
+		})
+	}
 
-	var total int64
-	if err := base.Count(&total).Error; err != nil {
-		return nil, 0, kxlerrors.Internal("db error")
+	highlight := makeHighlighter(q)
+	res.Items = make([]SearchResultItem, 0, len(rows))
+	for _, h := range rows {
+		var link string
+		if e, ok := s.entity(h.Type); ok {
+			link = e.URL(h.ID, h.Ref)
+		}
+		res.Items = append(res.Items, SearchResultItem{
+			ID:        h.ID,
+			Type:      h.Type,
+			Title:     h.Title,
+			Summary:   h.Summary,
+			Highlight: highlight(h.Summary),
+			URL:       link,
+		})
+	}
+	return res, nil
+}
+
+// query returns the visible rows of e matching q and the category/tag
+// filters of opts, with the expression scoring them. ok is false when the
+// filters rule out the entity altogether.
+func (s *SearchService) query(ctx context.Context, e SearchEntity, q string, opts SearchOptions) (tx *gorm.DB, rank clause.Expr, ok bool) {
+	if (opts.TagID != nil && e.TagTable == "") || (opts.CategoryID != nil && e.CategoryColumn == "") {
+		return nil, clause.Expr{}, false
 	}
-	var rows []model.Article
-	if err := base.Order("created_at desc").Limit(int(limit)).Offset(int(offset)).Find(&rows).Error; err != nil {
-		return nil, 0, kxlerrors.Internal("db error")
+	tx = s.db.WithContext(ctx).Table(e.Table)
+	if e.Visible != "" {
+		tx = tx.Where(e.Visible)
 	}
-	return rows, total, nil
+	if opts.CategoryID != nil {
+		tx = tx.Where(e.Table+"."+e.CategoryColumn+" = ?", *opts.CategoryID)
+	}
+	if opts.TagID != nil {
+		tx = tx.Where("EXISTS (SELECT 1 FROM "+e.TagTable+" WHERE "+e.TagTable+"."+e.TagKey+" = "+e.Table+".id AND "+e.TagTable+".tag_id = ?)", *opts.TagID)
+	}
+	tx, rank = s.match(tx, e, q)
+	return tx, rank, true
 }
 
-func (s *SearchService) searchCases(ctx context.Context, q string, limit, offset int64) ([]model.CaseStudy, int64, error) {
-	pattern := "%" + q + "%"
-	base := s.db.WithContext(ctx).Model(&model.CaseStudy{}).
-		Where("status = ?", 1).
-		Where("(client_name ILIKE ? OR summary ILIKE ? OR background ILIKE ? OR solution ILIKE ?)", pattern, pattern, pattern, pattern)
+// ftsQuery parses user input the way search engines do (quoted phrases, "or",
+// -exclusions) without ever failing on syntax.
+const ftsQuery = "websearch_to_tsquery('simple', ?)"
 
-	var total int64
-	if err := base.Count(&total).Error; err != nil {
-		return nil, 0, kxlerrors.Internal("db error")
+// ftsWeights weighs a tsvector ranked by ts_rank (see migration 000006); the
+// array is {D, C, B, A}, so a title (A) match counts ten times a body (C/D)
+// match.
+const ftsWeights = "'{0.1, 0.2, 0.4, 1.0}'"
+
+// maxSearchTerms bounds the SQL generated for long trigram queries.
+const maxSearchTerms = 8
+
+// match restricts tx to rows of e matching q and returns the expression
+// that scores them, higher being more relevant.
+func (s *SearchService) match(tx *gorm.DB, e SearchEntity, q string) (*gorm.DB, clause.Expr) {
+	if s.mode != kxlcfg.SearchModeTrigram {
+		vector := e.vector()
+		return tx.Where(vector+" @@ "+ftsQuery, q), gorm.Expr("ts_rank("+ftsWeights+", "+vector+", "+ftsQuery+")", q)
+	}
+
+	// Trigram mode: the parser behind full-text search cannot split Chinese
+	// into words, so every whitespace-separated term has to occur somewhere
+	// in the document as a substring, or be close to a word of it by trigram
+	// similarity (catching typos in longer terms). "ERP 系统" thus needs both
+	// "ERP" and "系统". Scoring adds, per term, 1.0 for a title hit, 0.4 for
+	// a summary hit and 0.2 otherwise, plus how similar the whole query is
+	// to the title; this ranks title matches first regardless of language.
+	doc := e.document()
+	terms := searchTerms(q)
+	scores := make([]string, 0, len(terms)+1)
+	args := make([]interface{}, 0, 2*len(terms)+1)
+	for _, term := range terms {
+		pattern := "%" + escapeLike(term) + "%"
+		tx = tx.Where(doc+" ILIKE ? OR ? <% "+doc, pattern, term)
+		scores = append(scores, "CASE WHEN coalesce("+e.Title+", '') ILIKE ? THEN 1.0 WHEN coalesce("+e.summary()+", '') ILIKE ? THEN 0.4 ELSE 0.2 END")
+		args = append(args, pattern, pattern)
 	}
-	var rows []model.CaseStudy
-	if err := base.Order("created_at desc").Limit(int(limit)).Offset(int(offset)).Find(&rows).Error; err != nil {
-		return nil, 0, kxlerrors.Internal("db error")
+	scores = append(scores, "word_similarity(?, coalesce("+e.Title+", ''))")
+	args = append(args, strings.ToLower(q))
+	return tx, gorm.Expr(strings.Join(scores, " + "), args...)
+}
+
+// searchTerms splits a query on whitespace, dropping duplicates.
+func searchTerms(q string) []string {
+	seen := map[string]bool{}
+	var terms []string
+	for _, f := range strings.Fields(strings.ToLower(q)) {
+		if seen[f] {
+			continue
+		}
+		seen[f] = true
+		terms = append(terms, f)
+		if len(terms) == maxSearchTerms {
+			break
+		}
 	}
-	return rows, total, nil
+	return terms
 }
 
+// escapeLike quotes the LIKE wildcards in s (backslash is the default escape).
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// makeHighlighter wraps every occurrence of the query's terms in <em>.
 func makeHighlighter(q string) func(string) string {
-	escaped := regexp.QuoteMeta(q)
-	re := regexp.MustCompile("(?i)" + escaped)
+	terms := searchTerms(q)
+	// Longest first so "ERP系统" wins over "ERP" when both are terms.
+	sort.SliceStable(terms, func(i, j int) bool { return len(terms[i]) > len(terms[j]) })
+	quoted := make([]string, 0, len(terms))
+	for _, t := range terms {
+		quoted = append(quoted, regexp.QuoteMeta(t))
+	}
+	re := regexp.MustCompile("(?i)" + strings.Join(quoted, "|"))
 	return func(text string) string {
 		if text == "" {
 			return ""
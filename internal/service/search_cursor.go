@@ -0,0 +1,56 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	kxlerrors "github.com/linkyfish/kxl_backend_go/internal/errors"
+)
+
+// searchCursor is the position of the last hit of a page in hitsOrder.
+// Fingerprint ties it to the query and filters that produced it, so a cursor
+// pasted into a different search is rejected instead of skipping results.
+type searchCursor struct {
+	Rank        float64   `json:"r"`
+	CreatedAt   time.Time `json:"c"`
+	Type        string    `json:"t"`
+	ID          string    `json:"i"`
+	Fingerprint string    `json:"f"`
+}
+
+func encodeSearchCursor(c searchCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeSearchCursor(token, fingerprint string) (*searchCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, kxlerrors.Validation("validation error: invalid cursor")
+	}
+	var c searchCursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.Type == "" || c.ID == "" {
+		return nil, kxlerrors.Validation("validation error: invalid cursor")
+	}
+	if c.Fingerprint != fingerprint {
+		return nil, kxlerrors.Validation("validation error: cursor does not match this search")
+	}
+	return &c, nil
+}
+
+func searchFingerprint(q string, opts SearchOptions, mode string) string {
+	h := sha256.New()
+	h.Write([]byte(mode + "\x00" + normalizeQuery(q) + "\x00" + opts.Type + "\x00"))
+	if opts.CategoryID != nil {
+		h.Write([]byte("c" + strconv.Itoa(*opts.CategoryID)))
+	}
+	h.Write([]byte{0})
+	if opts.TagID != nil {
+		h.Write([]byte("t" + strconv.Itoa(*opts.TagID)))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"net/url"
+	"strings"
+)
+
+// SearchEntity makes one table searchable. Title, Summary and Body are SQL
+// expressions over the table's columns; matches in Title rank highest, then
+// Summary, then Body. The search, facet and pagination code only works
+// through these descriptions, so adding a type means registering an entity.
+type SearchEntity struct {
+	// Type is the result type and the value of the `type` filter.
+	Type string
+	// Label names the type in the SSR search page.
+	Label string
+	Table string
+
+	Title   string
+	Summary string
+	Body    []string
+
+	// Visible restricts results to rows the public site shows (e.g.
+	// "projects.status = 1"); empty means every row.
+	Visible string
+	// Vector is a stored tsvector column used in full-text mode; without it
+	// the vector is computed from Title/Summary/Body on the fly, which is fine
+	// for small tables.
+	Vector string
+	// CategoryColumn and TagTable/TagKey enable the category and tag filters
+	// and facets; entities without them drop out when such a filter is set.
+	CategoryColumn string
+	TagTable       string
+	TagKey         string
+
+	// Ref is an optional SQL expression handed to URL next to the id, for
+	// entities whose link is stored in the row.
+	Ref string
+	URL func(id, ref string) string
+}
+
+// DefaultSearchEntities are registered by NewSearchService.
+func DefaultSearchEntities() []SearchEntity {
+	return []SearchEntity{
+		{
+			Type: "project", Label: "产品", Table: "projects",
+			Title: "name", Summary: "description",
+			Visible: "projects.status = 1 AND projects.published_at <= now()", Vector: "search_vector",
+			CategoryColumn: "category_id", TagTable: "project_tags", TagKey: "project_id",
+			Ref: "slug", URL: slugURL("/projects/"),
+		},
+		{
+			Type: "article", Label: "文章", Table: "articles",
+			Title: "title", Summary: "summary", Body: []string{"content"},
+			Visible: "articles.status = 1 AND articles.published_at <= now()", Vector: "search_vector",
+			CategoryColumn: "category_id", TagTable: "article_tags", TagKey: "article_id",
+			Ref: "slug", URL: slugURL("/articles/"),
+		},
+		{
+			Type: "case", Label: "案例", Table: "cases",
+			Title: "client_name", Summary: "summary", Body: []string{"background", "solution"},
+			Visible: "cases.status = 1 AND cases.published_at <= now()", Vector: "search_vector",
+			CategoryColumn: "category_id", Ref: "slug", URL: slugURL("/cases/"),
+		},
+		{
+			Type: "solution", Label: "解决方案", Table: "solutions",
+			Title: "name", Summary: "description",
+			Visible: "solutions.is_visible = true",
+			Ref:     "link",
+			URL: func(_, link string) string {
+				if link != "" {
+					return link
+				}
+				return "/#solutions"
+			},
+		},
+		{
+			Type: "testimonial", Label: "客户评价", Table: "testimonials",
+			Title: "name", Summary: "content", Body: []string{"company", "title"},
+			Visible: "testimonials.is_visible = true",
+			URL:     func(_, _ string) string { return "/#testimonials" },
+		},
+		{
+			Type: "team_member", Label: "团队成员", Table: "team_members",
+			Title: "name", Summary: "title", Body: []string{"bio"},
+			URL: func(_, _ string) string { return "/about#team" },
+		},
+		{
+			Type: "milestone", Label: "发展历程", Table: "milestones",
+			Title: "CAST(year AS text)", Summary: "content",
+			URL: func(_, _ string) string { return "/about#milestones" },
+		},
+		{
+			Type: "tag", Label: "标签", Table: "tags",
+			Title: "name",
+			Ref:   "name",
+			URL:   func(_, name string) string { return "/search?q=" + url.QueryEscape(name) },
+		},
+	}
+}
+
+// slugURL links to base+slug, or base+id for content without a slug.
+func slugURL(base string) func(id, slug string) string {
+	return func(id, slug string) string {
+		if slug == "" {
+			slug = id
+		}
+		return base + url.PathEscape(slug)
+	}
+}
+
+// RegisterEntity adds a searchable type, replacing any entity of the same
+// type. Not safe to call while searches are running.
+func (s *SearchService) RegisterEntity(e SearchEntity) {
+	for i := range s.entities {
+		if s.entities[i].Type == e.Type {
+			s.entities[i] = e
+			return
+		}
+	}
+	s.entities = append(s.entities, e)
+}
+
+// Entities returns the registered searchable types in display order.
+func (s *SearchService) Entities() []SearchEntity {
+	return append([]SearchEntity(nil), s.entities...)
+}
+
+// FilterType returns typ when it is a registered entity type and "" for
+// anything else, i.e. the type filter a search with typ actually applies.
+func (s *SearchService) FilterType(typ string) string {
+	if _, ok := s.entity(typ); ok {
+		return typ
+	}
+	return ""
+}
+
+// entitiesFor returns the entities a type filter leaves in play; an
+// unknown or empty type means all of them.
+func (s *SearchService) entitiesFor(typ string) []SearchEntity {
+	for _, e := range s.entities {
+		if e.Type == typ {
+			return []SearchEntity{e}
+		}
+	}
+	return s.entities
+}
+
+func (s *SearchService) entity(typ string) (SearchEntity, bool) {
+	for _, e := range s.entities {
+		if e.Type == typ {
+			return e, true
+		}
+	}
+	return SearchEntity{}, false
+}
+
+func (e SearchEntity) summary() string {
+	if e.Summary == "" {
+		return "''"
+	}
+	return e.Summary
+}
+
+func (e SearchEntity) ref() string {
+	if e.Ref == "" {
+		return "''"
+	}
+	return e.Ref
+}
+
+// document is the concatenated searchable text. For the entities indexed by
+// migration 000007 it must match the indexed expression.
+func (e SearchEntity) document() string {
+	cols := append([]string{e.Title, e.summary()}, e.Body...)
+	parts := make([]string, 0, len(cols))
+	for _, c := range cols {
+		parts = append(parts, "coalesce("+c+", '')")
+	}
+	return "(" + strings.Join(parts, " || ' ' || ") + ")"
+}
+
+// vector is the weighted tsvector of the entity: title A, summary B, body C,
+// like the stored search_vector columns of migration 000006.
+func (e SearchEntity) vector() string {
+	if e.Vector != "" {
+		return e.Vector
+	}
+	v := "setweight(to_tsvector('simple', coalesce(" + e.Title + ", '')), 'A') || " +
+		"setweight(to_tsvector('simple', coalesce(" + e.summary() + ", '')), 'B')"
+	if len(e.Body) > 0 {
+		body := make([]string, 0, len(e.Body))
+		for _, b := range e.Body {
+			body = append(body, "coalesce("+b+", '')")
+		}
+		v += " || setweight(to_tsvector('simple', " + strings.Join(body, " || ' ' || ") + "), 'C')"
+	}
+	return "(" + v + ")"
+}
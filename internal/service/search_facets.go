@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	kxlerrors "github.com/linkyfish/kxl_backend_go/internal/errors"
+	"github.com/linkyfish/kxl_backend_go/internal/model"
+)
+
+// maxTagFacets caps the tag facet to the most frequent tags.
+const maxTagFacets = 20
+
+type SearchTypeFacet struct {
+	Type  string `json:"type"`
+	Label string `json:"label"`
+	Count int64  `json:"count"`
+}
+
+type SearchTermFacet struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// SearchFacets counts the matches of a query per type, category and tag.
+// Each facet applies every filter except its own, so its entries are the
+// choices available from the current results (e.g. the type counts stay
+// visible while a type is selected).
+type SearchFacets struct {
+	Types      []SearchTypeFacet `json:"type"`
+	Categories []SearchTermFacet `json:"category"`
+	Tags       []SearchTermFacet `json:"tag"`
+}
+
+func (s *SearchService) Facets(ctx context.Context, q string, opts SearchOptions) (*SearchFacets, error) {
+	if s == nil || s.db == nil {
+		return nil, kxlerrors.Internal("db not configured")
+	}
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return nil, kxlerrors.Validation("validation error: q is required")
+	}
+
+	out := &SearchFacets{Types: []SearchTypeFacet{}, Categories: []SearchTermFacet{}, Tags: []SearchTermFacet{}}
+
+	typeOpts := opts
+	typeOpts.Type = ""
+	for _, e := range s.entities {
+		tx, _, ok := s.query(ctx, e, q, typeOpts)
+		var n int64
+		if ok {
+			if err := tx.Count(&n).Error; err != nil {
+				return nil, kxlerrors.Internal("db error")
+			}
+		}
+		out.Types = append(out.Types, SearchTypeFacet{Type: e.Type, Label: e.Label, Count: n})
+	}
+
+	catOpts := opts
+	catOpts.CategoryID = nil
+	catCounts := map[int]int64{}
+	for _, e := range s.entitiesFor(opts.Type) {
+		if e.CategoryColumn == "" {
+			continue
+		}
+		tx, _, ok := s.query(ctx, e, q, catOpts)
+		if !ok {
+			continue
+		}
+		var rows []struct {
+			ID    int
+			Count int64
+		}
+		column := e.Table + "." + e.CategoryColumn
+		if err := tx.Select(column + " AS id, COUNT(*) AS count").
+			Where(column + " IS NOT NULL").
+			Group(column).Scan(&rows).Error; err != nil {
+			return nil, kxlerrors.Internal("db error")
+		}
+		for _, r := range rows {
+			catCounts[r.ID] += r.Count
+		}
+	}
+	if len(catCounts) > 0 {
+		var cats []model.Category
+		if err := s.db.WithContext(ctx).Where("id IN ?", mapKeys(catCounts)).Find(&cats).Error; err != nil {
+			return nil, kxlerrors.Internal("db error")
+		}
+		for _, c := range cats {
+			out.Categories = append(out.Categories, SearchTermFacet{ID: c.ID, Name: c.Name, Count: catCounts[c.ID]})
+		}
+		sortTermFacets(out.Categories)
+	}
+
+	tagOpts := opts
+	tagOpts.TagID = nil
+	tagCounts := map[int]int64{}
+	for _, e := range s.entitiesFor(opts.Type) {
+		if e.TagTable == "" {
+			continue
+		}
+		tx, _, ok := s.query(ctx, e, q, tagOpts)
+		if !ok {
+			continue
+		}
+		var rows []struct {
+			ID    int
+			Count int64
+		}
+		if err := tx.Joins("JOIN " + e.TagTable + " ON " + e.TagTable + "." + e.TagKey + " = " + e.Table + ".id").
+			Select(e.TagTable + ".tag_id AS id, COUNT(*) AS count").
+			Group(e.TagTable + ".tag_id").Scan(&rows).Error; err != nil {
+			return nil, kxlerrors.Internal("db error")
+		}
+		for _, r := range rows {
+			tagCounts[r.ID] += r.Count
+		}
+	}
+	if len(tagCounts) > 0 {
+		var tags []model.Tag
+		if err := s.db.WithContext(ctx).Where("id IN ?", mapKeys(tagCounts)).Find(&tags).Error; err != nil {
+			return nil, kxlerrors.Internal("db error")
+		}
+		for _, t := range tags {
+			out.Tags = append(out.Tags, SearchTermFacet{ID: t.ID, Name: t.Name, Count: tagCounts[t.ID]})
+		}
+		sortTermFacets(out.Tags)
+		if len(out.Tags) > maxTagFacets {
+			out.Tags = out.Tags[:maxTagFacets]
+		}
+	}
+	return out, nil
+}
+
+func sortTermFacets(items []SearchTermFacet) {
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Count != items[j].Count {
+			return items[i].Count > items[j].Count
+		}
+		return items[i].ID < items[j].ID
+	})
+}
+
+func mapKeys(m map[int]int64) []int {
+	out := make([]int, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
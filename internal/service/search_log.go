@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	kxlcfg "github.com/linkyfish/kxl_backend_go/internal/config"
+	kxlerrors "github.com/linkyfish/kxl_backend_go/internal/errors"
+	"github.com/linkyfish/kxl_backend_go/internal/model"
+	"gorm.io/gorm"
+)
+
+const (
+	searchLogBuffer        = 4096
+	searchLogBatchSize     = 200
+	searchLogFlushInterval = 5 * time.Second
+	maxLoggedQueryLen      = 200
+	maxLoggedTypeLen       = 20
+	maxLoggedResultIDLen   = 64
+)
+
+// SearchLogService records site searches and result clicks. Records are
+// buffered in memory and inserted in batches by a background goroutine, so
+// logging never slows a search down; when the buffer is full (the database
+// is down or too slow) new records are dropped.
+type SearchLogService struct {
+	db      *gorm.DB
+	ipSalt  []byte
+	mu      sync.RWMutex
+	closed  bool
+	entries chan searchLogEntry
+	done    chan struct{}
+}
+
+type searchLogEntry struct {
+	query *model.SearchQueryLog
+	click *model.SearchClick
+}
+
+// NewSearchLogService starts the background writer; call Close on shutdown
+// to flush what is still buffered. IPs are hashed with the signing secret.
+func NewSearchLogService(cfg *kxlcfg.Config, db *gorm.DB) *SearchLogService {
+	s := &SearchLogService{
+		db:      db,
+		entries: make(chan searchLogEntry, searchLogBuffer),
+		done:    make(chan struct{}),
+	}
+	if cfg != nil {
+		s.ipSalt = []byte(cfg.Security.SigningSecret)
+	}
+	go s.run()
+	return s
+}
+
+// RecordQuery logs one search. q is normalized before it is stored;
+// searchType should be a registered entity type or "" (see
+// SearchService.FilterType).
+func (s *SearchLogService) RecordQuery(q, searchType string, results int64, ip string) {
+	if s == nil {
+		return
+	}
+	q = truncateRunes(normalizeQuery(q), maxLoggedQueryLen)
+	if q == "" {
+		return
+	}
+	// A value too long for its column would fail the whole batch insert.
+	searchType = truncateRunes(searchType, maxLoggedTypeLen)
+	s.enqueue(searchLogEntry{query: &model.SearchQueryLog{
+		Query:       q,
+		SearchType:  searchType,
+		ResultCount: int(results),
+		IPHash:      s.hashIP(ip),
+		CreatedAt:   time.Now().UTC(),
+	}})
+}
+
+// RecordClick logs a visitor opening result typ/id from the results for q.
+// Clicks whose type or id do not fit their columns are dropped.
+func (s *SearchLogService) RecordClick(q, typ, id, url, ip string) {
+	if s == nil {
+		return
+	}
+	q = truncateRunes(normalizeQuery(q), maxLoggedQueryLen)
+	if q == "" || utf8.RuneCountInString(typ) > maxLoggedTypeLen || utf8.RuneCountInString(id) > maxLoggedResultIDLen {
+		return
+	}
+	s.enqueue(searchLogEntry{click: &model.SearchClick{
+		Query:      q,
+		ResultType: typ,
+		ResultID:   id,
+		URL:        url,
+		IPHash:     s.hashIP(ip),
+		CreatedAt:  time.Now().UTC(),
+	}})
+}
+
+// Close stops the writer after flushing the buffer, or when ctx expires.
+func (s *SearchLogService) Close(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.entries)
+	}
+	s.mu.Unlock()
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *SearchLogService) enqueue(e searchLogEntry) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.entries <- e:
+	default:
+	}
+}
+
+func (s *SearchLogService) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(searchLogFlushInterval)
+	defer ticker.Stop()
+
+	var queries []model.SearchQueryLog
+	var clicks []model.SearchClick
+	flush := func() {
+		if s.db == nil {
+			queries, clicks = queries[:0], clicks[:0]
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if len(queries) > 0 {
+			if err := s.db.WithContext(ctx).CreateInBatches(queries, searchLogBatchSize).Error; err != nil {
+				log.Printf("search log: dropped %d queries: %v", len(queries), err)
+			}
+			queries = queries[:0]
+		}
+		if len(clicks) > 0 {
+			if err := s.db.WithContext(ctx).CreateInBatches(clicks, searchLogBatchSize).Error; err != nil {
+				log.Printf("search log: dropped %d clicks: %v", len(clicks), err)
+			}
+			clicks = clicks[:0]
+		}
+	}
+
+	for {
+		select {
+		case e, ok := <-s.entries:
+			if !ok {
+				flush()
+				return
+			}
+			if e.query != nil {
+				queries = append(queries, *e.query)
+			}
+			if e.click != nil {
+				clicks = append(clicks, *e.click)
+			}
+			if len(queries)+len(clicks) >= searchLogBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (s *SearchLogService) hashIP(ip string) string {
+	if ip == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, s.ipSalt)
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// SearchQueryStat aggregates the logged searches of one normalized query.
+type SearchQueryStat struct {
+	Query          string    `json:"query"`
+	Searches       int64     `json:"searches"`
+	Visitors       int64     `json:"visitors"`
+	AvgResults     float64   `json:"avg_results"`
+	LastSearchedAt time.Time `json:"last_searched_at"`
+}
+
+// SearchClickStat is the click-through of one query: how many of its
+// searches were followed by a click on a result.
+type SearchClickStat struct {
+	Query    string  `json:"query"`
+	Searches int64   `json:"searches"`
+	Clicks   int64   `json:"clicks"`
+	CTR      float64 `json:"ctr"`
+}
+
+// SearchResultClickStat counts clicks on one result across all queries.
+type SearchResultClickStat struct {
+	ResultType string `json:"result_type"`
+	ResultID   string `json:"result_id"`
+	URL        string `json:"url"`
+	Clicks     int64  `json:"clicks"`
+}
+
+// TopQueries returns the most searched queries in [from, to).
+func (s *SearchLogService) TopQueries(ctx context.Context, from, to time.Time, limit int) ([]SearchQueryStat, error) {
+	return s.queryStats(ctx, from, to, limit, false)
+}
+
+// ZeroResultQueries returns the most frequent searches in [from, to) that
+// found nothing: the content the site is missing.
+func (s *SearchLogService) ZeroResultQueries(ctx context.Context, from, to time.Time, limit int) ([]SearchQueryStat, error) {
+	return s.queryStats(ctx, from, to, limit, true)
+}
+
+func (s *SearchLogService) queryStats(ctx context.Context, from, to time.Time, limit int, zeroOnly bool) ([]SearchQueryStat, error) {
+	if s == nil || s.db == nil {
+		return nil, kxlerrors.Internal("db not configured")
+	}
+	q := s.db.WithContext(ctx).Model(&model.SearchQueryLog{}).
+		Select("query, COUNT(*) AS searches, COUNT(DISTINCT NULLIF(ip_hash, '')) AS visitors, " +
+			"AVG(result_count) AS avg_results, MAX(created_at) AS last_searched_at").
+		Where("created_at >= ? AND created_at < ?", from, to)
+	if zeroOnly {
+		q = q.Where("result_count = 0")
+	}
+	rows := []SearchQueryStat{}
+	if err := q.Group("query").Order("searches desc").Order("last_searched_at desc").
+		Limit(limit).Scan(&rows).Error; err != nil {
+		return nil, kxlerrors.Internal("db error")
+	}
+	return rows, nil
+}
+
+// ClickThrough returns per-query click-through rates for the most searched
+// queries in [from, to), and the most clicked results.
+func (s *SearchLogService) ClickThrough(ctx context.Context, from, to time.Time, limit int) ([]SearchClickStat, []SearchResultClickStat, error) {
+	if s == nil || s.db == nil {
+		return nil, nil, kxlerrors.Internal("db not configured")
+	}
+	db := s.db.WithContext(ctx)
+
+	queries := []SearchClickStat{}
+	if err := db.Raw(`
+SELECT l.query, l.searches, COALESCE(c.clicks, 0) AS clicks
+FROM (
+    SELECT query, COUNT(*) AS searches FROM search_query_logs
+    WHERE created_at >= ? AND created_at < ? GROUP BY query
+) l
+LEFT JOIN (
+    SELECT query, COUNT(*) AS clicks FROM search_clicks
+    WHERE created_at >= ? AND created_at < ? GROUP BY query
+) c ON c.query = l.query
+ORDER BY l.searches DESC, clicks DESC
+LIMIT ?`, from, to, from, to, limit).Scan(&queries).Error; err != nil {
+		return nil, nil, kxlerrors.Internal("db error")
+	}
+	for i := range queries {
+		if queries[i].Searches > 0 {
+			queries[i].CTR = float64(queries[i].Clicks) / float64(queries[i].Searches)
+		}
+	}
+
+	results := []SearchResultClickStat{}
+	if err := db.Model(&model.SearchClick{}).
+		Select("result_type, result_id, MAX(url) AS url, COUNT(*) AS clicks").
+		Where("created_at >= ? AND created_at < ?", from, to).
+		Group("result_type, result_id").Order("clicks desc").
+		Limit(limit).Scan(&results).Error; err != nil {
+		return nil, nil, kxlerrors.Internal("db error")
+	}
+	return queries, results, nil
+}
@@ -0,0 +1,42 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	kxlcfg "github.com/linkyfish/kxl_backend_go/internal/config"
+)
+
+func TestSearchLogService_HashIP(t *testing.T) {
+	s := NewSearchLogService(&kxlcfg.Config{Security: kxlcfg.SecurityConfig{SigningSecret: "k"}}, nil)
+	defer s.Close(context.Background())
+
+	h := s.hashIP("203.0.113.7")
+	if len(h) != 32 || strings.Contains(h, "203") {
+		t.Fatalf("unexpected hash %q", h)
+	}
+	if s.hashIP("203.0.113.7") != h || s.hashIP("203.0.113.8") == h {
+		t.Fatal("hash must be stable per address and differ across addresses")
+	}
+	if s.hashIP("") != "" {
+		t.Fatal("empty ip must stay empty")
+	}
+}
+
+func TestSearchLogService_CloseDrainsAndIgnoresLateRecords(t *testing.T) {
+	s := NewSearchLogService(nil, nil)
+	for i := 0; i < 10; i++ {
+		s.RecordQuery("ERP 系统", "", 3, "203.0.113.7")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+	s.RecordClick("erp", "article", "1", "/articles/1", "") // must not panic
+	if err := s.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-redis/redis/v8"
+	kxlerrors "github.com/linkyfish/kxl_backend_go/internal/errors"
+)
+
+const (
+	// popularQueriesKey is a sorted set of normalized queries scored by how
+	// many different visitors searched them with at least one result.
+	popularQueriesKey = "search:popular_queries"
+	popularQueriesMax = 1000
+	// popularClientsKey + query is the set of visitors (hashed) counted for
+	// the query, so a visitor repeating a search counts once per window.
+	popularClientsKey    = "search:popular_clients:"
+	popularClientsWindow = 7 * 24 * time.Hour
+	// popularBudgetKey + visitor caps how many queries one visitor can count
+	// towards the popular queries per hour.
+	popularBudgetKey    = "search:popular_budget:"
+	popularBudget       = 20
+	popularBudgetWindow = time.Hour
+	// suggestVersionKey is bumped whenever searchable content changes; cached
+	// suggestion lists embed it in their key, so a bump retires all of them.
+	suggestVersionKey = "search:suggest:version"
+	suggestCacheTTL   = 10 * time.Minute
+
+	MaxSuggestionLimit = 20
+	maxSuggestQueryLen = 64
+)
+
+type SearchSuggestion struct {
+	Text string `json:"text"`
+	// Type is "query" for a popular past search, otherwise the content type
+	// ("project", "article", "case" or "tag") the text was taken from.
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// Suggestions returns up to limit completions for the prefix q: popular past
+// queries blended with published project names, article titles, case client
+// names and tag names. An empty q returns the most popular queries. Results
+// are cached in Redis until content changes or the TTL expires.
+func (s *SearchService) Suggestions(ctx context.Context, q string, limit int) ([]SearchSuggestion, error) {
+	if s == nil || s.db == nil {
+		return nil, kxlerrors.Internal("db not configured")
+	}
+	if limit < 1 || limit > MaxSuggestionLimit {
+		return nil, kxlerrors.Validation("validation error: limit must be between 1 and " + strconv.Itoa(MaxSuggestionLimit))
+	}
+	q = normalizeQuery(q)
+	if utf8.RuneCountInString(q) > maxSuggestQueryLen {
+		return []SearchSuggestion{}, nil
+	}
+
+	var cacheKey string
+	if s.redis != nil {
+		version, err := s.redis.Get(ctx, suggestVersionKey).Int64()
+		if err != nil && err != redis.Nil {
+			version = -1 // Redis trouble: skip the cache, still answer.
+		}
+		if version >= 0 {
+			cacheKey = "search:suggest:" + strconv.FormatInt(version, 10) + ":" + strconv.Itoa(limit) + ":" + q
+			if raw, err := s.redis.Get(ctx, cacheKey).Bytes(); err == nil {
+				var cached []SearchSuggestion
+				if json.Unmarshal(raw, &cached) == nil {
+					return cached, nil
+				}
+			}
+		}
+	}
+
+	out, err := s.buildSuggestions(ctx, q, limit)
+	if err != nil {
+		return nil, err
+	}
+	if cacheKey != "" {
+		if payload, err := json.Marshal(out); err == nil {
+			_ = s.redis.Set(ctx, cacheKey, payload, suggestCacheTTL).Err()
+		}
+	}
+	return out, nil
+}
+
+// InvalidateSuggestions drops all cached suggestion lists; call it after
+// searchable content was created, changed, (un)published or deleted.
+func (s *SearchService) InvalidateSuggestions(ctx context.Context) {
+	if s == nil || s.redis == nil {
+		return
+	}
+	_ = s.redis.Incr(ctx, suggestVersionKey).Err()
+}
+
+func (s *SearchService) buildSuggestions(ctx context.Context, q string, limit int) ([]SearchSuggestion, error) {
+	popular := s.popularQueries(ctx, q, limit)
+	if q == "" {
+		return popular, nil
+	}
+
+	content, err := s.contentSuggestions(ctx, q, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Popular queries lead but take at most half of the list, so titles still
+	// show up; whatever content leaves unused is topped up with more queries.
+	out := make([]SearchSuggestion, 0, limit)
+	seen := map[string]bool{}
+	add := func(items []SearchSuggestion, max int) []SearchSuggestion {
+		rest := items[:0:0]
+		for _, it := range items {
+			key := strings.ToLower(it.Text)
+			if seen[key] {
+				continue
+			}
+			if len(out) >= max {
+				rest = append(rest, it)
+				continue
+			}
+			seen[key] = true
+			out = append(out, it)
+		}
+		return rest
+	}
+	rest := add(popular, (limit+1)/2)
+	add(content, limit)
+	add(rest, limit)
+	return out, nil
+}
+
+// contentSuggestions matches the prefix against titles, shortest first since
+// those are the closest completions.
+func (s *SearchService) contentSuggestions(ctx context.Context, q string, limit int) ([]SearchSuggestion, error) {
+	pattern := escapeLike(q) + "%"
+	sources := []struct {
+		typ, table, column, published string
+		url                           func(id, slug string) string
+	}{
+		{"project", "projects", "name", publishedCond, slugURL("/projects/")},
+		{"article", "articles", "title", publishedCond, slugURL("/articles/")},
+		{"case", "cases", "client_name", publishedCond, slugURL("/cases/")},
+		{"tag", "tags", "name", "", nil},
+	}
+
+	var out []SearchSuggestion
+	for _, src := range sources {
+		var rows []struct {
+			ID   string
+			Slug string
+			Text string
+		}
+		slug := "''"
+		if src.url != nil {
+			slug = "slug"
+		}
+		tx := s.db.WithContext(ctx).Table(src.table).
+			Select("CAST(id AS text) AS id, "+slug+" AS slug, "+src.column+" AS text").
+			Where(src.column+" ILIKE ?", pattern)
+		if src.published != "" {
+			tx = tx.Where(src.published)
+		}
+		if err := tx.Order("length(" + src.column + ")").Order(src.column).
+			Limit(limit).Scan(&rows).Error; err != nil {
+			return nil, kxlerrors.Internal("db error")
+		}
+		for _, r := range rows {
+			link := "/search?q=" + url.QueryEscape(r.Text)
+			if src.url != nil {
+				link = src.url(r.ID, r.Slug)
+			}
+			out = append(out, SearchSuggestion{Text: r.Text, Type: src.typ, URL: link})
+		}
+	}
+
+	// Across sources the tightest completion wins too, whatever its type.
+	sort.SliceStable(out, func(i, j int) bool {
+		return utf8.RuneCountInString(out[i].Text) < utf8.RuneCountInString(out[j].Text)
+	})
+	return out, nil
+}
+
+func (s *SearchService) popularQueries(ctx context.Context, prefix string, limit int) []SearchSuggestion {
+	if s.redis == nil {
+		return []SearchSuggestion{}
+	}
+	// Prefix filtering happens here rather than in Redis: the set is capped
+	// at popularQueriesMax members, and only the most popular ones matter.
+	scan := int64(limit)
+	if prefix != "" {
+		scan = 200
+	}
+	// Queries too few visitors ran are never shown, so one visitor cannot
+	// put text of their choice into everybody's suggestions.
+	queries, err := s.redis.ZRevRangeByScore(ctx, popularQueriesKey, &redis.ZRangeBy{
+		Min: strconv.Itoa(s.suggestMinClients()), Max: "+inf", Count: scan,
+	}).Result()
+	if err != nil {
+		return []SearchSuggestion{}
+	}
+	out := make([]SearchSuggestion, 0, limit)
+	for _, text := range queries {
+		if !strings.HasPrefix(text, prefix) {
+			continue
+		}
+		out = append(out, SearchSuggestion{Text: text, Type: "query", URL: "/search?q=" + url.QueryEscape(text)})
+		if len(out) == limit {
+			break
+		}
+	}
+	return out
+}
+
+// recordQuery counts a search by client (the visitor's IP) towards the
+// popular queries: once per client and query within popularClientsWindow,
+// and for at most popularBudget new queries per client and hour. Searches
+// without a client are not counted. Best effort.
+func (s *SearchService) recordQuery(ctx context.Context, q, client string) {
+	if s.redis == nil || client == "" {
+		return
+	}
+	q = normalizeQuery(q)
+	if q == "" || utf8.RuneCountInString(q) > maxSuggestQueryLen {
+		return
+	}
+	sum := sha256.Sum256([]byte(client))
+	who := hex.EncodeToString(sum[:16])
+
+	clientsKey := popularClientsKey + q
+	added, err := s.redis.SAdd(ctx, clientsKey, who).Result()
+	if err != nil {
+		return
+	}
+	_ = s.redis.Expire(ctx, clientsKey, popularClientsWindow).Err()
+	if added == 0 {
+		return
+	}
+	budgetKey := popularBudgetKey + who
+	n, err := s.redis.Incr(ctx, budgetKey).Result()
+	if err != nil {
+		return
+	}
+	if n == 1 {
+		_ = s.redis.Expire(ctx, budgetKey, popularBudgetWindow).Err()
+	}
+	if n > popularBudget {
+		_ = s.redis.SRem(ctx, clientsKey, who).Err()
+		return
+	}
+
+	pipe := s.redis.Pipeline()
+	pipe.ZIncrBy(ctx, popularQueriesKey, 1, q)
+	pipe.ZRemRangeByRank(ctx, popularQueriesKey, 0, -popularQueriesMax-1)
+	_, _ = pipe.Exec(ctx)
+}
+
+func (s *SearchService) suggestMinClients() int {
+	if s.minClients < 1 {
+		return 1
+	}
+	return s.minClients
+}
+
+// normalizeQuery lowercases q and collapses whitespace, so "ERP  系统" and
+// "erp 系统" count as the same query.
+func normalizeQuery(q string) string {
+	return strings.Join(strings.Fields(strings.ToLower(q)), " ")
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSearchTerms(t *testing.T) {
+	got := searchTerms("  ERP 系统  erp\t报表 ")
+	want := []string{"erp", "系统", "报表"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("searchTerms = %q, want %q", got, want)
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	if got := escapeLike(`100%_a\b`); got != `100\%\_a\\b` {
+		t.Fatalf("escapeLike = %q", got)
+	}
+}
+
+func TestMakeHighlighter_MixedTerms(t *testing.T) {
+	h := makeHighlighter("erp 系统")
+	got := h("ERP 管理系统")
+	if got != "<em>ERP</em> 管理<em>系统</em>" {
+		t.Fatalf("highlight = %q", got)
+	}
+}
+
+func TestNormalizeQuery(t *testing.T) {
+	if got := normalizeQuery("  ERP \t 系统 "); got != "erp 系统" {
+		t.Fatalf("normalizeQuery = %q", got)
+	}
+}
+
+func TestSearchCursor(t *testing.T) {
+	cat := 3
+	opts := SearchOptions{Type: "article", CategoryID: &cat}
+	fp := searchFingerprint("ERP 系统", opts, "trigram")
+	want := searchCursor{Rank: 1.4000000059604645, CreatedAt: time.Date(2025, 3, 1, 8, 0, 0, 123456000, time.UTC), Type: "article", ID: "a1", Fingerprint: fp}
+
+	got, err := decodeSearchCursor(encodeSearchCursor(want), searchFingerprint("erp  系统", opts, "trigram"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Rank != want.Rank || !got.CreatedAt.Equal(want.CreatedAt) || got.Type != want.Type || got.ID != want.ID {
+		t.Fatalf("round trip: got %+v, want %+v", got, want)
+	}
+
+	other := opts
+	other.CategoryID = nil
+	if _, err := decodeSearchCursor(encodeSearchCursor(want), searchFingerprint("erp 系统", other, "trigram")); err == nil {
+		t.Fatal("cursor accepted for a different filter")
+	}
+	if _, err := decodeSearchCursor("not-a-cursor", fp); err == nil {
+		t.Fatal("garbage cursor accepted")
+	}
+}
+
+func TestSearchEntities(t *testing.T) {
+	s := NewSearchService(nil, nil, nil)
+	project, ok := s.entity("project")
+	if !ok {
+		t.Fatal("project not registered")
+	}
+	// Must match the trigram index of migration 000007.
+	if got := project.document(); got != "(coalesce(name, '') || ' ' || coalesce(description, ''))" {
+		t.Fatalf("document = %q", got)
+	}
+	if team, _ := s.entity("team_member"); team.URL("7", "") != "/about#team" {
+		t.Fatalf("team_member url = %q", team.URL("7", ""))
+	}
+	if _, ok := s.ResultURL(context.Background(), "nope", "1"); ok {
+		t.Fatal("unknown type resolved")
+	}
+	if got := s.FilterType("article"); got != "article" {
+		t.Fatalf("FilterType(article) = %q", got)
+	}
+	if got := s.FilterType(strings.Repeat("x", 100)); got != "" {
+		t.Fatalf("unregistered type kept: %q", got)
+	}
+
+	n := len(s.Entities())
+	s.RegisterEntity(SearchEntity{Type: "faq", Table: "faqs", Title: "question", URL: func(id, _ string) string { return "/faq#" + id }})
+	s.RegisterEntity(SearchEntity{Type: "faq", Table: "faqs", Title: "question", URL: func(id, _ string) string { return "/help#" + id }})
+	if len(s.Entities()) != n+1 {
+		t.Fatalf("entities = %d, want %d", len(s.Entities()), n+1)
+	}
+	if got := s.entitiesFor("faq"); len(got) != 1 || got[0].URL("2", "") != "/help#2" {
+		t.Fatalf("entitiesFor(faq) = %+v", got)
+	}
+}
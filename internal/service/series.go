@@ -0,0 +1,391 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	kxlerrors "github.com/linkyfish/kxl_backend_go/internal/errors"
+	"github.com/linkyfish/kxl_backend_go/internal/model"
+	"github.com/linkyfish/kxl_backend_go/internal/util"
+	"gorm.io/gorm"
+)
+
+// Series kinds.
+const (
+	SeriesKindSeries     = "series"
+	SeriesKindCollection = "collection"
+)
+
+// SeriesService manages series and collections and their ordered items.
+type SeriesService struct {
+	db *gorm.DB
+}
+
+func NewSeriesService(db *gorm.DB) *SeriesService {
+	return &SeriesService{db: db}
+}
+
+// SeriesItemRef names one piece of content to place in a series.
+type SeriesItemRef struct {
+	Type string `json:"type" form:"type"`
+	ID   string `json:"id" form:"id"`
+}
+
+// SeriesEntry is an item of a series with what is needed to link to it.
+// Position counts from 1 among the entries returned, so it skips content
+// hidden from the reader.
+type SeriesEntry struct {
+	Position   int     `json:"position"`
+	Type       string  `json:"type"`
+	ID         string  `json:"id"`
+	Slug       string  `json:"slug"`
+	Title      string  `json:"title"`
+	CoverImage *string `json:"cover_image"`
+	URL        string  `json:"url"`
+	Published  bool    `json:"published"`
+}
+
+// SeriesNav places one piece of content within a series it belongs to:
+// part Part of Total, with its neighbours.
+type SeriesNav struct {
+	Series model.Series
+	Part   int
+	Total  int
+	Prev   *SeriesEntry
+	Next   *SeriesEntry
+}
+
+func (s *SeriesService) ListAll(ctx context.Context) ([]model.Series, error) {
+	if s == nil || s.db == nil {
+		return nil, kxlerrors.Internal("db not configured")
+	}
+	var rows []model.Series
+	if err := s.db.WithContext(ctx).Order("sort_order asc, created_at desc").Find(&rows).Error; err != nil {
+		return nil, kxlerrors.Internal("db error")
+	}
+	return rows, nil
+}
+
+func (s *SeriesService) ListVisible(ctx context.Context) ([]model.Series, error) {
+	if s == nil || s.db == nil {
+		return nil, kxlerrors.Internal("db not configured")
+	}
+	var rows []model.Series
+	if err := s.db.WithContext(ctx).Where("is_visible = ?", true).
+		Order("sort_order asc, created_at desc").Find(&rows).Error; err != nil {
+		return nil, kxlerrors.Internal("db error")
+	}
+	return rows, nil
+}
+
+func (s *SeriesService) Get(ctx context.Context, id string) (*model.Series, error) {
+	if s == nil || s.db == nil {
+		return nil, kxlerrors.Internal("db not configured")
+	}
+	if !util.IsUUID(id) {
+		return nil, kxlerrors.NotFound("not found: series not found")
+	}
+	var row model.Series
+	if err := s.db.WithContext(ctx).Where("id = ?", id).First(&row).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, kxlerrors.NotFound("not found: series not found")
+		}
+		return nil, kxlerrors.Internal("db error")
+	}
+	return &row, nil
+}
+
+// GetPublic finds a visible series by slug or id.
+func (s *SeriesService) GetPublic(ctx context.Context, key string) (*model.Series, error) {
+	if s == nil || s.db == nil {
+		return nil, kxlerrors.Internal("db not configured")
+	}
+	col := "slug"
+	if util.IsUUID(key) {
+		col = "id"
+	}
+	var row model.Series
+	if err := s.db.WithContext(ctx).Where(col+" = ? AND is_visible = ?", key, true).First(&row).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, kxlerrors.NotFound("not found: series not found")
+		}
+		return nil, kxlerrors.Internal("db error")
+	}
+	return &row, nil
+}
+
+func (s *SeriesService) Create(ctx context.Context, payload *model.Series) (*model.Series, error) {
+	if s == nil || s.db == nil {
+		return nil, kxlerrors.Internal("db not configured")
+	}
+	if err := validateSeries(payload); err != nil {
+		return nil, err
+	}
+	slug, err := s.seriesSlug(ctx, "", payload.Slug, payload.Title)
+	if err != nil {
+		return nil, err
+	}
+	payload.Slug = slug
+	if err := s.db.WithContext(ctx).Create(payload).Error; err != nil {
+		return nil, kxlerrors.Internal("db error")
+	}
+	return payload, nil
+}
+
+// Update saves payload over series id. An empty slug keeps the current one.
+func (s *SeriesService) Update(ctx context.Context, id string, payload *model.Series) (*model.Series, error) {
+	row, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSeries(payload); err != nil {
+		return nil, err
+	}
+	requested := payload.Slug
+	if requested == "" {
+		requested = row.Slug
+	}
+	slug, err := s.seriesSlug(ctx, row.ID, requested, payload.Title)
+	if err != nil {
+		return nil, err
+	}
+
+	row.Kind = payload.Kind
+	row.Title = payload.Title
+	row.Slug = slug
+	row.Description = payload.Description
+	row.CoverImage = payload.CoverImage
+	row.IsVisible = payload.IsVisible
+	row.SortOrder = payload.SortOrder
+
+	if err := s.db.WithContext(ctx).Save(row).Error; err != nil {
+		return nil, kxlerrors.Internal("db error")
+	}
+	return row, nil
+}
+
+func (s *SeriesService) Delete(ctx context.Context, id string) error {
+	if s == nil || s.db == nil {
+		return kxlerrors.Internal("db not configured")
+	}
+	if !util.IsUUID(id) {
+		return kxlerrors.NotFound("not found: series not found")
+	}
+	// Items go with it (ON DELETE CASCADE).
+	res := s.db.WithContext(ctx).Where("id = ?", id).Delete(&model.Series{})
+	if res.Error != nil {
+		return kxlerrors.Internal("db error")
+	}
+	if res.RowsAffected == 0 {
+		return kxlerrors.NotFound("not found: series not found")
+	}
+	return nil
+}
+
+func validateSeries(payload *model.Series) error {
+	switch payload.Kind {
+	case "":
+		payload.Kind = SeriesKindSeries
+	case SeriesKindSeries, SeriesKindCollection:
+	default:
+		return kxlerrors.Validation("validation error: kind must be series or collection")
+	}
+	if strings.TrimSpace(payload.Title) == "" {
+		return kxlerrors.Validation("validation error: missing required fields")
+	}
+	return nil
+}
+
+// seriesSlug checks a requested slug, or derives a free one from title.
+func (s *SeriesService) seriesSlug(ctx context.Context, id, requested, title string) (string, error) {
+	db := s.db.WithContext(ctx).Model(&model.Series{})
+	if id != "" {
+		db = db.Where("id <> ?", id)
+	}
+	if requested != "" {
+		if !util.ValidSlug(requested) {
+			return "", kxlerrors.Validation("validation error: slug must be lowercase letters, digits and single hyphens, at most 80 characters")
+		}
+		var n int64
+		if err := db.Where("slug = ?", requested).Count(&n).Error; err != nil {
+			return "", kxlerrors.Internal("db error")
+		}
+		if n > 0 {
+			return "", kxlerrors.Conflict("conflict: slug already in use")
+		}
+		return requested, nil
+	}
+
+	base := util.Slugify(title)
+	if base == "" {
+		base = "series"
+	}
+	if len(base) > util.SlugMaxLen-4 {
+		base = strings.TrimRight(base[:util.SlugMaxLen-4], "-")
+	}
+	var taken []string
+	if err := db.Where("slug = ? OR slug LIKE ?", base, base+"-%").Pluck("slug", &taken).Error; err != nil {
+		return "", kxlerrors.Internal("db error")
+	}
+	used := make(map[string]bool, len(taken))
+	for _, t := range taken {
+		used[t] = true
+	}
+	slug := base
+	for n := 2; used[slug]; n++ {
+		slug = base + "-" + strconv.Itoa(n)
+	}
+	return slug, nil
+}
+
+// SetItems replaces the items of series id with refs, in order. Every ref
+// must name existing content, at most once.
+func (s *SeriesService) SetItems(ctx context.Context, id string, refs []SeriesItemRef) error {
+	row, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	seen := make(map[SeriesItemRef]bool, len(refs))
+	byType := map[string][]string{}
+	for _, ref := range refs {
+		if _, ok := contentTables[ref.Type]; !ok {
+			return kxlerrors.Validation("validation error: unknown content type")
+		}
+		if !util.IsUUID(ref.ID) {
+			return kxlerrors.Validation("validation error: invalid content id")
+		}
+		if seen[ref] {
+			return kxlerrors.Validation("validation error: duplicate series item")
+		}
+		seen[ref] = true
+		byType[ref.Type] = append(byType[ref.Type], ref.ID)
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for typ, ids := range byType {
+			var n int64
+			if err := tx.Table(contentTables[typ].table).Where("id IN ?", ids).Count(&n).Error; err != nil {
+				return kxlerrors.Internal("db error")
+			}
+			if int(n) != len(ids) {
+				return kxlerrors.NotFound(contentTables[typ].notFound)
+			}
+		}
+		if err := tx.Where("series_id = ?", row.ID).Delete(&model.SeriesItem{}).Error; err != nil {
+			return kxlerrors.Internal("db error")
+		}
+		if len(refs) == 0 {
+			return nil
+		}
+		items := make([]model.SeriesItem, 0, len(refs))
+		for i, ref := range refs {
+			items = append(items, model.SeriesItem{SeriesID: row.ID, ContentType: ref.Type, ContentID: ref.ID, Position: i + 1})
+		}
+		if err := tx.Create(&items).Error; err != nil {
+			return kxlerrors.Internal("db error")
+		}
+		return nil
+	})
+}
+
+// Entries lists the items of series id in order. With publicOnly, content
+// visitors can't see is left out.
+func (s *SeriesService) Entries(ctx context.Context, id string, publicOnly bool) ([]SeriesEntry, error) {
+	if s == nil || s.db == nil {
+		return nil, kxlerrors.Internal("db not configured")
+	}
+	var items []model.SeriesItem
+	if err := s.db.WithContext(ctx).Where("series_id = ?", id).Order("position asc").Find(&items).Error; err != nil {
+		return nil, kxlerrors.Internal("db error")
+	}
+	byType := map[string][]string{}
+	for _, it := range items {
+		byType[it.ContentType] = append(byType[it.ContentType], it.ContentID)
+	}
+
+	found := map[string]SeriesEntry{}
+	for typ, ids := range byType {
+		ct, ok := contentTables[typ]
+		if !ok {
+			continue
+		}
+		var rows []struct {
+			ID         string
+			Slug       string
+			Title      string
+			CoverImage *string
+			Published  bool
+		}
+		q := s.db.WithContext(ctx).Table(ct.table).
+			Select("id, slug, "+ct.title+" AS title, cover_image, ("+publishedCond+") AS published").
+			Where("id IN ?", ids)
+		if publicOnly {
+			q = q.Where(publishedCond)
+		}
+		if err := q.Find(&rows).Error; err != nil {
+			return nil, kxlerrors.Internal("db error")
+		}
+		for _, r := range rows {
+			key := r.Slug
+			if key == "" {
+				key = r.ID
+			}
+			found[typ+":"+r.ID] = SeriesEntry{
+				Type: typ, ID: r.ID, Slug: r.Slug, Title: r.Title, CoverImage: r.CoverImage,
+				URL: ct.path + key, Published: r.Published,
+			}
+		}
+	}
+
+	out := make([]SeriesEntry, 0, len(items))
+	for _, it := range items {
+		e, ok := found[it.ContentType+":"+it.ContentID]
+		if !ok {
+			continue
+		}
+		e.Position = len(out) + 1
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+// Navigation returns the visible series that include content typ/id, with
+// its place among their published items. Content a visitor can't see in a
+// series (e.g. a draft opened through a preview link) gets no navigation.
+func (s *SeriesService) Navigation(ctx context.Context, typ, id string) ([]SeriesNav, error) {
+	if s == nil || s.db == nil {
+		return nil, kxlerrors.Internal("db not configured")
+	}
+	var series []model.Series
+	if err := s.db.WithContext(ctx).
+		Where("is_visible = ? AND id IN (?)", true,
+			s.db.Model(&model.SeriesItem{}).Select("series_id").Where("content_type = ? AND content_id = ?", typ, id)).
+		Order("sort_order asc, created_at desc").Find(&series).Error; err != nil {
+		return nil, kxlerrors.Internal("db error")
+	}
+
+	out := []SeriesNav{}
+	for _, se := range series {
+		entries, err := s.Entries(ctx, se.ID, true)
+		if err != nil {
+			return nil, err
+		}
+		for i, e := range entries {
+			if e.Type != typ || e.ID != id {
+				continue
+			}
+			nav := SeriesNav{Series: se, Part: i + 1, Total: len(entries)}
+			if i > 0 {
+				nav.Prev = &entries[i-1]
+			}
+			if i+1 < len(entries) {
+				nav.Next = &entries[i+1]
+			}
+			out = append(out, nav)
+			break
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/linkyfish/kxl_backend_go/internal/model"
+)
+
+func TestValidateSeries(t *testing.T) {
+	s := &model.Series{Title: "Go 入门"}
+	if err := validateSeries(s); err != nil || s.Kind != SeriesKindSeries {
+		t.Errorf("default kind: %v, %q", err, s.Kind)
+	}
+	if err := validateSeries(&model.Series{Title: "2025 回顾", Kind: SeriesKindCollection}); err != nil {
+		t.Errorf("collection rejected: %v", err)
+	}
+	if err := validateSeries(&model.Series{Title: "x", Kind: "playlist"}); err == nil {
+		t.Error("unknown kind accepted")
+	}
+	if err := validateSeries(&model.Series{Title: "  "}); err == nil {
+		t.Error("blank title accepted")
+	}
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	kxlerrors "github.com/linkyfish/kxl_backend_go/internal/errors"
+	"github.com/linkyfish/kxl_backend_go/internal/util"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SlugService gives articles, projects and cases their URL slugs and maps
+// slugs, old slugs and ids back to content.
+type SlugService struct {
+	db *gorm.DB
+}
+
+func NewSlugService(db *gorm.DB) *SlugService {
+	return &SlugService{db: db}
+}
+
+// SlugTarget is what a detail URL key resolves to. Canonical is the current
+// slug; it is empty for content that has none yet, which is then served
+// under its id.
+type SlugTarget struct {
+	ID        string
+	Canonical string
+}
+
+// Redirect reports whether a request for key should be sent to the
+// canonical URL instead.
+func (t SlugTarget) Redirect(key string) bool {
+	return t.Canonical != "" && t.Canonical != key
+}
+
+// Assign sets the slug of typ/id inside the transaction that saves it.
+// requested is an admin-chosen slug ("" to keep the current one); content
+// without a slug gets one generated from title. A replaced slug is kept as a
+// redirect.
+func (s *SlugService) Assign(tx *gorm.DB, typ, id, current, requested, title string) (string, error) {
+	ct, ok := contentTables[typ]
+	if !ok {
+		return "", kxlerrors.Validation("validation error: unknown content type")
+	}
+
+	slug := current
+	switch {
+	case requested != "":
+		if !util.ValidSlug(requested) {
+			return "", kxlerrors.Validation("validation error: slug must be lowercase letters, digits and single hyphens, at most 80 characters")
+		}
+		if requested == current {
+			return current, nil
+		}
+		var n int64
+		if err := tx.Table(ct.table).Where("slug = ? AND id <> ?", requested, id).Count(&n).Error; err != nil {
+			return "", kxlerrors.Internal("db error")
+		}
+		if n > 0 {
+			return "", kxlerrors.Conflict("conflict: slug already in use")
+		}
+		slug = requested
+	case current != "":
+		return current, nil
+	default:
+		var err error
+		if slug, err = s.freeSlug(tx, typ, id, title); err != nil {
+			return "", err
+		}
+	}
+
+	if err := tx.Table(ct.table).Where("id = ?", id).UpdateColumn("slug", slug).Error; err != nil {
+		return "", kxlerrors.Internal("db error")
+	}
+	// The new slug no longer redirects elsewhere; the old one now points here.
+	if err := tx.Exec("DELETE FROM content_slug_redirects WHERE content_type = ? AND slug = ?", typ, slug).Error; err != nil {
+		return "", kxlerrors.Internal("db error")
+	}
+	if current != "" {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "content_type"}, {Name: "slug"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"content_id": id, "created_at": gorm.Expr("now()")}),
+		}).Table("content_slug_redirects").Create(map[string]interface{}{
+			"content_type": typ, "slug": current, "content_id": id,
+		}).Error; err != nil {
+			return "", kxlerrors.Internal("db error")
+		}
+	}
+	return slug, nil
+}
+
+// freeSlug slugifies title and appends -2, -3, … until it is unused.
+func (s *SlugService) freeSlug(tx *gorm.DB, typ, id, title string) (string, error) {
+	ct := contentTables[typ]
+	base := util.Slugify(title)
+	if base == "" {
+		base = typ + "-" + strings.SplitN(id, "-", 2)[0]
+	}
+	if len(base) > util.SlugMaxLen-4 {
+		base = strings.TrimRight(base[:util.SlugMaxLen-4], "-")
+	}
+
+	var taken []string
+	if err := tx.Table(ct.table).Where("id <> ? AND (slug = ? OR slug LIKE ?)", id, base, base+"-%").
+		Pluck("slug", &taken).Error; err != nil {
+		return "", kxlerrors.Internal("db error")
+	}
+	used := make(map[string]bool, len(taken))
+	for _, t := range taken {
+		used[t] = true
+	}
+	slug := base
+	for n := 2; used[slug]; n++ {
+		slug = base + "-" + strconv.Itoa(n)
+	}
+	return slug, nil
+}
+
+// Resolve finds the content a detail URL key names: a current slug, an id,
+// or a replaced slug. It does not look at status; callers load the content
+// with their own visibility rules before redirecting, so drafts don't leak
+// their slugs.
+func (s *SlugService) Resolve(ctx context.Context, typ, key string) (*SlugTarget, error) {
+	if s == nil || s.db == nil {
+		return nil, kxlerrors.Internal("db not configured")
+	}
+	ct, ok := contentTables[typ]
+	if !ok {
+		return nil, kxlerrors.Validation("validation error: unknown content type")
+	}
+	db := s.db.WithContext(ctx)
+
+	find := func(col, val string) (*SlugTarget, error) {
+		var row struct {
+			ID   string
+			Slug string
+		}
+		err := db.Table(ct.table).Select("id, slug").Where(col+" = ?", val).Take(&row).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, kxlerrors.Internal("db error")
+		}
+		return &SlugTarget{ID: row.ID, Canonical: row.Slug}, nil
+	}
+
+	if util.IsUUID(key) {
+		if t, err := find("id", key); t != nil || err != nil {
+			return t, err
+		}
+		return nil, kxlerrors.NotFound(ct.notFound)
+	}
+	if t, err := find("slug", key); t != nil || err != nil {
+		return t, err
+	}
+	var contentID string
+	err := db.Table("content_slug_redirects").Select("content_id").
+		Where("content_type = ? AND slug = ?", typ, key).Take(&contentID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, kxlerrors.NotFound(ct.notFound)
+	}
+	if err != nil {
+		return nil, kxlerrors.Internal("db error")
+	}
+	if t, err := find("id", contentID); t != nil || err != nil {
+		return t, err
+	}
+	return nil, kxlerrors.NotFound(ct.notFound)
+}
+
+// Backfill generates slugs for content that has none, returning how many
+// rows it updated.
+func (s *SlugService) Backfill(ctx context.Context) (int, error) {
+	if s == nil || s.db == nil {
+		return 0, kxlerrors.Internal("db not configured")
+	}
+	n := 0
+	for _, typ := range []string{ContentArticle, ContentProject, ContentCase} {
+		var rows []struct {
+			ID    string
+			Title string
+		}
+		if err := s.db.WithContext(ctx).Table(contentTables[typ].table).
+			Select("id, " + contentTables[typ].title + " AS title").Where("slug = ''").Order("created_at").
+			Find(&rows).Error; err != nil {
+			return n, kxlerrors.Internal("db error")
+		}
+		for _, r := range rows {
+			if err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+				_, err := s.Assign(tx, typ, r.ID, "", "", r.Title)
+				return err
+			}); err != nil {
+				return n, err
+			}
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/linkyfish/kxl_backend_go/internal/util"
+)
+
+func TestSlugify(t *testing.T) {
+	cases := map[string]string{
+		"开心乐科技发布云端 ERP 1.0": "kai-xin-le-ke-ji-fa-bu-yun-duan-erp-1-0",
+		"Hello, World!":     "hello-world",
+		"  --北京--  ":        "bei-jing",
+		"¿?":                "",
+	}
+	for in, want := range cases {
+		if got := util.Slugify(in); got != want {
+			t.Errorf("Slugify(%q) = %q, want %q", in, got, want)
+		}
+	}
+	long := util.Slugify(strings.Repeat("word ", 40))
+	if len(long) > util.SlugMaxLen || strings.HasSuffix(long, "-") || !strings.HasSuffix(long, "word") {
+		t.Errorf("long slug not cut at a word boundary: %q", long)
+	}
+}
+
+func TestValidSlugAndRedirect(t *testing.T) {
+	for _, s := range []string{"cloud-erp", "a1"} {
+		if !util.ValidSlug(s) {
+			t.Errorf("ValidSlug(%q) = false", s)
+		}
+	}
+	for _, s := range []string{"", "Cloud", "a--b", "-a", "a_b", "11111111-1111-1111-1111-111111111111"} {
+		if util.ValidSlug(s) {
+			t.Errorf("ValidSlug(%q) = true", s)
+		}
+	}
+
+	target := SlugTarget{ID: "11111111-1111-1111-1111-111111111111", Canonical: "cloud-erp"}
+	if target.Redirect("cloud-erp") || !target.Redirect(target.ID) || !target.Redirect("old-slug") {
+		t.Error("unexpected redirect decision")
+	}
+	if (SlugTarget{ID: target.ID}).Redirect(target.ID) {
+		t.Error("content without a slug redirected")
+	}
+}
@@ -1,19 +1,30 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"io"
 	"mime/multipart"
 	"net/http"
-	"os"
+	"net/url"
+	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gabriel-vasile/mimetype"
 	kxlcfg "github.com/linkyfish/kxl_backend_go/internal/config"
 	kxlerrors "github.com/linkyfish/kxl_backend_go/internal/errors"
+	"github.com/linkyfish/kxl_backend_go/internal/model"
 	"github.com/linkyfish/kxl_backend_go/internal/util"
+	"github.com/linkyfish/kxl_backend_go/pkg/storage"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 const (
@@ -22,34 +33,98 @@ const (
 )
 
 type UploadService struct {
-	cfg *kxlcfg.Config
+	cfg   *kxlcfg.Config
+	db    *gorm.DB
+	store storage.Storage
+
+	variantMu    sync.Mutex
+	variantCache map[string]variantCacheEntry
+}
+
+// Uploader identifies who uploaded a file; the zero value is anonymous.
+type Uploader struct {
+	Type string
+	ID   string
 }
 
-func NewUploadService(cfg *kxlcfg.Config) *UploadService {
-	return &UploadService{cfg: cfg}
+// UploadResult describes a stored upload. Width, Height and Variants are only
+// set for images; ID is the media library row when a database is configured.
+// Deduplicated is set when identical content was already stored.
+type UploadResult struct {
+	ID           string         `json:"id,omitempty"`
+	URL          string         `json:"url"`
+	Mime         string         `json:"mime"`
+	Size         int64          `json:"size"`
+	Width        int            `json:"width,omitempty"`
+	Height       int            `json:"height,omitempty"`
+	Variants     []ImageVariant `json:"variants,omitempty"`
+	Deduplicated bool           `json:"deduplicated,omitempty"`
+}
+
+// NewUploadService wires the upload service to a storage backend. A nil store
+// falls back to the local filesystem under cfg.Uploads.Dir; a nil db disables
+// media library bookkeeping.
+func NewUploadService(cfg *kxlcfg.Config, db *gorm.DB, store storage.Storage) *UploadService {
+	s := &UploadService{cfg: cfg, db: db, store: store}
+	if s.store == nil {
+		s.store = storage.NewLocal(s.uploadsDir())
+	}
+	return s
 }
 
-func (s *UploadService) Upload(ctx context.Context, file *multipart.FileHeader, kind string) (string, error) {
+func (s *UploadService) Upload(ctx context.Context, file *multipart.FileHeader, kind string, by Uploader) (*UploadResult, error) {
 	if file == nil {
-		return "", kxlerrors.Validation("validation error: missing file")
+		return nil, kxlerrors.Validation("validation error: missing file")
 	}
 
 	maxBytes := s.maxSizeBytes(kind)
 	if file.Size > maxBytes {
-		return "", kxlerrors.Validation("validation error: file too large")
+		return nil, kxlerrors.Validation("validation error: file too large")
 	}
 
-	mime, err := detectMime(file)
+	src, err := file.Open()
 	if err != nil {
-		return "", kxlerrors.Validation("validation error: invalid upload")
+		return nil, kxlerrors.Validation("validation error: invalid upload")
+	}
+	defer src.Close()
+
+	// Site users upload against a storage quota; admins do not.
+	if by.Type != model.UploaderTypeUser || by.ID == "" {
+		return s.save(ctx, src, file.Size, file.Filename, kind, by)
+	}
+	if err := s.reserveQuota(ctx, by.ID, file.Size); err != nil {
+		return nil, err
+	}
+	res, err := s.save(ctx, src, file.Size, file.Filename, kind, by)
+	switch {
+	case err != nil:
+		s.adjustQuota(ctx, by.ID, -file.Size, -1)
+		return nil, err
+	case res.Deduplicated:
+		// Nothing new was stored.
+		s.adjustQuota(ctx, by.ID, -file.Size, -1)
+	case res.Size != file.Size:
+		// Metadata stripping changes the stored size slightly.
+		s.adjustQuota(ctx, by.ID, res.Size-file.Size, 0)
+	}
+	return res, nil
+}
+
+// save sniffs the content type, stores the file under a fresh
+// <kind>s/YYYY/MM/<uuid>.<ext> key, derives image variants and records the
+// media library row. Content already in the library (same kind and SHA-256)
+// is not stored twice; the existing file is returned instead.
+func (s *UploadService) save(ctx context.Context, src io.Reader, size int64, name, kind string, by Uploader) (*UploadResult, error) {
+	mime, src, err := sniffMime(src)
+	if err != nil {
+		return nil, kxlerrors.Validation("validation error: invalid upload")
 	}
 
 	ext := extensionForMime(kind, mime)
 	if ext == "" {
-		return "", kxlerrors.Validation("validation error: unsupported file type")
+		return nil, kxlerrors.Validation("validation error: unsupported file type")
 	}
 
-	baseDir := s.uploadsDir()
 	subdir := "images"
 	if kind == UploadKindVideo {
 		subdir = "videos"
@@ -59,80 +134,259 @@ func (s *UploadService) Upload(ctx context.Context, file *multipart.FileHeader,
 	month := now.Format("01")
 
 	uuid := util.NewUUID()
-	rel := filepath.ToSlash(filepath.Join(subdir, year, month, uuid+"."+ext))
-	dest := filepath.Join(baseDir, filepath.FromSlash(rel))
-	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
-		return "", kxlerrors.Internal("io error: failed to create directory")
-	}
+	key := path.Join(subdir, year, month, uuid+"."+ext)
 
-	src, err := file.Open()
-	if err != nil {
-		return "", kxlerrors.Validation("validation error: invalid upload")
-	}
-	defer src.Close()
+	res := &UploadResult{Mime: mime, Size: size}
+	var sum string
+	var derived derivedImage
+	if kind == UploadKindVideo {
+		// Videos are streamed, so the hash is only known once the file is
+		// written; a duplicate is discarded afterwards. Stripping keeps the
+		// size unchanged.
+		if s.stripVideoMetadata() {
+			stripped := stripVideoMetadata(ext, src)
+			defer stripped.Close()
+			src = stripped
+		}
+		hasher := sha256.New()
+		if err := s.store.Put(ctx, key, io.TeeReader(src, hasher), size, mime); err != nil {
+			return nil, kxlerrors.Internal("io error: failed to write file")
+		}
+		sum = hex.EncodeToString(hasher.Sum(nil))
+		if dup := s.reuseDuplicate(ctx, kind, sum); dup != nil {
+			_ = s.store.Delete(ctx, key)
+			return dup, nil
+		}
+	} else {
+		// Images are small enough (ImageMaxBytes) to buffer for decoding.
+		data, err := io.ReadAll(src)
+		if err != nil {
+			return nil, kxlerrors.Validation("validation error: invalid upload")
+		}
+		if s.stripImageMetadata() {
+			if data, err = stripImageMetadata(ext, data); err != nil {
+				return nil, kxlerrors.Validation("validation error: invalid image")
+			}
+		}
+		digest := sha256.Sum256(data)
+		sum = hex.EncodeToString(digest[:])
+		if dup := s.reuseDuplicate(ctx, kind, sum); dup != nil {
+			return dup, nil
+		}
+		res.Size = int64(len(data))
+		if err := s.store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), mime); err != nil {
+			return nil, kxlerrors.Internal("io error: failed to write file")
+		}
 
-	out, err := os.Create(dest)
-	if err != nil {
-		return "", kxlerrors.Internal("io error: failed to write file")
+		// A failed derivative never fails the upload; the original is still usable.
+		derived, err = s.generateVariants(ctx, key, ext, data)
+		if err != nil {
+			logVariantError(key, err)
+		}
+		res.Width, res.Height, res.Variants = derived.Width, derived.Height, derived.Variants
 	}
-	defer out.Close()
+	res.URL = s.PublicURL(key)
 
-	if _, err := io.Copy(out, src); err != nil {
-		_ = os.Remove(dest)
-		return "", kxlerrors.Internal("io error: failed to write file")
+	if s.db != nil {
+		m, err := s.recordMedia(ctx, key, kind, mime, res.Size, sum, name, by, derived)
+		if err != nil {
+			_ = s.store.Delete(ctx, key)
+			for _, k := range derived.Keys {
+				_ = s.store.Delete(ctx, k)
+			}
+			return nil, err
+		}
+		res.ID = m.ID
 	}
-
-	return "/uploads/" + rel, nil
+	return res, nil
 }
 
-func (s *UploadService) DeleteRelativePath(ctx context.Context, relPath string) error {
-	relPath = strings.TrimSpace(relPath)
-	relPath = strings.TrimPrefix(relPath, "/")
-	if relPath == "" || strings.Contains(relPath, "\x00") {
-		return kxlerrors.Validation("validation error: invalid path")
+// reuseDuplicate looks for an already stored file of the same kind with the
+// same SHA-256 and, if it is still present in storage, claims one more upload
+// on it and returns it. It returns nil whenever a fresh copy should be stored
+// instead, including on lookup errors.
+func (s *UploadService) reuseDuplicate(ctx context.Context, kind, sum string) *UploadResult {
+	if s.db == nil {
+		return nil
 	}
-
-	parts := splitPath(relPath)
-	for _, p := range parts {
-		if p == "" || p == "." || p == ".." {
-			return kxlerrors.Validation("validation error: invalid path")
+	var rows []model.Media
+	if err := s.db.WithContext(ctx).Where("kind = ? AND sha256 = ?", kind, sum).
+		Order("created_at asc").Limit(5).Find(&rows).Error; err != nil {
+		return nil
+	}
+	for i := range rows {
+		m := &rows[i]
+		if _, err := s.store.Stat(ctx, m.Path); err != nil {
+			continue
+		}
+		// A re-upload means the file is about to be used again, so it must
+		// not be collected as an orphan in the meantime. The update misses if
+		// a concurrent delete removed the row.
+		res := s.db.WithContext(ctx).Model(&model.Media{}).Where("id = ?", m.ID).
+			UpdateColumns(map[string]interface{}{
+				"upload_count": gorm.Expr("upload_count + 1"),
+				"orphaned_at":  nil,
+				"updated_at":   time.Now().UTC(),
+			})
+		if res.Error != nil || res.RowsAffected == 0 {
+			continue
 		}
+		out := &UploadResult{ID: m.ID, URL: s.PublicURL(m.Path), Mime: m.Mime, Size: m.Size, Deduplicated: true}
+		if m.Width != nil && m.Height != nil {
+			out.Width, out.Height = *m.Width, *m.Height
+		}
+		if m.Kind == UploadKindImage {
+			out.Variants = s.ImageVariants(ctx, out.URL)
+		}
+		return out
 	}
+	return nil
+}
 
-	baseDir := s.uploadsDir()
-	baseAbs, err := filepath.Abs(baseDir)
-	if err != nil {
-		return kxlerrors.Internal("io error")
+func (s *UploadService) recordMedia(ctx context.Context, key, kind, mime string, size int64, sum, name string, by Uploader, derived derivedImage) (*model.Media, error) {
+	variants, _ := json.Marshal(derived.Keys)
+	if derived.Keys == nil {
+		variants = []byte("[]")
+	}
+	m := &model.Media{
+		Path:         key,
+		Kind:         kind,
+		Mime:         mime,
+		Size:         size,
+		SHA256:       sum,
+		OriginalName: truncateRunes(filepath.Base(name), 255),
+		UploaderType: by.Type,
+		Variants:     variants,
+	}
+	if by.ID != "" {
+		id := by.ID
+		m.UploaderID = &id
 	}
+	if derived.Width > 0 && derived.Height > 0 {
+		w, h := derived.Width, derived.Height
+		m.Width, m.Height = &w, &h
+	}
+	if err := s.db.WithContext(ctx).Create(m).Error; err != nil {
+		return nil, kxlerrors.Internal("db error")
+	}
+	return m, nil
+}
 
-	full := filepath.Join(baseAbs, filepath.FromSlash(relPath))
-	fullAbs, err := filepath.Abs(full)
+// Open streams a stored file; used to serve /uploads/* when the backend is
+// not a local directory.
+func (s *UploadService) Open(ctx context.Context, relPath string) (io.ReadCloser, *storage.Object, error) {
+	if IsPrivateUploadKey(relPath) {
+		return nil, nil, storageError(storage.ErrNotFound)
+	}
+	rc, obj, err := s.store.Open(ctx, relPath)
 	if err != nil {
-		return kxlerrors.Internal("io error")
+		return nil, nil, storageError(err)
 	}
+	return rc, obj, nil
+}
 
-	// Disallow directory traversal.
-	if !strings.HasPrefix(fullAbs, baseAbs+string(os.PathSeparator)) && fullAbs != baseAbs {
-		return kxlerrors.NotFound("not found: file not found")
+// IsPrivateUploadKey reports whether a key belongs to in-progress data (e.g.
+// resumable upload chunks) that must never be served. The key is unescaped
+// and cleaned first, as a static file server would, so "%74mp/..." or
+// "images/../tmp/..." are caught too; undecodable keys count as private.
+func IsPrivateUploadKey(key string) bool {
+	unescaped, err := url.PathUnescape(key)
+	if err != nil {
+		return true
 	}
+	clean := strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(unescaped, "\\", "/")), "/")
+	return clean == "tmp" || strings.HasPrefix(clean, "tmp/")
+}
+
+// DeleteRelativePath releases one upload of a stored file. Identical uploads
+// share a file (see reuseDuplicate), so the file, its derivatives and its
+// media library row are only removed once the last upload is released.
+func (s *UploadService) DeleteRelativePath(ctx context.Context, relPath string) error {
+	return s.remove(ctx, relPath, false)
+}
 
-	info, err := os.Stat(fullAbs)
+// PurgeRelativePath removes a stored file however many uploads share it.
+// Used by the media library, which checks content references itself.
+func (s *UploadService) PurgeRelativePath(ctx context.Context, relPath string) error {
+	return s.remove(ctx, relPath, true)
+}
+
+func (s *UploadService) remove(ctx context.Context, relPath string, purge bool) error {
+	key, err := storage.CleanKey(relPath)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return kxlerrors.NotFound("not found: file not found")
-		}
-		return kxlerrors.Internal("io error")
+		return storageError(err)
 	}
-	if info.IsDir() {
-		return kxlerrors.Validation("validation error: path is a directory")
+	if s.db == nil {
+		return s.deleteFiles(ctx, key, nil)
 	}
 
-	if err := os.Remove(fullAbs); err != nil {
+	// The row lock orders this against reuseDuplicate, which would otherwise
+	// hand out a URL whose file is being deleted.
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var m *model.Media
+		var row model.Media
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("path = ?", key).First(&row).Error
+		switch {
+		case err == nil:
+			m = &row
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return kxlerrors.Internal("db error")
+		}
+
+		if m != nil && !purge && m.UploadCount > 1 {
+			if err := tx.Model(&model.Media{}).Where("id = ?", m.ID).
+				UpdateColumn("upload_count", gorm.Expr("upload_count - 1")).Error; err != nil {
+				return kxlerrors.Internal("db error")
+			}
+			return nil
+		}
+
+		if err := s.deleteFiles(ctx, key, m); err != nil {
+			return err
+		}
+		if m != nil {
+			if err := tx.Delete(&model.Media{}, "id = ?", m.ID).Error; err != nil {
+				return kxlerrors.Internal("db error")
+			}
+			if m.UploaderType == model.UploaderTypeUser && m.UploaderID != nil {
+				s.adjustQuota(ctx, *m.UploaderID, -m.Size, -1)
+			}
+		}
+		return nil
+	})
+}
+
+// deleteFiles removes an original and its derivatives from storage. A file
+// that is already gone is fine as long as there is a media row to clean up.
+func (s *UploadService) deleteFiles(ctx context.Context, key string, m *model.Media) error {
+	err := s.store.Delete(ctx, key)
+	switch {
+	case err == nil:
+	case errors.Is(err, storage.ErrNotFound) && m != nil:
+	case errors.Is(err, storage.ErrNotFound), errors.Is(err, storage.ErrInvalidKey):
+		return storageError(err)
+	default:
 		return kxlerrors.New(50003, "io error: failed to delete file", http.StatusInternalServerError, nil)
 	}
+	s.deleteVariants(ctx, key, m)
 	return nil
 }
 
+// Storage exposes the configured backend, e.g. so the router can tell
+// whether files can be served straight from disk.
+func (s *UploadService) Storage() storage.Storage {
+	return s.store
+}
+
+// PublicURL maps a storage key to the URL handed out to clients.
+func (s *UploadService) PublicURL(key string) string {
+	base := "/uploads"
+	if s != nil && s.cfg != nil && strings.TrimSpace(s.cfg.Uploads.PublicBaseURL) != "" {
+		base = strings.TrimSpace(s.cfg.Uploads.PublicBaseURL)
+	}
+	return strings.TrimRight(base, "/") + "/" + strings.TrimPrefix(key, "/")
+}
+
 func (s *UploadService) uploadsDir() string {
 	if s != nil && s.cfg != nil && s.cfg.Uploads.Dir != "" {
 		return strings.TrimSpace(s.cfg.Uploads.Dir)
@@ -140,6 +394,17 @@ func (s *UploadService) uploadsDir() string {
 	return "uploads"
 }
 
+func storageError(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrInvalidKey):
+		return kxlerrors.Validation("validation error: invalid path")
+	case errors.Is(err, storage.ErrNotFound):
+		return kxlerrors.NotFound("not found: file not found")
+	default:
+		return kxlerrors.Internal("io error")
+	}
+}
+
 func (s *UploadService) maxSizeBytes(kind string) int64 {
 	if s == nil || s.cfg == nil {
 		if kind == UploadKindVideo {
@@ -159,17 +424,24 @@ func (s *UploadService) maxSizeBytes(kind string) int64 {
 	return 10 * 1024 * 1024
 }
 
-func detectMime(file *multipart.FileHeader) (string, error) {
-	f, err := file.Open()
-	if err != nil {
-		return "", err
+// sniffMime detects the content type from the leading bytes and returns a
+// reader that still yields the whole stream.
+func sniffMime(r io.Reader) (string, io.Reader, error) {
+	head := make([]byte, 3072)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", nil, err
 	}
-	defer f.Close()
-	m, err := mimetype.DetectReader(f)
-	if err != nil {
-		return "", err
+	head = head[:n]
+	return mimetype.Detect(head).String(), io.MultiReader(bytes.NewReader(head), r), nil
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
 	}
-	return m.String(), nil
+	return string(runes[:n])
 }
 
 func extensionForMime(kind, mime string) string {
@@ -196,14 +468,3 @@ func extensionForMime(kind, mime string) string {
 		return ""
 	}
 }
-
-func splitPath(p string) []string {
-	p = strings.ReplaceAll(p, "\\", "/")
-	parts := strings.Split(p, "/")
-	out := make([]string, 0, len(parts))
-	for _, part := range parts {
-		out = append(out, part)
-	}
-	return out
-}
-
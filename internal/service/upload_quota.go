@@ -0,0 +1,220 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	kxlerrors "github.com/linkyfish/kxl_backend_go/internal/errors"
+	"github.com/linkyfish/kxl_backend_go/internal/model"
+	"github.com/linkyfish/kxl_backend_go/internal/util"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const uploadTicketPurpose = "upload-ticket"
+
+// UploadQuota is a user's storage allowance. Max* of -1 means unlimited.
+type UploadQuota struct {
+	MaxBytes       int64 `json:"max_bytes"`
+	UsedBytes      int64 `json:"used_bytes"`
+	RemainingBytes int64 `json:"remaining_bytes"`
+	MaxFiles       int64 `json:"max_files"`
+	UsedFiles      int64 `json:"used_files"`
+	RemainingFiles int64 `json:"remaining_files"`
+}
+
+// UploadTicket lets a client upload on behalf of a user without the session
+// cookie (e.g. from a separate upload host). Kind restricts the ticket to
+// images or videos when set.
+type UploadTicket struct {
+	Ticket    string    `json:"ticket"`
+	UserID    string    `json:"-"`
+	Kind      string    `json:"kind,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type uploadTicketClaims struct {
+	UserID string `json:"uid"`
+	Kind   string `json:"kind,omitempty"`
+	Exp    int64  `json:"exp"`
+}
+
+// IssueTicket signs a short-lived upload ticket for userID.
+func (s *UploadService) IssueTicket(userID, kind string) (*UploadTicket, error) {
+	if kind != "" && kind != UploadKindImage && kind != UploadKindVideo {
+		return nil, kxlerrors.Validation("validation error: invalid kind")
+	}
+	secret := s.signingSecret()
+	if secret == "" {
+		return nil, kxlerrors.Internal("upload tickets not configured")
+	}
+	ttl := 600
+	if s.cfg != nil && s.cfg.Uploads.TicketTTLSeconds > 0 {
+		ttl = s.cfg.Uploads.TicketTTLSeconds
+	}
+	exp := time.Now().Add(time.Duration(ttl) * time.Second).UTC().Truncate(time.Second)
+	token, err := util.SignToken(secret, uploadTicketPurpose, uploadTicketClaims{UserID: userID, Kind: kind, Exp: exp.Unix()})
+	if err != nil {
+		return nil, kxlerrors.Internal("failed to sign ticket")
+	}
+	return &UploadTicket{Ticket: token, UserID: userID, Kind: kind, ExpiresAt: exp}, nil
+}
+
+// VerifyTicket checks the signature and expiry of an upload ticket.
+func (s *UploadService) VerifyTicket(token string) (*UploadTicket, error) {
+	var claims uploadTicketClaims
+	if err := util.VerifyToken(s.signingSecret(), uploadTicketPurpose, strings.TrimSpace(token), &claims); err != nil {
+		return nil, kxlerrors.Unauthorized()
+	}
+	exp := time.Unix(claims.Exp, 0).UTC()
+	if claims.UserID == "" || time.Now().After(exp) {
+		return nil, kxlerrors.Unauthorized()
+	}
+	return &UploadTicket{Ticket: token, UserID: claims.UserID, Kind: claims.Kind, ExpiresAt: exp}, nil
+}
+
+// Quota returns the current allowance of a user.
+func (s *UploadService) Quota(ctx context.Context, userID string) (*UploadQuota, error) {
+	if s == nil || s.db == nil {
+		return nil, kxlerrors.Internal("db not configured")
+	}
+	var row model.UserUploadQuota
+	err := s.db.WithContext(ctx).Where("user_id = ?", userID).First(&row).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, kxlerrors.Internal("db error")
+	}
+	maxBytes, maxFiles := s.quotaLimits(&row)
+	q := &UploadQuota{
+		MaxBytes:       maxBytes,
+		UsedBytes:      row.UsedBytes,
+		RemainingBytes: -1,
+		MaxFiles:       maxFiles,
+		UsedFiles:      row.UsedFiles,
+		RemainingFiles: -1,
+	}
+	if maxBytes >= 0 {
+		q.RemainingBytes = max(maxBytes-row.UsedBytes, 0)
+	}
+	if maxFiles >= 0 {
+		q.RemainingFiles = max(maxFiles-row.UsedFiles, 0)
+	}
+	return q, nil
+}
+
+// SetQuotaLimits overrides the limits of one user; nil restores the default.
+func (s *UploadService) SetQuotaLimits(ctx context.Context, userID string, maxBytes, maxFiles *int64) (*UploadQuota, error) {
+	if s == nil || s.db == nil {
+		return nil, kxlerrors.Internal("db not configured")
+	}
+	if (maxBytes != nil && *maxBytes < 0) || (maxFiles != nil && *maxFiles < 0) {
+		return nil, kxlerrors.Validation("validation error: limits must be >= 0")
+	}
+	var n int64
+	if err := s.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", userID).Count(&n).Error; err != nil {
+		return nil, kxlerrors.Internal("db error")
+	}
+	if n == 0 {
+		return nil, kxlerrors.NotFound("not found: user not found")
+	}
+	row := model.UserUploadQuota{UserID: userID, MaxBytes: maxBytes, MaxFiles: maxFiles}
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"max_bytes", "max_files", "updated_at"}),
+	}).Create(&row).Error; err != nil {
+		return nil, kxlerrors.Internal("db error")
+	}
+	return s.Quota(ctx, userID)
+}
+
+// reserveQuota charges size bytes and one file to a user before the upload
+// is stored, failing with the remaining allowance when it does not fit.
+func (s *UploadService) reserveQuota(ctx context.Context, userID string, size int64) error {
+	if s.db == nil {
+		return nil
+	}
+	db := s.db.WithContext(ctx)
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.UserUploadQuota{UserID: userID}).Error; err != nil {
+		return kxlerrors.Internal("db error")
+	}
+	defBytes, defFiles := s.quotaDefaults()
+	res := db.Model(&model.UserUploadQuota{}).
+		Where("user_id = ?", userID).
+		Where("used_bytes + ? <= COALESCE(max_bytes, ?)", size, unlimitedAsMax(defBytes)).
+		Where("used_files + 1 <= COALESCE(max_files, ?)", unlimitedAsMax(defFiles)).
+		UpdateColumns(map[string]interface{}{
+			"used_bytes": gorm.Expr("used_bytes + ?", size),
+			"used_files": gorm.Expr("used_files + 1"),
+			"updated_at": time.Now().UTC(),
+		})
+	if res.Error != nil {
+		return kxlerrors.Internal("db error")
+	}
+	if res.RowsAffected == 0 {
+		q, err := s.Quota(ctx, userID)
+		if err != nil {
+			return err
+		}
+		return kxlerrors.New(kxlerrors.CodeQuotaExceeded, "quota exceeded: upload quota exceeded", http.StatusForbidden, q)
+	}
+	return nil
+}
+
+// adjustQuota adds the given deltas to a user's usage, never going below
+// zero. Best effort: a failure only makes the counters drift.
+func (s *UploadService) adjustQuota(ctx context.Context, userID string, bytes, files int64) {
+	if s.db == nil || userID == "" || (bytes == 0 && files == 0) {
+		return
+	}
+	_ = s.db.WithContext(ctx).Model(&model.UserUploadQuota{}).
+		Where("user_id = ?", userID).
+		UpdateColumns(map[string]interface{}{
+			"used_bytes": gorm.Expr("GREATEST(used_bytes + ?, 0)", bytes),
+			"used_files": gorm.Expr("GREATEST(used_files + ?, 0)", files),
+			"updated_at": time.Now().UTC(),
+		}).Error
+}
+
+func (s *UploadService) quotaLimits(row *model.UserUploadQuota) (int64, int64) {
+	maxBytes, maxFiles := s.quotaDefaults()
+	if row.MaxBytes != nil {
+		maxBytes = *row.MaxBytes
+	}
+	if row.MaxFiles != nil {
+		maxFiles = *row.MaxFiles
+	}
+	return maxBytes, maxFiles
+}
+
+// quotaDefaults returns the configured per-user limits; 0 or less in the
+// config means unlimited, reported as -1.
+func (s *UploadService) quotaDefaults() (int64, int64) {
+	maxBytes, maxFiles := int64(-1), int64(-1)
+	if s.cfg != nil {
+		if s.cfg.Uploads.UserQuotaBytes > 0 {
+			maxBytes = s.cfg.Uploads.UserQuotaBytes
+		}
+		if s.cfg.Uploads.UserQuotaFiles > 0 {
+			maxFiles = int64(s.cfg.Uploads.UserQuotaFiles)
+		}
+	}
+	return maxBytes, maxFiles
+}
+
+func (s *UploadService) signingSecret() string {
+	if s == nil || s.cfg == nil {
+		return ""
+	}
+	return s.cfg.Security.SigningSecret
+}
+
+func unlimitedAsMax(limit int64) int64 {
+	if limit < 0 {
+		return math.MaxInt64 / 2
+	}
+	return limit
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	kxlcfg "github.com/linkyfish/kxl_backend_go/internal/config"
+	"github.com/linkyfish/kxl_backend_go/internal/util"
+)
+
+func TestUploadTicket_RoundTrip(t *testing.T) {
+	cfg := &kxlcfg.Config{Security: kxlcfg.SecurityConfig{SigningSecret: "test-secret"}}
+	svc := NewUploadService(cfg, nil, nil)
+
+	ticket, err := svc.IssueTicket("11111111-1111-1111-1111-111111111111", UploadKindImage)
+	if err != nil {
+		t.Fatalf("IssueTicket: %v", err)
+	}
+	got, err := svc.VerifyTicket(ticket.Ticket)
+	if err != nil {
+		t.Fatalf("VerifyTicket: %v", err)
+	}
+	if got.UserID != ticket.UserID || got.Kind != UploadKindImage || !got.ExpiresAt.Equal(ticket.ExpiresAt) {
+		t.Fatalf("unexpected ticket %+v", got)
+	}
+
+	payload, sig, _ := strings.Cut(ticket.Ticket, ".")
+	if _, err := svc.VerifyTicket(payload + "x." + sig); err == nil {
+		t.Fatal("tampered ticket accepted")
+	}
+	other := NewUploadService(&kxlcfg.Config{Security: kxlcfg.SecurityConfig{SigningSecret: "other"}}, nil, nil)
+	if _, err := other.VerifyTicket(ticket.Ticket); err == nil {
+		t.Fatal("ticket accepted with a different secret")
+	}
+
+	// Same secret, different purpose.
+	token, _ := util.SignToken("test-secret", "something-else", uploadTicketClaims{UserID: "u", Exp: ticket.ExpiresAt.Unix()})
+	if _, err := svc.VerifyTicket(token); err == nil {
+		t.Fatal("token for another purpose accepted")
+	}
+	expired, _ := util.SignToken("test-secret", uploadTicketPurpose, uploadTicketClaims{UserID: "u", Exp: 1})
+	if _, err := svc.VerifyTicket(expired); err == nil {
+		t.Fatal("expired ticket accepted")
+	}
+}
+
+func TestUploadTicket_RequiresSecret(t *testing.T) {
+	svc := NewUploadService(&kxlcfg.Config{}, nil, nil)
+	if _, err := svc.IssueTicket("u", ""); err == nil {
+		t.Fatal("expected tickets to be disabled without a signing secret")
+	}
+}
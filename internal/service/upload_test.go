@@ -0,0 +1,142 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	kxlcfg "github.com/linkyfish/kxl_backend_go/internal/config"
+	"github.com/linkyfish/kxl_backend_go/pkg/storage"
+)
+
+// newFileHeader builds a *multipart.FileHeader the same way Echo does when
+// parsing a request.
+func newFileHeader(t *testing.T, name string, data []byte) *multipart.FileHeader {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", `form-data; name="file"; filename="`+name+`"`)
+	part, err := mw.CreatePart(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = part.Write(data)
+	_ = mw.Close()
+
+	form, err := multipart.NewReader(&body, mw.Boundary()).ReadForm(32 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = form.RemoveAll() })
+	return form.File["file"][0]
+}
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestUploadImage_NoWebPForJPEG(t *testing.T) {
+	cfg := &kxlcfg.Config{Uploads: kxlcfg.UploadsConfig{
+		ImageMaxBytes:      10 << 20,
+		ImageVariantWidths: []int{320},
+		ImageWebP:          true,
+	}}
+	svc := NewUploadService(cfg, nil, storage.NewLocal(t.TempDir()))
+
+	img, _ := png.Decode(bytes.NewReader(testPNG(t, 400, 200)))
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	res, err := svc.Upload(context.Background(), newFileHeader(t, "a.jpg", buf.Bytes()), UploadKindImage, Uploader{})
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if len(res.Variants) != 1 || !strings.HasSuffix(res.Variants[0].URL, "_w320.jpg") || res.Variants[0].WebP != "" {
+		t.Fatalf("unexpected variants %+v", res.Variants)
+	}
+}
+
+func TestUploadImage_GeneratesVariants(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &kxlcfg.Config{Uploads: kxlcfg.UploadsConfig{
+		PublicBaseURL:      "https://cdn.example.com/uploads/",
+		ImageMaxBytes:      10 << 20,
+		ImageVariantWidths: []int{640, 320},
+		ImageWebP:          true,
+	}}
+	svc := NewUploadService(cfg, nil, storage.NewLocal(dir))
+
+	res, err := svc.Upload(context.Background(), newFileHeader(t, "a.png", testPNG(t, 400, 200)), UploadKindImage, Uploader{})
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if !strings.HasPrefix(res.URL, "https://cdn.example.com/uploads/images/") || !strings.HasSuffix(res.URL, ".png") {
+		t.Fatalf("unexpected url %q", res.URL)
+	}
+	// 640 is not narrower than the 400px source, so only 320 is made.
+	if res.Width != 400 || res.Height != 200 || len(res.Variants) != 1 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	v := res.Variants[0]
+	if v.Width != 320 || !strings.HasSuffix(v.URL, "_w320.png") {
+		t.Fatalf("unexpected variant %+v", v)
+	}
+	key, _ := svc.KeyFromURL(v.URL)
+	f, err := os.Open(filepath.Join(dir, filepath.FromSlash(key)))
+	if err != nil {
+		t.Fatalf("open variant: %v", err)
+	}
+	defer f.Close()
+	cfgImg, err := png.DecodeConfig(f)
+	if err != nil || cfgImg.Width != 320 || cfgImg.Height != 160 {
+		t.Fatalf("variant %s = %+v, %v", key, cfgImg, err)
+	}
+	// The WebP copy is only kept when it is smaller than the PNG.
+	if v.WebP != "" {
+		webpKey, _ := svc.KeyFromURL(v.WebP)
+		pngInfo, _ := os.Stat(filepath.Join(dir, filepath.FromSlash(key)))
+		webpInfo, err := os.Stat(filepath.Join(dir, filepath.FromSlash(webpKey)))
+		if err != nil || webpInfo.Size() >= pngInfo.Size() {
+			t.Fatalf("webp variant kept: %v, %v", webpInfo, err)
+		}
+	}
+
+	got := svc.ImageVariants(context.Background(), res.URL)
+	if len(got) != 1 || got[0] != v {
+		t.Fatalf("ImageVariants = %+v; want %+v", got, res.Variants)
+	}
+	if v := svc.ImageVariants(context.Background(), "https://elsewhere.example.com/a.png"); v != nil {
+		t.Fatalf("foreign url should have no variants, got %+v", v)
+	}
+
+	// Deleting the original takes its derivatives with it.
+	origKey, _ := svc.KeyFromURL(res.URL)
+	if err := svc.DeleteRelativePath(context.Background(), origKey); err != nil {
+		t.Fatalf("DeleteRelativePath: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(key))); !os.IsNotExist(err) {
+		t.Fatalf("variant %s survived delete: %v", key, err)
+	}
+}
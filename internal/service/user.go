@@ -175,3 +175,63 @@ func (s *UserService) DeleteAdmin(ctx context.Context, id string) error {
 	return nil
 }
 
+
+// FindUser looks a user up by id, username or email.
+func (s *UserService) FindUser(ctx context.Context, identifier string) (*model.User, error) {
+	if s == nil || s.db == nil {
+		return nil, kxlerrors.Internal("db not configured")
+	}
+	q := s.db.WithContext(ctx)
+	if util.IsUUID(identifier) {
+		q = q.Where("id = ?", identifier)
+	} else {
+		q = q.Where("username = ? OR email = ?", identifier, identifier)
+	}
+	var user model.User
+	if err := q.First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, kxlerrors.NotFound("not found: user not found")
+		}
+		return nil, kxlerrors.Internal("db error")
+	}
+	return &user, nil
+}
+
+// FindAdmin looks an admin up by id or username.
+func (s *UserService) FindAdmin(ctx context.Context, identifier string) (*model.Admin, error) {
+	if s == nil || s.db == nil {
+		return nil, kxlerrors.Internal("db not configured")
+	}
+	q := s.db.WithContext(ctx)
+	if util.IsUUID(identifier) {
+		q = q.Where("id = ?", identifier)
+	} else {
+		q = q.Where("username = ?", identifier)
+	}
+	var admin model.Admin
+	if err := q.First(&admin).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, kxlerrors.NotFound("not found: admin not found")
+		}
+		return nil, kxlerrors.Internal("db error")
+	}
+	return &admin, nil
+}
+
+func (s *UserService) SetAdminPassword(ctx context.Context, id, password string) error {
+	if s == nil || s.db == nil {
+		return kxlerrors.Internal("db not configured")
+	}
+	hashed, err := util.HashPassword(password)
+	if err != nil {
+		return kxlerrors.Internal("password hash error")
+	}
+	res := s.db.WithContext(ctx).Model(&model.Admin{}).Where("id = ?", id).Update("password_hash", hashed)
+	if res.Error != nil {
+		return kxlerrors.Internal("db error")
+	}
+	if res.RowsAffected == 0 {
+		return kxlerrors.NotFound("not found: admin not found")
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// stripVideoMetadata returns src with the metadata of an MP4 upload blanked
+// out: udta (GPS position, camera make and model, ...) and meta (QuickTime
+// and iTunes-style keys) boxes at the top level, in moov and in its tracks
+// are turned into free boxes of the same size. Sizes and sample offsets stay
+// valid, so the file plays as before without being rewritten. Stripping stops
+// at the first box that does not add up and copies the rest unchanged. The
+// stream is processed while it is read; close the result once done with it.
+// Other containers (WebM) are returned as-is.
+func stripVideoMetadata(ext string, src io.Reader) io.ReadCloser {
+	if ext != "mp4" {
+		return io.NopCloser(src)
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		w := bufio.NewWriterSize(pw, 64<<10)
+		r := bufio.NewReaderSize(src, 64<<10)
+		err := stripMP4Boxes(w, r, -1)
+		if errors.Is(err, errMalformedVideo) || errors.Is(err, io.ErrUnexpectedEOF) {
+			// Boxes that do not add up are left alone; the rest of the
+			// file (if any) is copied unchanged.
+			_, err = io.Copy(w, r)
+		}
+		if err == nil {
+			err = w.Flush()
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
+
+var errMalformedVideo = errors.New("malformed video")
+
+// stripMP4Boxes copies the boxes in the next n bytes of r (n < 0: up to EOF)
+// to w, blanking metadata boxes and descending into moov and trak. Write
+// errors are sticky in w and surface on the next copy or the final Flush.
+func stripMP4Boxes(w *bufio.Writer, r *bufio.Reader, n int64) error {
+	for n != 0 {
+		var hdr [16]byte
+		if n >= 0 && n < 8 {
+			return copyBody(w, r, n)
+		}
+		read, err := io.ReadFull(r, hdr[:8])
+		if err != nil {
+			w.Write(hdr[:read])
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				if n < 0 {
+					return nil
+				}
+				return io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		hlen := int64(8)
+		size := int64(binary.BigEndian.Uint32(hdr[:4]))
+		switch size {
+		case 1:
+			if m, err := io.ReadFull(r, hdr[8:16]); err != nil {
+				w.Write(hdr[:8+m])
+				return errMalformedVideo
+			}
+			hlen = 16
+			size = int64(binary.BigEndian.Uint64(hdr[8:16]))
+			if size < 0 {
+				w.Write(hdr[:hlen])
+				return errMalformedVideo
+			}
+		case 0:
+			// The box extends to the end of its parent; -1 is the file's end.
+			size = n
+		}
+		if (size >= 0 && size < hlen) || (n >= 0 && size > n) {
+			w.Write(hdr[:hlen])
+			return errMalformedVideo
+		}
+
+		typ := string(hdr[4:8])
+		switch typ {
+		case "udta", "meta":
+			copy(hdr[4:8], "free")
+		}
+		w.Write(hdr[:hlen])
+		body := int64(-1)
+		if size >= 0 {
+			body = size - hlen
+		}
+		switch typ {
+		case "udta", "meta":
+			err = copyBody(zeroWriter{w}, r, body)
+		case "moov", "trak":
+			err = stripMP4Boxes(w, r, body)
+		default:
+			err = copyBody(w, r, body)
+		}
+		if err != nil {
+			return err
+		}
+		if size < 0 {
+			return nil
+		}
+		if n > 0 {
+			n -= size
+		}
+	}
+	return nil
+}
+
+// copyBody copies n bytes of r to w, or everything up to EOF when n < 0.
+func copyBody(w io.Writer, r io.Reader, n int64) error {
+	if n < 0 {
+		_, err := io.Copy(w, r)
+		return err
+	}
+	if _, err := io.CopyN(w, r, n); err != nil {
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	return nil
+}
+
+// zeroWriter writes as many zero bytes as it is given.
+type zeroWriter struct{ w io.Writer }
+
+var zeroBlock [32 << 10]byte
+
+func (z zeroWriter) Write(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		k := len(p) - written
+		if k > len(zeroBlock) {
+			k = len(zeroBlock)
+		}
+		m, err := z.w.Write(zeroBlock[:k])
+		written += m
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
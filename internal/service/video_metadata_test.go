@@ -0,0 +1,74 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+// mp4Box builds a box with a 32-bit size header.
+func mp4Box(typ string, children ...[]byte) []byte {
+	body := bytes.Join(children, nil)
+	out := make([]byte, 8, 8+len(body))
+	binary.BigEndian.PutUint32(out, uint32(8+len(body)))
+	copy(out[4:], typ)
+	return append(out, body...)
+}
+
+func stripMP4(t *testing.T, data []byte) []byte {
+	t.Helper()
+	r := stripVideoMetadata("mp4", bytes.NewReader(data))
+	defer r.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != len(data) {
+		t.Fatalf("size changed: %d -> %d", len(data), len(out))
+	}
+	return out
+}
+
+func TestStripVideoMetadata_MP4(t *testing.T) {
+	gps := []byte("\xa9xyz+37.7749-122.4194/")
+	mdat := mp4Box("mdat", []byte("frame data udta meta"))
+	data := bytes.Join([][]byte{
+		mp4Box("ftyp", []byte("isom\x00\x00\x02\x00")),
+		mp4Box("moov",
+			mp4Box("mvhd", make([]byte, 12)),
+			mp4Box("udta", mp4Box("\xa9xyz", gps)),
+			mp4Box("trak", mp4Box("tkhd", make([]byte, 8)), mp4Box("meta", []byte("iPhone 15 Pro"))),
+			mp4Box("meta", mp4Box("keys", []byte("com.apple.quicktime.location.ISO6709"))),
+		),
+		mdat,
+	}, nil)
+
+	out := stripMP4(t, data)
+	for _, leak := range []string{"+37.7749", "iPhone", "com.apple.quicktime", "udta", "\xa9xyz"} {
+		if bytes.Contains(out[:len(out)-len(mdat)], []byte(leak)) {
+			t.Errorf("%q left in stripped file", leak)
+		}
+	}
+	if !bytes.HasSuffix(out, mdat) || !bytes.Contains(out, []byte("mvhd")) || !bytes.Contains(out, []byte("tkhd")) {
+		t.Errorf("media boxes changed: %q", out)
+	}
+	if n := bytes.Count(out, []byte("free")); n != 3 {
+		t.Errorf("got %d free boxes, want 3", n)
+	}
+}
+
+func TestStripVideoMetadata_MalformedPassthrough(t *testing.T) {
+	data := append(mp4Box("ftyp", []byte("isom")), mp4Box("moov", mp4Box("udta", []byte("x")))...)
+	// udta claims to be shorter than its own header.
+	binary.BigEndian.PutUint32(data[20:], 4)
+	if out := stripMP4(t, data); !bytes.Equal(out, data) {
+		t.Errorf("malformed file changed: %q", out)
+	}
+
+	webm := []byte("\x1a\x45\xdf\xa3 udta")
+	r := stripVideoMetadata("webm", bytes.NewReader(webm))
+	if out, _ := io.ReadAll(r); !bytes.Equal(out, webm) {
+		t.Errorf("webm changed: %q", out)
+	}
+}
@@ -0,0 +1,23 @@
+package util
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+const randomAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz23456789"
+
+// RandomString returns a cryptographically random string of n characters
+// drawn from an alphabet without easily confused glyphs (0/O, 1/l/I).
+func RandomString(n int) (string, error) {
+	out := make([]byte, n)
+	max := big.NewInt(int64(len(randomAlphabet)))
+	for i := range out {
+		idx, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		out[i] = randomAlphabet[idx.Int64()]
+	}
+	return string(out), nil
+}
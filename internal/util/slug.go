@@ -0,0 +1,68 @@
+package util
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+)
+
+// SlugMaxLen is the longest slug Slugify produces and ValidSlug accepts.
+const SlugMaxLen = 80
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+var pinyinArgs = pinyin.NewArgs()
+
+// Slugify turns a title into a URL slug: lowercase ASCII letters and digits
+// separated by hyphens. Chinese characters are transliterated to toneless
+// pinyin, one word per character ("开心乐 ERP 1.0" → "kai-xin-le-erp-1-0");
+// anything else becomes a separator. The result may be empty.
+func Slugify(s string) string {
+	words := []string{}
+	var cur strings.Builder
+	flush := func() {
+		if cur.Len() > 0 {
+			words = append(words, cur.String())
+			cur.Reset()
+		}
+	}
+	for _, r := range s {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			cur.WriteRune(unicode.ToLower(r))
+		case unicode.Is(unicode.Han, r):
+			flush()
+			if py := pinyin.SinglePinyin(r, pinyinArgs); len(py) > 0 && py[0] != "" {
+				words = append(words, py[0])
+			}
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	// Cut at a word boundary.
+	out := ""
+	for _, w := range words {
+		next := w
+		if out != "" {
+			next = out + "-" + w
+		}
+		if len(next) > SlugMaxLen {
+			if out == "" {
+				out = w[:SlugMaxLen]
+			}
+			break
+		}
+		out = next
+	}
+	return out
+}
+
+// ValidSlug reports whether s is a well-formed slug. UUIDs are rejected so a
+// slug can never be mistaken for an id.
+func ValidSlug(s string) bool {
+	return len(s) <= SlugMaxLen && slugPattern.MatchString(s) && !IsUUID(s)
+}
@@ -6,3 +6,9 @@ func NewUUID() string {
 	return uuid.NewString()
 }
 
+
+// IsUUID reports whether s is a canonical UUID string (safe to compare against uuid columns).
+func IsUUID(s string) bool {
+	_, err := uuid.Parse(s)
+	return err == nil && len(s) == 36
+}
@@ -0,0 +1,26 @@
+DROP TABLE IF EXISTS system_configs;
+DROP TABLE IF EXISTS friendly_links;
+DROP TABLE IF EXISTS partners;
+DROP TABLE IF EXISTS solutions;
+DROP TABLE IF EXISTS testimonials;
+DROP TABLE IF EXISTS banners;
+DROP TABLE IF EXISTS team_members;
+DROP TABLE IF EXISTS milestones;
+DROP TABLE IF EXISTS company_info;
+DROP TABLE IF EXISTS messages;
+DROP TABLE IF EXISTS case_projects;
+DROP TABLE IF EXISTS cases;
+DROP TABLE IF EXISTS article_tags;
+DROP TABLE IF EXISTS articles;
+DROP TABLE IF EXISTS project_tags;
+DROP TABLE IF EXISTS project_versions;
+DROP TABLE IF EXISTS project_media;
+DROP TABLE IF EXISTS project_features;
+DROP TABLE IF EXISTS projects;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS categories;
+DROP TABLE IF EXISTS admins;
+DROP TABLE IF EXISTS admin_role_permissions;
+DROP TABLE IF EXISTS admin_permissions;
+DROP TABLE IF EXISTS admin_roles;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,299 @@
+-- Baseline schema matching the GORM models in internal/model.
+
+CREATE TABLE IF NOT EXISTS users (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    username        VARCHAR(64)  NOT NULL,
+    email           VARCHAR(255) NOT NULL,
+    password_hash   VARCHAR(255) NOT NULL,
+    status          SMALLINT     NOT NULL DEFAULT 1,
+    session_version INTEGER      NOT NULL DEFAULT 0,
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS users_username_key ON users (username);
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON users (email);
+
+CREATE TABLE IF NOT EXISTS admin_roles (
+    code        VARCHAR(64)  PRIMARY KEY,
+    name        VARCHAR(128) NOT NULL,
+    description TEXT         NOT NULL DEFAULT '',
+    is_system   BOOLEAN      NOT NULL DEFAULT false,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS admin_permissions (
+    code        VARCHAR(64)  PRIMARY KEY,
+    name        VARCHAR(128) NOT NULL,
+    group_name  VARCHAR(64)  NOT NULL DEFAULT '',
+    description TEXT         NOT NULL DEFAULT '',
+    is_system   BOOLEAN      NOT NULL DEFAULT false,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS admin_role_permissions (
+    role_code       VARCHAR(64) NOT NULL REFERENCES admin_roles (code) ON DELETE CASCADE,
+    permission_code VARCHAR(64) NOT NULL REFERENCES admin_permissions (code) ON DELETE CASCADE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (role_code, permission_code)
+);
+
+CREATE TABLE IF NOT EXISTS admins (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    username      VARCHAR(64)  NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    role          VARCHAR(64)  NOT NULL DEFAULT 'admin',
+    status        SMALLINT     NOT NULL DEFAULT 1,
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at    TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS admins_username_key ON admins (username);
+
+CREATE TABLE IF NOT EXISTS categories (
+    id         SERIAL PRIMARY KEY,
+    name       VARCHAR(128) NOT NULL,
+    type       VARCHAR(32)  NOT NULL,
+    sort_order INTEGER      NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS categories_type_idx ON categories (type, sort_order);
+
+CREATE TABLE IF NOT EXISTS tags (
+    id         SERIAL PRIMARY KEY,
+    name       VARCHAR(128) NOT NULL,
+    type       VARCHAR(32)  NOT NULL,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS tags_type_idx ON tags (type);
+
+CREATE TABLE IF NOT EXISTS projects (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name        VARCHAR(255) NOT NULL,
+    description TEXT         NOT NULL DEFAULT '',
+    cover_image VARCHAR(512),
+    category_id INTEGER REFERENCES categories (id) ON DELETE SET NULL,
+    status      SMALLINT     NOT NULL DEFAULT 0,
+    sort_order  INTEGER      NOT NULL DEFAULT 0,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS projects_status_sort_idx ON projects (status, sort_order);
+CREATE INDEX IF NOT EXISTS projects_category_idx ON projects (category_id);
+
+CREATE TABLE IF NOT EXISTS project_features (
+    id          SERIAL PRIMARY KEY,
+    project_id  UUID         NOT NULL REFERENCES projects (id) ON DELETE CASCADE,
+    name        VARCHAR(255) NOT NULL,
+    description TEXT         NOT NULL DEFAULT '',
+    icon        VARCHAR(255),
+    sort_order  INTEGER      NOT NULL DEFAULT 0,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS project_features_project_idx ON project_features (project_id, sort_order);
+
+CREATE TABLE IF NOT EXISTS project_media (
+    id         SERIAL PRIMARY KEY,
+    project_id UUID         NOT NULL REFERENCES projects (id) ON DELETE CASCADE,
+    type       VARCHAR(32)  NOT NULL,
+    url        VARCHAR(512) NOT NULL,
+    title      VARCHAR(255),
+    sort_order INTEGER      NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS project_media_project_idx ON project_media (project_id, sort_order);
+
+CREATE TABLE IF NOT EXISTS project_versions (
+    id           SERIAL PRIMARY KEY,
+    project_id   UUID        NOT NULL REFERENCES projects (id) ON DELETE CASCADE,
+    version      VARCHAR(64) NOT NULL,
+    release_date TIMESTAMPTZ NOT NULL,
+    changelog    TEXT        NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS project_versions_project_idx ON project_versions (project_id, release_date DESC);
+
+CREATE TABLE IF NOT EXISTS project_tags (
+    project_id UUID    NOT NULL REFERENCES projects (id) ON DELETE CASCADE,
+    tag_id     INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (project_id, tag_id)
+);
+
+CREATE TABLE IF NOT EXISTS articles (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    title        VARCHAR(255) NOT NULL,
+    summary      TEXT         NOT NULL DEFAULT '',
+    content      TEXT         NOT NULL DEFAULT '',
+    cover_image  VARCHAR(512),
+    category_id  INTEGER REFERENCES categories (id) ON DELETE SET NULL,
+    view_count   INTEGER      NOT NULL DEFAULT 0,
+    status       SMALLINT     NOT NULL DEFAULT 0,
+    published_at TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS articles_status_published_idx ON articles (status, published_at DESC);
+CREATE INDEX IF NOT EXISTS articles_category_idx ON articles (category_id);
+
+CREATE TABLE IF NOT EXISTS article_tags (
+    article_id UUID    NOT NULL REFERENCES articles (id) ON DELETE CASCADE,
+    tag_id     INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (article_id, tag_id)
+);
+
+CREATE TABLE IF NOT EXISTS cases (
+    id                 UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    client_name        VARCHAR(255) NOT NULL,
+    cover_image        VARCHAR(512),
+    summary            TEXT         NOT NULL DEFAULT '',
+    background         TEXT         NOT NULL DEFAULT '',
+    solution           TEXT         NOT NULL DEFAULT '',
+    results            JSONB        NOT NULL DEFAULT '[]'::jsonb,
+    testimonial        TEXT,
+    testimonial_author VARCHAR(128),
+    testimonial_title  VARCHAR(128),
+    category_id        INTEGER REFERENCES categories (id) ON DELETE SET NULL,
+    status             SMALLINT     NOT NULL DEFAULT 0,
+    created_at         TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at         TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS cases_status_created_idx ON cases (status, created_at DESC);
+CREATE INDEX IF NOT EXISTS cases_category_idx ON cases (category_id);
+
+CREATE TABLE IF NOT EXISTS case_projects (
+    case_id    UUID NOT NULL REFERENCES cases (id) ON DELETE CASCADE,
+    project_id UUID NOT NULL REFERENCES projects (id) ON DELETE CASCADE,
+    PRIMARY KEY (case_id, project_id)
+);
+
+CREATE TABLE IF NOT EXISTS messages (
+    id         SERIAL PRIMARY KEY,
+    name       VARCHAR(128) NOT NULL,
+    company    VARCHAR(255),
+    phone      VARCHAR(64)  NOT NULL DEFAULT '',
+    email      VARCHAR(255) NOT NULL DEFAULT '',
+    content    TEXT         NOT NULL,
+    status     SMALLINT     NOT NULL DEFAULT 0,
+    note       TEXT,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS messages_status_idx ON messages (status, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS company_info (
+    id                 SERIAL PRIMARY KEY,
+    name               VARCHAR(255) NOT NULL DEFAULT '',
+    description        TEXT         NOT NULL DEFAULT '',
+    phone              VARCHAR(64)  NOT NULL DEFAULT '',
+    email              VARCHAR(255) NOT NULL DEFAULT '',
+    address            VARCHAR(512) NOT NULL DEFAULT '',
+    working_hours      VARCHAR(255) NOT NULL DEFAULT '',
+    map_coordinates    VARCHAR(128) NOT NULL DEFAULT '',
+    hero_title         VARCHAR(255) NOT NULL DEFAULT '',
+    hero_subtitle      VARCHAR(512) NOT NULL DEFAULT '',
+    stats_years        VARCHAR(32),
+    stats_projects     VARCHAR(32),
+    stats_clients      VARCHAR(32),
+    stats_satisfaction VARCHAR(32),
+    created_at         TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at         TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS milestones (
+    id         SERIAL PRIMARY KEY,
+    year       INTEGER     NOT NULL,
+    content    TEXT        NOT NULL,
+    sort_order INTEGER     NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS team_members (
+    id         SERIAL PRIMARY KEY,
+    name       VARCHAR(128) NOT NULL,
+    title      VARCHAR(128) NOT NULL DEFAULT '',
+    avatar     VARCHAR(512) NOT NULL DEFAULT '',
+    bio        TEXT         NOT NULL DEFAULT '',
+    sort_order INTEGER      NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS banners (
+    id         SERIAL PRIMARY KEY,
+    title      VARCHAR(255) NOT NULL,
+    subtitle   VARCHAR(512),
+    highlight  TEXT,
+    tag        VARCHAR(64),
+    image      VARCHAR(512),
+    link       VARCHAR(512),
+    link_text  VARCHAR(64)  NOT NULL DEFAULT '',
+    bg_class   VARCHAR(128) NOT NULL DEFAULT '',
+    sort_order INTEGER      NOT NULL DEFAULT 0,
+    is_visible BOOLEAN      NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS testimonials (
+    id         SERIAL PRIMARY KEY,
+    name       VARCHAR(128) NOT NULL,
+    title      VARCHAR(128),
+    company    VARCHAR(255),
+    avatar     VARCHAR(512),
+    content    TEXT         NOT NULL,
+    rating     INTEGER      NOT NULL DEFAULT 5,
+    sort_order INTEGER      NOT NULL DEFAULT 0,
+    is_visible BOOLEAN      NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS solutions (
+    id          SERIAL PRIMARY KEY,
+    name        VARCHAR(255) NOT NULL,
+    description TEXT         NOT NULL DEFAULT '',
+    icon        VARCHAR(255),
+    bg_class    VARCHAR(128) NOT NULL DEFAULT '',
+    link        VARCHAR(512) NOT NULL DEFAULT '',
+    sort_order  INTEGER      NOT NULL DEFAULT 0,
+    is_visible  BOOLEAN      NOT NULL DEFAULT true,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS partners (
+    id         SERIAL PRIMARY KEY,
+    name       VARCHAR(255) NOT NULL,
+    logo       VARCHAR(512),
+    website    VARCHAR(512),
+    sort_order INTEGER      NOT NULL DEFAULT 0,
+    is_visible BOOLEAN      NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS friendly_links (
+    id          SERIAL PRIMARY KEY,
+    name        VARCHAR(255) NOT NULL,
+    url         VARCHAR(512) NOT NULL,
+    logo        VARCHAR(512),
+    description TEXT,
+    sort_order  INTEGER      NOT NULL DEFAULT 0,
+    is_visible  BOOLEAN      NOT NULL DEFAULT true,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS system_configs (
+    id          SERIAL PRIMARY KEY,
+    group_name  VARCHAR(64)  NOT NULL DEFAULT '',
+    key         VARCHAR(128) NOT NULL,
+    value       TEXT         NOT NULL DEFAULT '',
+    description TEXT,
+    sort_order  INTEGER      NOT NULL DEFAULT 0,
+    is_public   BOOLEAN      NOT NULL DEFAULT false,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS system_configs_group_key_key ON system_configs (group_name, key);
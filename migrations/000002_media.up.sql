@@ -0,0 +1,27 @@
+-- Media library: one row per uploaded original. Derivatives (resized/WebP
+-- copies) are listed in `variants` and live and die with their original.
+
+CREATE TABLE IF NOT EXISTS media (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    path            VARCHAR(512) NOT NULL,
+    kind            VARCHAR(16)  NOT NULL,
+    mime            VARCHAR(128) NOT NULL,
+    size            BIGINT       NOT NULL DEFAULT 0,
+    width           INTEGER,
+    height          INTEGER,
+    sha256          CHAR(64)     NOT NULL,
+    original_name   VARCHAR(255) NOT NULL DEFAULT '',
+    uploader_type   VARCHAR(16)  NOT NULL DEFAULT '',
+    uploader_id     UUID,
+    variants        JSONB        NOT NULL DEFAULT '[]'::jsonb,
+    ref_count       INTEGER      NOT NULL DEFAULT 0,
+    last_scanned_at TIMESTAMPTZ,
+    orphaned_at     TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS media_path_key ON media (path);
+CREATE INDEX IF NOT EXISTS media_sha256_idx ON media (sha256);
+CREATE INDEX IF NOT EXISTS media_uploader_idx ON media (uploader_type, uploader_id);
+CREATE INDEX IF NOT EXISTS media_orphaned_idx ON media (orphaned_at) WHERE orphaned_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS media_created_idx ON media (created_at DESC);
@@ -0,0 +1,21 @@
+-- In-progress tus uploads. Received chunks are stored as separate objects
+-- (keys listed in `parts`) until the upload is complete and finalized into a
+-- regular media row.
+
+CREATE TABLE IF NOT EXISTS resumable_uploads (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind          VARCHAR(16)   NOT NULL,
+    upload_length BIGINT        NOT NULL,
+    upload_offset BIGINT        NOT NULL DEFAULT 0,
+    metadata      JSONB         NOT NULL DEFAULT '{}'::jsonb,
+    parts         JSONB         NOT NULL DEFAULT '[]'::jsonb,
+    status        VARCHAR(16)   NOT NULL DEFAULT 'uploading',
+    uploader_type VARCHAR(16)   NOT NULL DEFAULT '',
+    uploader_id   UUID,
+    media_id      UUID REFERENCES media (id) ON DELETE SET NULL,
+    url           VARCHAR(1024) NOT NULL DEFAULT '',
+    expires_at    TIMESTAMPTZ   NOT NULL,
+    created_at    TIMESTAMPTZ   NOT NULL DEFAULT now(),
+    updated_at    TIMESTAMPTZ   NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS resumable_uploads_expires_idx ON resumable_uploads (expires_at) WHERE status <> 'completed';
@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS media_sha256_idx ON media (sha256);
+DROP INDEX IF EXISTS media_kind_sha256_idx;
+ALTER TABLE media DROP COLUMN IF EXISTS upload_count;
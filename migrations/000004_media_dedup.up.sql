@@ -0,0 +1,7 @@
+-- Identical uploads share one stored file. upload_count is how many uploads
+-- were resolved to this row; deleting an upload releases one of them and the
+-- file is only removed once none remain.
+
+ALTER TABLE media ADD COLUMN IF NOT EXISTS upload_count INTEGER NOT NULL DEFAULT 1;
+CREATE INDEX IF NOT EXISTS media_kind_sha256_idx ON media (kind, sha256);
+DROP INDEX IF EXISTS media_sha256_idx;
@@ -0,0 +1,12 @@
+-- Storage used by each user's uploads. max_bytes / max_files override the
+-- configured defaults for one user when set.
+
+CREATE TABLE IF NOT EXISTS user_upload_quotas (
+    user_id     UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    used_bytes  BIGINT      NOT NULL DEFAULT 0,
+    used_files  INTEGER     NOT NULL DEFAULT 0,
+    max_bytes   BIGINT,
+    max_files   INTEGER,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS cases_search_idx;
+ALTER TABLE cases DROP COLUMN IF EXISTS search_vector;
+DROP INDEX IF EXISTS articles_search_idx;
+ALTER TABLE articles DROP COLUMN IF EXISTS search_vector;
+DROP INDEX IF EXISTS projects_search_idx;
+ALTER TABLE projects DROP COLUMN IF EXISTS search_vector;
@@ -0,0 +1,23 @@
+-- Full-text search vectors for site search. Titles/names weigh A, summaries
+-- B and bodies C so ts_rank puts title matches first. The 'simple'
+-- configuration does no stemming, which suits our mixed Chinese/English text.
+
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(description, '')), 'B')
+) STORED;
+CREATE INDEX IF NOT EXISTS projects_search_idx ON projects USING GIN (search_vector);
+
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(summary, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(content, '')), 'C')
+) STORED;
+CREATE INDEX IF NOT EXISTS articles_search_idx ON articles USING GIN (search_vector);
+
+ALTER TABLE cases ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(client_name, '')), 'A') ||
+    setweight(to_tsvector('simple', coalesce(summary, '')), 'B') ||
+    setweight(to_tsvector('simple', coalesce(background, '') || ' ' || coalesce(solution, '')), 'C')
+) STORED;
+CREATE INDEX IF NOT EXISTS cases_search_idx ON cases USING GIN (search_vector);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS cases_search_trgm_idx;
+DROP INDEX IF EXISTS articles_search_trgm_idx;
+DROP INDEX IF EXISTS projects_search_trgm_idx;
@@ -0,0 +1,20 @@
+-- Trigram indexes for SEARCH_MODE=trigram. Chinese text has no spaces for
+-- the full-text parser to split on, so this mode matches substrings
+-- (ILIKE) and trigram similarity instead; both are served by these indexes.
+-- The indexed expressions must match SearchEntity.document() in
+-- internal/service/search_entities.go. CJK characters only produce trigrams under a
+-- UTF-8 database locale (not C/POSIX); matching stays correct either way.
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS projects_search_trgm_idx ON projects USING GIN (
+    (coalesce(name, '') || ' ' || coalesce(description, '')) gin_trgm_ops
+);
+
+CREATE INDEX IF NOT EXISTS articles_search_trgm_idx ON articles USING GIN (
+    (coalesce(title, '') || ' ' || coalesce(summary, '') || ' ' || coalesce(content, '')) gin_trgm_ops
+);
+
+CREATE INDEX IF NOT EXISTS cases_search_trgm_idx ON cases USING GIN (
+    (coalesce(client_name, '') || ' ' || coalesce(summary, '') || ' ' || coalesce(background, '') || ' ' || coalesce(solution, '')) gin_trgm_ops
+);
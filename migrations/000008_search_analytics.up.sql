@@ -0,0 +1,26 @@
+-- Site search analytics: one row per search (first result page) and one per
+-- click on a result through /search/click. IPs are stored as salted hashes
+-- only, enough to count distinct visitors.
+
+CREATE TABLE IF NOT EXISTS search_query_logs (
+    id           BIGSERIAL PRIMARY KEY,
+    query        VARCHAR(200) NOT NULL,
+    search_type  VARCHAR(20)  NOT NULL DEFAULT '',
+    result_count INTEGER      NOT NULL DEFAULT 0,
+    ip_hash      VARCHAR(64)  NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS search_query_logs_created_at_idx ON search_query_logs (created_at);
+CREATE INDEX IF NOT EXISTS search_query_logs_query_idx ON search_query_logs (query, created_at);
+
+CREATE TABLE IF NOT EXISTS search_clicks (
+    id          BIGSERIAL PRIMARY KEY,
+    query       VARCHAR(200) NOT NULL,
+    result_type VARCHAR(20)  NOT NULL,
+    result_id   VARCHAR(64)  NOT NULL,
+    url         TEXT         NOT NULL,
+    ip_hash     VARCHAR(64)  NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS search_clicks_created_at_idx ON search_clicks (created_at);
+CREATE INDEX IF NOT EXISTS search_clicks_query_idx ON search_clicks (query, created_at);
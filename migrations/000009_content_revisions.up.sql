@@ -0,0 +1,14 @@
+-- Immutable snapshots of articles, projects and cases: every admin save
+-- writes the editable fields as they are after the save, numbered per item.
+
+CREATE TABLE IF NOT EXISTS content_revisions (
+    id           BIGSERIAL PRIMARY KEY,
+    content_type VARCHAR(20) NOT NULL,
+    content_id   UUID        NOT NULL,
+    version      INTEGER     NOT NULL,
+    data         JSONB       NOT NULL,
+    admin_id     UUID        REFERENCES admins (id) ON DELETE SET NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS content_revisions_content_version_key
+    ON content_revisions (content_type, content_id, version);
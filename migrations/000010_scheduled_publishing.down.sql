@@ -0,0 +1,17 @@
+DROP INDEX IF EXISTS cases_unpublish_at_idx;
+DROP INDEX IF EXISTS cases_publish_at_idx;
+DROP INDEX IF EXISTS projects_unpublish_at_idx;
+DROP INDEX IF EXISTS projects_publish_at_idx;
+DROP INDEX IF EXISTS articles_unpublish_at_idx;
+DROP INDEX IF EXISTS articles_publish_at_idx;
+
+ALTER TABLE cases DROP COLUMN IF EXISTS unpublish_at;
+ALTER TABLE cases DROP COLUMN IF EXISTS publish_at;
+ALTER TABLE cases DROP COLUMN IF EXISTS published_at;
+
+ALTER TABLE projects DROP COLUMN IF EXISTS unpublish_at;
+ALTER TABLE projects DROP COLUMN IF EXISTS publish_at;
+ALTER TABLE projects DROP COLUMN IF EXISTS published_at;
+
+ALTER TABLE articles DROP COLUMN IF EXISTS unpublish_at;
+ALTER TABLE articles DROP COLUMN IF EXISTS publish_at;
@@ -0,0 +1,27 @@
+-- Scheduled publishing: publish_at / unpublish_at are applied by the
+-- background scheduler, which flips status and sets published_at. Projects
+-- and cases get published_at too, so every public query can require
+-- published_at <= now(); content already online counts as published when
+-- it was created.
+
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ;
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS unpublish_at TIMESTAMPTZ;
+
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS published_at TIMESTAMPTZ;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ;
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS unpublish_at TIMESTAMPTZ;
+
+ALTER TABLE cases ADD COLUMN IF NOT EXISTS published_at TIMESTAMPTZ;
+ALTER TABLE cases ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ;
+ALTER TABLE cases ADD COLUMN IF NOT EXISTS unpublish_at TIMESTAMPTZ;
+
+UPDATE articles SET published_at = created_at WHERE status = 1 AND published_at IS NULL;
+UPDATE projects SET published_at = created_at WHERE status = 1 AND published_at IS NULL;
+UPDATE cases SET published_at = created_at WHERE status = 1 AND published_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS articles_publish_at_idx ON articles (publish_at) WHERE publish_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS articles_unpublish_at_idx ON articles (unpublish_at) WHERE unpublish_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS projects_publish_at_idx ON projects (publish_at) WHERE publish_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS projects_unpublish_at_idx ON projects (unpublish_at) WHERE unpublish_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS cases_publish_at_idx ON cases (publish_at) WHERE publish_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS cases_unpublish_at_idx ON cases (unpublish_at) WHERE unpublish_at IS NOT NULL;
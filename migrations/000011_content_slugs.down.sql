@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS content_slug_redirects;
+
+DROP INDEX IF EXISTS cases_slug_key;
+DROP INDEX IF EXISTS projects_slug_key;
+DROP INDEX IF EXISTS articles_slug_key;
+
+ALTER TABLE cases DROP COLUMN IF EXISTS slug;
+ALTER TABLE projects DROP COLUMN IF EXISTS slug;
+ALTER TABLE articles DROP COLUMN IF EXISTS slug;
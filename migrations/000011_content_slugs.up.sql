@@ -0,0 +1,20 @@
+-- Human-readable URLs: slug is unique per content type once set; rows
+-- created before this migration keep '' until saved again or filled in by
+-- `kxlctl slugs backfill`. Replaced slugs are kept in content_slug_redirects
+-- so old links keep working.
+
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS slug VARCHAR(80) NOT NULL DEFAULT '';
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS slug VARCHAR(80) NOT NULL DEFAULT '';
+ALTER TABLE cases ADD COLUMN IF NOT EXISTS slug VARCHAR(80) NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS articles_slug_key ON articles (slug) WHERE slug <> '';
+CREATE UNIQUE INDEX IF NOT EXISTS projects_slug_key ON projects (slug) WHERE slug <> '';
+CREATE UNIQUE INDEX IF NOT EXISTS cases_slug_key ON cases (slug) WHERE slug <> '';
+
+CREATE TABLE IF NOT EXISTS content_slug_redirects (
+    content_type VARCHAR(20) NOT NULL,
+    slug         VARCHAR(80) NOT NULL,
+    content_id   UUID        NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (content_type, slug)
+);
@@ -0,0 +1,6 @@
+ALTER TABLE cases DROP COLUMN IF EXISTS solution_html;
+ALTER TABLE cases DROP COLUMN IF EXISTS background_html;
+ALTER TABLE cases DROP COLUMN IF EXISTS content_format;
+
+ALTER TABLE articles DROP COLUMN IF EXISTS content_html;
+ALTER TABLE articles DROP COLUMN IF EXISTS content_format;
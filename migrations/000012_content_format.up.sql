@@ -0,0 +1,12 @@
+-- Markdown authoring: content_format says how article content and case
+-- background/solution are written. The *_html columns cache the rendered
+-- Markdown and stay empty for HTML content, which is served as stored.
+
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS content_format VARCHAR(10) NOT NULL DEFAULT 'html'
+    CHECK (content_format IN ('html', 'markdown'));
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS content_html TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE cases ADD COLUMN IF NOT EXISTS content_format VARCHAR(10) NOT NULL DEFAULT 'html'
+    CHECK (content_format IN ('html', 'markdown'));
+ALTER TABLE cases ADD COLUMN IF NOT EXISTS background_html TEXT NOT NULL DEFAULT '';
+ALTER TABLE cases ADD COLUMN IF NOT EXISTS solution_html TEXT NOT NULL DEFAULT '';
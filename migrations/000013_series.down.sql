@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS series_items;
+DROP TABLE IF EXISTS series;
@@ -0,0 +1,29 @@
+-- Series and collections: ordered groups of articles (and optionally
+-- projects and cases). A series is read in order ("Part N of M"); a
+-- collection is a curated list. Items point at content by type and id, so
+-- deleted or unpublished content is skipped when a series is read.
+
+CREATE TABLE IF NOT EXISTS series (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind        VARCHAR(16)  NOT NULL DEFAULT 'series'
+        CHECK (kind IN ('series', 'collection')),
+    title       VARCHAR(255) NOT NULL,
+    slug        VARCHAR(80)  NOT NULL,
+    description TEXT         NOT NULL DEFAULT '',
+    cover_image VARCHAR(512),
+    is_visible  BOOLEAN      NOT NULL DEFAULT true,
+    sort_order  INTEGER      NOT NULL DEFAULT 0,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS series_slug_key ON series (slug);
+
+CREATE TABLE IF NOT EXISTS series_items (
+    series_id    UUID        NOT NULL REFERENCES series (id) ON DELETE CASCADE,
+    content_type VARCHAR(20) NOT NULL CHECK (content_type IN ('article', 'project', 'case')),
+    content_id   UUID        NOT NULL,
+    position     INTEGER     NOT NULL,
+    PRIMARY KEY (series_id, content_type, content_id)
+);
+CREATE INDEX IF NOT EXISTS series_items_series_idx ON series_items (series_id, position);
+CREATE INDEX IF NOT EXISTS series_items_content_idx ON series_items (content_type, content_id);
@@ -0,0 +1,4 @@
+-- summary_generated marks summaries excerpted from the content rather than
+-- written by an editor; those are regenerated whenever the article is saved.
+
+ALTER TABLE articles ADD COLUMN IF NOT EXISTS summary_generated BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,10 @@
+// Package migrations embeds the versioned SQL schema migrations.
+//
+// Files are named `<version>_<name>.up.sql` / `<version>_<name>.down.sql` and
+// are applied in ascending version order by `kxl-api migrate`.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
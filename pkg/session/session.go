@@ -132,6 +132,75 @@ func newSID() (string, error) {
 	return hex.EncodeToString(b), nil
 }
 
+const (
+	KindUser  = "user"
+	KindAdmin = "admin"
+)
+
+// Info describes a stored session as seen by operators (see cmd/kxlctl).
+type Info struct {
+	SID       string        `json:"sid"`
+	Kind      string        `json:"kind"`
+	SubjectID string        `json:"subject_id"`
+	TTL       time.Duration `json:"ttl"`
+}
+
+// ListSessions scans all sessions of the given kind ("user" or "admin").
+// When subjectID is non-empty only sessions belonging to that user/admin are returned.
+func (m *Manager) ListSessions(ctx context.Context, kind, subjectID string) ([]Info, error) {
+	if kind != KindUser && kind != KindAdmin {
+		return nil, fmt.Errorf("unknown session kind %q", kind)
+	}
+	prefix := m.Prefix + kind + ":"
+	out := []Info{}
+	iter := m.Client.Scan(ctx, 0, prefix+"*", 200).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		raw, err := m.Client.Get(ctx, key).Bytes()
+		if err != nil {
+			// Expired between SCAN and GET.
+			continue
+		}
+		info := Info{SID: key[len(prefix):], Kind: kind}
+		if kind == KindUser {
+			var s UserSession
+			if json.Unmarshal(raw, &s) == nil {
+				info.SubjectID = s.UserID
+			}
+		} else {
+			var s AdminSession
+			if json.Unmarshal(raw, &s) == nil {
+				info.SubjectID = s.AdminID
+			}
+		}
+		if subjectID != "" && info.SubjectID != subjectID {
+			continue
+		}
+		if ttl, err := m.Client.TTL(ctx, key).Result(); err == nil {
+			info.TTL = ttl
+		}
+		out = append(out, info)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeleteSessionsFor removes every session of the given kind owned by subjectID.
+func (m *Manager) DeleteSessionsFor(ctx context.Context, kind, subjectID string) (int, error) {
+	rows, err := m.ListSessions(ctx, kind, subjectID)
+	if err != nil {
+		return 0, err
+	}
+	for _, r := range rows {
+		if err := m.Client.Del(ctx, m.Prefix+kind+":"+r.SID).Err(); err != nil {
+			return 0, err
+		}
+	}
+	return len(rows), nil
+}
+
 func (m *Manager) CookieInfo() string {
 	return fmt.Sprintf("user_cookie=%s admin_cookie=%s secure=%v", m.UserCookieName, m.AdminCookieName, m.CookieSecure)
 }
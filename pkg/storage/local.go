@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Local stores files under a directory on the local filesystem.
+type Local struct {
+	dir string
+}
+
+func NewLocal(dir string) *Local {
+	return &Local{dir: dir}
+}
+
+// Dir returns the root directory, used by the router to serve files directly.
+func (l *Local) Dir() string {
+	return l.dir
+}
+
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	full, err := l.resolve(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temp file in the same directory and rename so readers never
+	// observe a partially written file.
+	tmp, err := os.CreateTemp(filepath.Dir(full), ".upload-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), full); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+func (l *Local) Open(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	obj, err := l.Stat(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	full, _ := l.resolve(obj.Key)
+	f, err := os.Open(full)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, err
+	}
+	return f, obj, nil
+}
+
+func (l *Local) Stat(ctx context.Context, key string) (*Object, error) {
+	full, err := l.resolve(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(full)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	// Directories are not objects; treat them like a missing key so both
+	// drivers behave the same.
+	if info.IsDir() {
+		return nil, ErrNotFound
+	}
+	key, _ = CleanKey(key)
+	return &Object{
+		Key:         key,
+		Size:        info.Size(),
+		ContentType: mime.TypeByExtension(path.Ext(key)),
+		ModTime:     info.ModTime(),
+	}, nil
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	if _, err := l.Stat(ctx, key); err != nil {
+		return err
+	}
+	full, _ := l.resolve(key)
+	if err := os.Remove(full); err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (l *Local) resolve(key string) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	baseAbs, err := filepath.Abs(l.dir)
+	if err != nil {
+		return "", err
+	}
+	fullAbs, err := filepath.Abs(filepath.Join(baseAbs, filepath.FromSlash(key)))
+	if err != nil {
+		return "", err
+	}
+	// Disallow directory traversal.
+	if !strings.HasPrefix(fullAbs, baseAbs+string(os.PathSeparator)) {
+		return "", ErrInvalidKey
+	}
+	return fullAbs, nil
+}
@@ -0,0 +1,159 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/linkyfish/kxl_backend_go/internal/config"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3 stores files in an S3-compatible bucket (AWS S3, MinIO, OSS, COS...).
+type S3 struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+// NewS3 connects to the configured endpoint and verifies that the bucket
+// exists so misconfiguration fails at startup rather than on first upload.
+func NewS3(cfg config.S3Config) (*S3, error) {
+	endpoint := strings.TrimSpace(cfg.Endpoint)
+	bucket := strings.TrimSpace(cfg.Bucket)
+	if endpoint == "" || bucket == "" {
+		return nil, errors.New("storage: s3 endpoint and bucket are required")
+	}
+
+	secure := cfg.UseSSL
+	// Accept "https://host:port" as well as a bare "host:port".
+	if strings.Contains(endpoint, "://") {
+		u, err := url.Parse(endpoint)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("storage: invalid s3 endpoint %q", cfg.Endpoint)
+		}
+		secure = u.Scheme == "https"
+		endpoint = u.Host
+	}
+
+	lookup := minio.BucketLookupAuto
+	if cfg.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure:       secure,
+		Region:       cfg.Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ok, err := client.BucketExists(ctx, bucket)
+	if err != nil {
+		return nil, fmt.Errorf("storage: check bucket %q: %w", bucket, err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("storage: bucket %q does not exist", bucket)
+	}
+
+	return &S3{
+		client: client,
+		bucket: bucket,
+		prefix: strings.Trim(strings.TrimSpace(cfg.Prefix), "/"),
+	}, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	name, err := s.objectName(key)
+	if err != nil {
+		return err
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	_, err = s.client.PutObject(ctx, s.bucket, name, r, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+func (s *S3) Open(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	name, err := s.objectName(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	obj, err := s.client.GetObject(ctx, s.bucket, name, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, mapS3Error(err)
+	}
+	// GetObject is lazy; Stat performs the request and surfaces NoSuchKey.
+	info, err := obj.Stat()
+	if err != nil {
+		_ = obj.Close()
+		return nil, nil, mapS3Error(err)
+	}
+	return obj, s.toObject(key, info), nil
+}
+
+func (s *S3) Stat(ctx context.Context, key string) (*Object, error) {
+	name, err := s.objectName(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := s.client.StatObject(ctx, s.bucket, name, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, mapS3Error(err)
+	}
+	return s.toObject(key, info), nil
+}
+
+// Delete removes the object. S3 deletes are idempotent, so the object is
+// stat'ed first to report ErrNotFound the same way the local driver does.
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if _, err := s.Stat(ctx, key); err != nil {
+		return err
+	}
+	name, _ := s.objectName(key)
+	if err := s.client.RemoveObject(ctx, s.bucket, name, minio.RemoveObjectOptions{}); err != nil {
+		return mapS3Error(err)
+	}
+	return nil
+}
+
+func (s *S3) objectName(key string) (string, error) {
+	key, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	if s.prefix == "" {
+		return key, nil
+	}
+	return path.Join(s.prefix, key), nil
+}
+
+func (s *S3) toObject(key string, info minio.ObjectInfo) *Object {
+	key, _ = CleanKey(key)
+	return &Object{
+		Key:         key,
+		Size:        info.Size,
+		ContentType: info.ContentType,
+		ModTime:     info.LastModified,
+	}
+}
+
+func mapS3Error(err error) error {
+	resp := minio.ToErrorResponse(err)
+	if resp.Code == "NoSuchKey" || resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+// Package storage abstracts where uploaded files live so that several API
+// instances can share them. Keys are slash-separated paths relative to the
+// storage root, e.g. "images/2024/05/<uuid>.jpg".
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/linkyfish/kxl_backend_go/internal/config"
+)
+
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+var (
+	ErrNotFound   = errors.New("storage: object not found")
+	ErrInvalidKey = errors.New("storage: invalid key")
+)
+
+// Object describes a stored file.
+type Object struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Storage is implemented by every upload backend. Implementations must return
+// ErrNotFound for missing keys and ErrInvalidKey for keys rejected by CleanKey.
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+	Stat(ctx context.Context, key string) (*Object, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// New builds the driver selected by cfg.Uploads.Driver.
+func New(cfg *config.Config) (Storage, error) {
+	driver := strings.ToLower(strings.TrimSpace(cfg.Uploads.Driver))
+	switch driver {
+	case "", DriverLocal:
+		dir := strings.TrimSpace(cfg.Uploads.Dir)
+		if dir == "" {
+			dir = "uploads"
+		}
+		return NewLocal(dir), nil
+	case DriverS3:
+		return NewS3(cfg.Uploads.S3)
+	default:
+		return nil, fmt.Errorf("storage: unknown driver %q", cfg.Uploads.Driver)
+	}
+}
+
+// CleanKey normalizes a key and rejects anything that could escape the
+// storage root (empty segments, ".", "..", NUL bytes).
+func CleanKey(key string) (string, error) {
+	key = strings.TrimSpace(key)
+	key = strings.ReplaceAll(key, "\\", "/")
+	key = strings.TrimPrefix(key, "/")
+	if key == "" || strings.Contains(key, "\x00") {
+		return "", ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return "", ErrInvalidKey
+		}
+	}
+	return key, nil
+}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/linkyfish/kxl_backend_go/internal/config"
+)
+
+func TestCleanKey(t *testing.T) {
+	ok := map[string]string{
+		"images/2024/05/a.jpg": "images/2024/05/a.jpg",
+		"/images/a.jpg":        "images/a.jpg",
+		`images\2024\a.jpg`:    "images/2024/a.jpg",
+		"  videos/x.mp4  ":     "videos/x.mp4",
+	}
+	for in, want := range ok {
+		got, err := CleanKey(in)
+		if err != nil || got != want {
+			t.Errorf("CleanKey(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "/", "../etc/passwd", "images/../../x", "images//a.jpg", "./a", "a\x00b"} {
+		if _, err := CleanKey(in); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("CleanKey(%q) err = %v; want ErrInvalidKey", in, err)
+		}
+	}
+}
+
+func TestLocal(t *testing.T) {
+	testDriver(t, NewLocal(t.TempDir()))
+}
+
+// TestS3 runs the same checks against a real bucket, e.g. a local MinIO:
+//
+//	docker run -p 9000:9000 minio/minio server /data
+//	KXL_TEST_S3_ENDPOINT=127.0.0.1:9000 KXL_TEST_S3_BUCKET=test \
+//	KXL_TEST_S3_ACCESS_KEY=minioadmin KXL_TEST_S3_SECRET_KEY=minioadmin go test ./pkg/storage
+func TestS3(t *testing.T) {
+	endpoint := os.Getenv("KXL_TEST_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("KXL_TEST_S3_ENDPOINT not set")
+	}
+	s, err := NewS3(config.S3Config{
+		Endpoint:  endpoint,
+		Bucket:    os.Getenv("KXL_TEST_S3_BUCKET"),
+		AccessKey: os.Getenv("KXL_TEST_S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("KXL_TEST_S3_SECRET_KEY"),
+		PathStyle: true,
+		Prefix:    "kxl-test",
+	})
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+	testDriver(t, s)
+}
+
+func testDriver(t *testing.T, s Storage) {
+	t.Helper()
+	ctx := context.Background()
+	key := "images/2024/05/test.txt"
+	body := []byte("hello storage")
+
+	if err := s.Put(ctx, key, bytes.NewReader(body), int64(len(body)), "text/plain"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	obj, err := s.Stat(ctx, key)
+	if err != nil || obj.Size != int64(len(body)) {
+		t.Fatalf("Stat = %+v, %v", obj, err)
+	}
+
+	rc, _, err := s.Open(ctx, key)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	got, _ := io.ReadAll(rc)
+	_ = rc.Close()
+	if !bytes.Equal(got, body) {
+		t.Fatalf("Open content = %q", got)
+	}
+
+	// A "directory" is never an object, on either driver.
+	if err := s.Delete(ctx, "images/2024"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Delete(dir) err = %v; want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, "../outside"); !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("Delete(traversal) err = %v; want ErrInvalidKey", err)
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := s.Delete(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("second Delete err = %v; want ErrNotFound", err)
+	}
+	if _, _, err := s.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Open after delete err = %v; want ErrNotFound", err)
+	}
+}